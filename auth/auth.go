@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"jmrashed/apps/userApp/model"
@@ -16,10 +20,12 @@ import (
 )
 
 var (
-	jwtSecret        = []byte(os.Getenv("JWT_SECRET"))
-	refreshSecret    = []byte(os.Getenv("REFRESH_SECRET"))
-	accessTokenTTL   = 15 * time.Minute
-	refreshTokenTTL  = 7 * 24 * time.Hour
+	jwtSecret             = []byte(os.Getenv("JWT_SECRET"))
+	refreshSecret         = []byte(os.Getenv("REFRESH_SECRET"))
+	inviteSecret          = []byte(os.Getenv("INVITE_SECRET"))
+	accessTokenTTL        = 15 * time.Minute
+	refreshTokenTTL       = 7 * 24 * time.Hour
+	impersonationTokenTTL = 10 * time.Minute
 )
 
 func init() {
@@ -29,6 +35,9 @@ func init() {
 	if len(refreshSecret) == 0 {
 		refreshSecret = []byte("your-refresh-secret") // Default for development
 	}
+	if len(inviteSecret) == 0 {
+		inviteSecret = []byte("your-invite-secret") // Default for development
+	}
 }
 
 // Claims represents JWT claims
@@ -38,9 +47,22 @@ type Claims struct {
 	Email       string   `json:"email"`
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
+	Act         *Actor   `json:"act,omitempty"` // Set when an admin is impersonating the subject
 	jwt.StandardClaims
 }
 
+// Actor identifies the user acting on behalf of the token subject (RFC 8693 "act" claim)
+type Actor struct {
+	Subject  string `json:"sub"`
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// IsImpersonated reports whether the token was issued for an impersonation session
+func (c *Claims) IsImpersonated() bool {
+	return c.Act != nil
+}
+
 // RefreshClaims represents refresh token claims
 type RefreshClaims struct {
 	UserID int    `json:"user_id"`
@@ -119,6 +141,51 @@ func GenerateTokens(user model.User) (*model.AuthResponse, error) {
 	}, nil
 }
 
+// GenerateImpersonationToken generates a short-lived access token for user on behalf of impersonator.
+// No refresh token is issued, so the session ends when the access token expires.
+func GenerateImpersonationToken(user model.User, impersonator *Claims) (*model.ImpersonationResponse, error) {
+	var roles []string
+	var permissions []string
+
+	for _, role := range user.Roles {
+		roles = append(roles, role.Name)
+		for _, perm := range role.Permissions {
+			permissions = append(permissions, perm.Name)
+		}
+	}
+
+	claims := &Claims{
+		UserID:      user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Roles:       roles,
+		Permissions: permissions,
+		Act: &Actor{
+			Subject:  fmt.Sprintf("%d", impersonator.UserID),
+			UserID:   impersonator.UserID,
+			Username: impersonator.Username,
+		},
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(impersonationTokenTTL).Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Subject:   fmt.Sprintf("%d", user.ID),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ImpersonationResponse{
+		User:           user,
+		AccessToken:    tokenString,
+		ExpiresIn:      int64(impersonationTokenTTL.Seconds()),
+		ImpersonatorID: impersonator.UserID,
+	}, nil
+}
+
 // ValidateAccessToken validates and parses an access token
 func ValidateAccessToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
@@ -159,6 +226,51 @@ func ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
 	return nil, errors.New("invalid refresh token")
 }
 
+// GenerateInviteCode signs an invitation ID and expiry into an opaque, URL-safe code
+func GenerateInviteCode(invitationID int, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d", invitationID, expiresAt.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + signInvite(payload)
+}
+
+// ParseInviteCode verifies an invite code's signature and expiry and returns the invitation ID
+func ParseInviteCode(code string) (int, error) {
+	parts := strings.Split(code, ".")
+	if len(parts) != 2 {
+		return 0, errors.New("malformed invite code")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return 0, errors.New("malformed invite code")
+	}
+	if !hmac.Equal([]byte(signInvite(string(payload))), []byte(parts[1])) {
+		return 0, errors.New("invalid invite code")
+	}
+
+	var invitationID int
+	var expiresAt int64
+	if _, err := fmt.Sscanf(string(payload), "%d.%d", &invitationID, &expiresAt); err != nil {
+		return 0, errors.New("malformed invite code")
+	}
+	if time.Now().Unix() > expiresAt {
+		return 0, errors.New("invite code has expired")
+	}
+
+	return invitationID, nil
+}
+
+func signInvite(payload string) string {
+	mac := hmac.New(sha256.New, inviteSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HashToken returns the hex SHA-256 of a token, for storing tokens without keeping them usable
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
 // GenerateSecureToken generates a cryptographically secure random token
 func GenerateSecureToken(length int) (string, error) {
 	bytes := make([]byte, length)
@@ -141,4 +141,68 @@ func TestGenerateSecureToken(t *testing.T) {
 	token2, err := GenerateSecureToken(32)
 	assert.NoError(t, err)
 	assert.NotEqual(t, token1, token2) // Should be different each time
-}
\ No newline at end of file
+}
+
+func TestGenerateImpersonationToken(t *testing.T) {
+	user := model.User{
+		ID:       2,
+		Username: "targetuser",
+		Email:    "target@example.com",
+		Roles: []model.Role{
+			{
+				ID:   2,
+				Name: "user",
+				Permissions: []model.Permission{
+					{
+						ID:   4,
+						Name: "read_todos",
+					},
+				},
+			},
+		},
+	}
+	admin := &Claims{UserID: 1, Username: "admin", Roles: []string{"admin"}}
+
+	response, err := GenerateImpersonationToken(user, admin)
+	assert.NoError(t, err)
+	assert.Equal(t, admin.UserID, response.ImpersonatorID)
+	assert.LessOrEqual(t, response.ExpiresIn, int64(accessTokenTTL.Seconds()))
+
+	claims, err := ValidateAccessToken(response.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.True(t, claims.IsImpersonated())
+	assert.Equal(t, admin.UserID, claims.Act.UserID)
+	assert.Equal(t, "admin", claims.Act.Username)
+	assert.Contains(t, claims.Permissions, "read_todos")
+	assert.NotContains(t, claims.Roles, "admin")
+	assert.WithinDuration(t, time.Now().Add(impersonationTokenTTL), time.Unix(claims.ExpiresAt, 0), 5*time.Second)
+}
+
+func TestInviteCode(t *testing.T) {
+	code := GenerateInviteCode(42, time.Now().Add(time.Hour))
+
+	id, err := ParseInviteCode(code)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	// Tampered signature
+	_, err = ParseInviteCode(code + "x")
+	assert.Error(t, err)
+
+	// Expired code
+	expired := GenerateInviteCode(42, time.Now().Add(-time.Minute))
+	_, err = ParseInviteCode(expired)
+	assert.Error(t, err)
+
+	// Malformed code
+	_, err = ParseInviteCode("not-a-code")
+	assert.Error(t, err)
+}
+
+func TestHashToken(t *testing.T) {
+	hash := HashToken("token")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashToken("token"))
+	assert.NotEqual(t, hash, HashToken("other"))
+}
@@ -91,10 +91,128 @@ func (db *DB) InitializeSchema() error {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
 
+	// Bring tables created by earlier releases up to date
+	if err := db.ensureColumns(); err != nil {
+		return err
+	}
+
+	if err := db.ensureIndexes(); err != nil {
+		return err
+	}
+
 	log.Println("Database schema initialized successfully")
 	return nil
 }
 
+// columnMigration adds a column to a table created before the column existed in schema.sql
+type columnMigration struct {
+	Table      string
+	Column     string
+	Definition string
+}
+
+// columnMigrations must mirror the column definitions in schema.sql
+var columnMigrations = []columnMigration{
+	{"users", "deletion_scheduled_at", "TIMESTAMP NULL DEFAULT NULL"},
+	{"users", "avatar_key", "VARCHAR(255) NULL DEFAULT NULL"},
+	{"users", "avatar_url", "VARCHAR(512) NULL DEFAULT NULL"},
+	{"todos", "due_at", "TIMESTAMP NULL DEFAULT NULL"},
+	{"todos", "remind_at", "TIMESTAMP NULL DEFAULT NULL"},
+	{"todos", "reminded_at", "TIMESTAMP NULL DEFAULT NULL"},
+	{"todos", "completed_at", "TIMESTAMP NULL DEFAULT NULL"},
+	{"todos", "series_id", "INT NULL DEFAULT NULL"},
+	{"todos", "occurrence_at", "TIMESTAMP NULL DEFAULT NULL"},
+	{"todos", "project_id", "INT NULL DEFAULT NULL"},
+	{"todos", "parent_id", "INT NULL DEFAULT NULL"},
+	{"todos", "auto_complete", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	{"todos", "status", "VARCHAR(50) NOT NULL DEFAULT 'todo'"},
+	{"todos", "board_position", "INT NOT NULL DEFAULT 0"},
+	{"todos", "position", "VARCHAR(255) CHARACTER SET ascii COLLATE ascii_bin NOT NULL DEFAULT ''"},
+	{"todos", "priority", "TINYINT NOT NULL DEFAULT 0"},
+	{"todos", "archived_at", "TIMESTAMP NULL DEFAULT NULL"},
+	{"todos", "deleted_at", "TIMESTAMP NULL DEFAULT NULL"},
+	{"user_preferences", "auto_archive_days", "INT NOT NULL DEFAULT 0"},
+	{"user_preferences", "enforce_dependencies", "BOOLEAN NOT NULL DEFAULT FALSE"},
+}
+
+// columnBackfills fill in a column from existing data right after columnMigrations adds it,
+// keyed by table.column
+var columnBackfills = map[string]string{
+	"todos.status": "UPDATE todos SET status = 'done' WHERE completed = true",
+	// Rank existing todos in ID order with fixed-width base-36 keys; the trailing 1 keeps
+	// them valid rank keys, which never end in 0
+	"todos.position": "UPDATE todos SET position = CONCAT(LOWER(LPAD(CONV(id, 10, 36), 8, '0')), '1')",
+}
+
+// indexMigration adds an index to a table created before the index existed in schema.sql
+type indexMigration struct {
+	Table   string
+	Name    string
+	Columns string
+}
+
+// indexMigrations must mirror the index definitions in schema.sql
+var indexMigrations = []indexMigration{
+	{"todos", "idx_todos_due_at", "user_id, due_at"},
+	{"todos", "idx_todos_remind_at", "remind_at"},
+	{"todos", "idx_todos_series", "series_id, occurrence_at"},
+	{"todos", "idx_todos_project", "project_id, completed"},
+	{"todos", "idx_todos_parent", "parent_id, completed"},
+	{"todos", "idx_todos_board", "project_id, status, board_position"},
+	{"todos", "idx_todos_position", "user_id, position"},
+	{"todos", "idx_todos_priority", "user_id, completed, priority"},
+	{"todos", "idx_todos_archived", "user_id, archived_at"},
+	{"todos", "idx_todos_deleted_at", "deleted_at"},
+}
+
+// ensureColumns adds any column from columnMigrations missing from an existing table
+func (db *DB) ensureColumns() error {
+	for _, m := range columnMigrations {
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM information_schema.COLUMNS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?`, m.Table, m.Column).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to inspect column %s.%s: %w", m.Table, m.Column, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.Table, m.Column, m.Definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.Table, m.Column, err)
+		}
+		log.Printf("Added column %s.%s", m.Table, m.Column)
+
+		if backfill, ok := columnBackfills[m.Table+"."+m.Column]; ok {
+			if _, err := db.Exec(backfill); err != nil {
+				return fmt.Errorf("failed to backfill column %s.%s: %w", m.Table, m.Column, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ensureIndexes adds any index from indexMigrations missing from an existing table
+func (db *DB) ensureIndexes() error {
+	for _, m := range indexMigrations {
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM information_schema.STATISTICS
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?`, m.Table, m.Name).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to inspect index %s.%s: %w", m.Table, m.Name, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("CREATE INDEX %s ON %s (%s)", m.Name, m.Table, m.Columns)); err != nil {
+			return fmt.Errorf("failed to add index %s.%s: %w", m.Table, m.Name, err)
+		}
+		log.Printf("Added index %s.%s", m.Table, m.Name)
+	}
+	return nil
+}
+
 // GetDefaultConfig returns default database configuration
 func GetDefaultConfig() Config {
 	return Config{
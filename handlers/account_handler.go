@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type AccountHandler struct {
+	accountService *service.AccountService
+}
+
+func NewAccountHandler(accountService *service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// RequestExport starts an asynchronous export of the current user's data
+func (h *AccountHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	job, err := h.accountService.RequestExport(claims.UserID, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusAccepted, "Export started", job)
+}
+
+// GetExport returns the status of the current user's latest export
+func (h *AccountHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	job, err := h.accountService.GetLatestExport(claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Export retrieved successfully", job)
+}
+
+// DownloadExport streams a finished export archive
+func (h *AccountHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid export ID")
+		return
+	}
+
+	file, job, err := h.accountService.OpenExport(id, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filepath.Base(job.FilePath)+`"`)
+	http.ServeContent(w, r, filepath.Base(job.FilePath), *job.CompletedAt, file)
+}
+
+// DeleteAccount schedules the current user's account for deletion
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var req model.DeleteAccountRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	response, err := h.accountService.DeleteAccount(claims.UserID, req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Account scheduled for deletion", response)
+}
+
+// RecoverAccount reactivates an account scheduled for deletion
+func (h *AccountHandler) RecoverAccount(w http.ResponseWriter, r *http.Request) {
+	var req model.RecoverAccountRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	user, err := h.accountService.RecoverAccount(req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Account recovered successfully", user)
+}
@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type AdminHandler struct {
+	authService         *service.AuthService
+	auditService        *service.AuditService
+	registrationService *service.RegistrationService
+}
+
+func NewAdminHandler(authService *service.AuthService, auditService *service.AuditService,
+	registrationService *service.RegistrationService) *AdminHandler {
+	return &AdminHandler{
+		authService:         authService,
+		auditService:        auditService,
+		registrationService: registrationService,
+	}
+}
+
+// ImpersonateUser issues a short-lived token for acting as another user
+func (h *AdminHandler) ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	response, err := h.authService.Impersonate(claims, id, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Impersonation token issued", response)
+}
+
+// ListAuditEvents retrieves audit events with filtering and pagination
+func (h *AdminHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditEventFilter(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.auditService.ListEvents(filter)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Audit events retrieved successfully", result)
+}
+
+// ExportAuditEvents streams matching audit events as CSV or NDJSON
+func (h *AdminHandler) ExportAuditEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditEventFilter(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+
+	switch format {
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit-events.ndjson"`)
+		encoder := json.NewEncoder(w)
+		err = h.auditService.ExportEvents(filter, func(event *model.AuditEvent) error {
+			return encoder.Encode(event)
+		})
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit-events.csv"`)
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"id", "created_at", "actor_id", "impersonator_id", "action", "target_type", "target_id",
+			"before", "after", "ip_address", "user_agent", "request_id", "prev_hash", "hash"})
+		err = h.auditService.ExportEvents(filter, func(event *model.AuditEvent) error {
+			return writer.Write([]string{
+				strconv.FormatInt(event.ID, 10),
+				event.CreatedAt.UTC().Format(time.RFC3339),
+				formatOptionalInt(event.ActorID),
+				formatOptionalInt(event.ImpersonatorID),
+				csvCell(event.Action),
+				csvCell(event.TargetType),
+				csvCell(event.TargetID),
+				csvCell(string(event.Before)),
+				csvCell(string(event.After)),
+				csvCell(event.IPAddress),
+				csvCell(event.UserAgent),
+				csvCell(event.RequestID),
+				event.PrevHash,
+				event.Hash,
+			})
+		})
+		writer.Flush()
+	default:
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid export format (expected csv or ndjson)")
+		return
+	}
+
+	// Headers are already sent, so a failure can only truncate the stream
+	if err != nil {
+		log.Printf("ERROR: audit export %s interrupted: %v", r.RequestURI, err)
+	}
+}
+
+// VerifyAuditChain checks the audit log hash chain for tampering
+func (h *AdminHandler) VerifyAuditChain(w http.ResponseWriter, r *http.Request) {
+	result, err := h.auditService.VerifyChain()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Audit chain verified", result)
+}
+
+// GetRegistrationSettings returns the current registration mode
+func (h *AdminHandler) GetRegistrationSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.registrationService.GetSettings()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Registration settings retrieved successfully", settings)
+}
+
+// UpdateRegistrationSettings changes the registration mode
+func (h *AdminHandler) UpdateRegistrationSettings(w http.ResponseWriter, r *http.Request) {
+	var req model.RegistrationSettings
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	settings, err := h.registrationService.UpdateSettings(req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Registration settings updated successfully", settings)
+}
+
+// CreateInvitation issues a new invite code
+func (h *AdminHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateInvitationRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	invitation, err := h.registrationService.CreateInvitation(req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Invitation created successfully", invitation)
+}
+
+// ListInvitations lists pending invitations, or all of them with ?all=true
+func (h *AdminHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	includeInactive := r.URL.Query().Get("all") == "true"
+
+	invitations, err := h.registrationService.ListInvitations(includeInactive)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Invitations retrieved successfully", invitations)
+}
+
+// RevokeInvitation revokes an unused invitation
+func (h *AdminHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	if err := h.registrationService.RevokeInvitation(id, middleware.GetRequestMeta(r)); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Invitation revoked successfully", nil)
+}
+
+// CreateOrganization creates a new organization
+func (h *AdminHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateOrganizationRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	org, err := h.registrationService.CreateOrganization(req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Organization created successfully", org)
+}
+
+// ListOrganizations lists all organizations
+func (h *AdminHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.registrationService.ListOrganizations()
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Organizations retrieved successfully", orgs)
+}
+
+// parseAuditEventFilter reads audit event filters from query parameters
+func parseAuditEventFilter(r *http.Request) (model.AuditEventFilter, error) {
+	query := r.URL.Query()
+	filter := model.AuditEventFilter{
+		Page:       1,
+		Limit:      50,
+		Action:     query.Get("action"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+		RequestID:  query.Get("request_id"),
+	}
+
+	if page := query.Get("page"); page != "" {
+		if p, err := strconv.Atoi(page); err == nil && p > 0 {
+			filter.Page = p
+		}
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if l, err := strconv.Atoi(limit); err == nil && l > 0 && l <= 100 {
+			filter.Limit = l
+		}
+	}
+
+	if actorID := query.Get("actor_id"); actorID != "" {
+		id, err := strconv.Atoi(actorID)
+		if err != nil {
+			return filter, errInvalidQuery("actor_id")
+		}
+		filter.ActorID = id
+	}
+
+	if impersonatorID := query.Get("impersonator_id"); impersonatorID != "" {
+		id, err := strconv.Atoi(impersonatorID)
+		if err != nil {
+			return filter, errInvalidQuery("impersonator_id")
+		}
+		filter.ImpersonatorID = id
+	}
+
+	if from := query.Get("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return filter, errInvalidQuery("from")
+		}
+		filter.From = &t
+	}
+
+	if to := query.Get("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return filter, errInvalidQuery("to")
+		}
+		filter.To = &t
+	}
+
+	return filter, nil
+}
+
+// errInvalidQuery reports a malformed query parameter
+func errInvalidQuery(name string) error {
+	return fmt.Errorf("invalid %s parameter", name)
+}
+
+// csvCell prefixes values that spreadsheets would evaluate as formulas with a quote, so
+// exported user input stays text
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// formatOptionalInt renders a nullable ID for CSV output
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
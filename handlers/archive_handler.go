@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type ArchiveHandler struct {
+	archiveService *service.ArchiveService
+}
+
+func NewArchiveHandler(archiveService *service.ArchiveService) *ArchiveHandler {
+	return &ArchiveHandler{
+		archiveService: archiveService,
+	}
+}
+
+// ArchiveTodo archives a completed todo along with its subtasks
+func (h *ArchiveHandler) ArchiveTodo(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	todo, err := h.archiveService.Archive(id, claims.UserID, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todo archived successfully", todo)
+}
+
+// UnarchiveTodo takes a todo out of the archive
+func (h *ArchiveHandler) UnarchiveTodo(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	todo, err := h.archiveService.Unarchive(id, claims.UserID, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todo unarchived successfully", todo)
+}
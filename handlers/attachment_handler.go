@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/service"
+	"jmrashed/apps/userApp/storage"
+
+	"github.com/gorilla/mux"
+)
+
+type AttachmentHandler struct {
+	attachmentService *service.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// UploadAttachment attaches the multipart "file" field to a todo
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	maxBytes := h.attachmentService.MaxUploadBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+multipartOverhead)
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			writeErrorResponse(w, http.StatusRequestEntityTooLarge, service.ErrAttachmentTooLarge.Error())
+			return
+		}
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Missing attachment file")
+		return
+	}
+	defer file.Close()
+
+	// The declared part Content-Type is ignored; the service sniffs the data itself
+	data, err := ioutil.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read attachment file")
+		return
+	}
+
+	attachment, err := h.attachmentService.Upload(todoID, claims.UserID, header.Filename, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAttachmentTooLarge), errors.Is(err, service.ErrAttachmentQuota):
+			writeErrorResponse(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, service.ErrAttachmentType):
+			writeErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+		default:
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Attachment uploaded successfully", attachment)
+}
+
+// ListAttachments lists a todo's attachments
+func (h *AttachmentHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	attachments, err := h.attachmentService.ListAttachments(todoID, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Attachments retrieved successfully", attachments)
+}
+
+// GetAttachment returns one of a todo's attachments with a fresh download URL
+func (h *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	todoID, attachmentID, ok := attachmentIDs(w, r)
+	if !ok {
+		return
+	}
+
+	attachment, err := h.attachmentService.GetAttachment(todoID, attachmentID, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Attachment retrieved successfully", attachment)
+}
+
+// DeleteAttachment deletes an attachment and its stored file
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	todoID, attachmentID, ok := attachmentIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(todoID, attachmentID, claims.UserID); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Attachment deleted successfully", nil)
+}
+
+// GetAttachmentUsage reports how much of their attachment quota the current user has used
+func (h *AttachmentHandler) GetAttachmentUsage(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	usage, err := h.attachmentService.Usage(claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve attachment usage")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Attachment usage retrieved successfully", usage)
+}
+
+// DownloadAttachment streams an attachment to anyone holding an unexpired signed link
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	attachmentID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid attachment ID")
+		return
+	}
+
+	query := r.URL.Query()
+	blob, attachment, err := h.attachmentService.OpenDownload(attachmentID, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrInvalidSignature), errors.Is(err, storage.ErrLinkExpired):
+			writeErrorResponse(w, http.StatusForbidden, err.Error())
+		default:
+			writeErrorResponse(w, http.StatusNotFound, "Attachment not found")
+		}
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
+
+// attachmentIDs parses the todo and attachment IDs of an attachment route, writing an error
+// response when either is invalid
+func attachmentIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return 0, 0, false
+	}
+	attachmentID, err := strconv.Atoi(vars["attachmentId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid attachment ID")
+		return 0, 0, false
+	}
+	return todoID, attachmentID, true
+}
@@ -35,7 +35,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authResponse, err := h.authService.Register(req)
+	authResponse, err := h.authService.Register(req, middleware.GetRequestMeta(r))
 	if err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
@@ -59,7 +59,7 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	authResponse, err := h.authService.Login(req)
+	authResponse, err := h.authService.Login(req, middleware.GetRequestMeta(r))
 	if err != nil {
 		writeErrorResponse(w, http.StatusUnauthorized, err.Error())
 		return
@@ -128,7 +128,7 @@ func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.authService.LogoutAll(claims.UserID); err != nil {
+	if err := h.authService.LogoutAll(claims.UserID, middleware.GetRequestMeta(r)); err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to logout from all devices")
 		return
 	}
@@ -161,10 +161,7 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-	}
+	var req model.UpdateProfileRequest
 
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -177,12 +174,16 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := h.authService.UpdateUserProfile(claims.UserID, req.Username, req.Email)
+	user, err := h.authService.UpdateUserProfile(claims.UserID, req, middleware.GetRequestMeta(r))
 	if err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	if req.Email != "" && user.PendingEmail == req.Email {
+		writeSuccessResponse(w, http.StatusOK, "Profile updated; confirm the link sent to the new email address to complete the change", user)
+		return
+	}
 	writeSuccessResponse(w, http.StatusOK, "Profile updated successfully", user)
 }
 
@@ -210,7 +211,7 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.authService.ChangePassword(claims.UserID, req.CurrentPassword, req.NewPassword); err != nil {
+	if err := h.authService.ChangePassword(claims.UserID, req.CurrentPassword, req.NewPassword, middleware.GetRequestMeta(r)); err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	"jmrashed/apps/userApp/media"
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/service"
+)
+
+// multipartOverhead allows for multipart boundaries and headers around the file
+const multipartOverhead = 64 << 10
+
+type AvatarHandler struct {
+	avatarService *service.AvatarService
+}
+
+func NewAvatarHandler(avatarService *service.AvatarService) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService: avatarService,
+	}
+}
+
+// UploadAvatar replaces the current user's avatar from the multipart "avatar" field
+func (h *AvatarHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	maxBytes := h.avatarService.MaxUploadBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+multipartOverhead)
+	if err := r.ParseMultipartForm(maxBytes); err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			writeErrorResponse(w, http.StatusRequestEntityTooLarge, service.ErrAvatarTooLarge.Error())
+			return
+		}
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid multipart form")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Missing avatar file")
+		return
+	}
+	defer file.Close()
+
+	// The declared part Content-Type is ignored; the service sniffs the data itself
+	data, err := ioutil.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read avatar file")
+		return
+	}
+
+	avatar, err := h.avatarService.UploadAvatar(claims.UserID, data, middleware.GetRequestMeta(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAvatarTooLarge), errors.Is(err, media.ErrImageTooLarge):
+			writeErrorResponse(w, http.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, media.ErrUnsupportedImage):
+			writeErrorResponse(w, http.StatusUnsupportedMediaType, err.Error())
+		default:
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		}
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Avatar updated successfully", avatar)
+}
+
+// DeleteAvatar removes the current user's avatar
+func (h *AvatarHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	if err := h.avatarService.RemoveAvatar(claims.UserID, middleware.GetRequestMeta(r)); err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to remove avatar")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Avatar removed successfully", nil)
+}
+
+// MediaFileServer serves locally stored blobs without directory listings. Blob keys are
+// never reused, so responses may be cached indefinitely. Todo attachments are private and
+// only downloadable through signed links, so they are never served here.
+func MediaFileServer(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") || strings.HasPrefix(path.Clean("/"+r.URL.Path), "/attachments/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		files.ServeHTTP(w, r)
+	})
+}
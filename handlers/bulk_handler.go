@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+)
+
+type BulkHandler struct {
+	bulkService *service.BulkService
+}
+
+func NewBulkHandler(bulkService *service.BulkService) *BulkHandler {
+	return &BulkHandler{
+		bulkService: bulkService,
+	}
+}
+
+// ExecuteBulk applies a list of todo operations and reports the outcome of each. Every
+// operation is checked against the permission of its kind.
+func (h *BulkHandler) ExecuteBulk(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var req model.BulkRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	result, err := h.bulkService.Execute(claims.UserID, claims.Permissions, req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	message := "Bulk operations applied successfully"
+	if result.Failed > 0 {
+		message = "Some bulk operations failed"
+		if result.Mode == model.BulkAtomic {
+			message = "Bulk operations failed; no changes were kept"
+		}
+	}
+	writeSuccessResponse(w, http.StatusOK, message, result)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type ChecklistHandler struct {
+	subtaskService *service.SubtaskService
+}
+
+func NewChecklistHandler(subtaskService *service.SubtaskService) *ChecklistHandler {
+	return &ChecklistHandler{
+		subtaskService: subtaskService,
+	}
+}
+
+// AddItem adds an item to a todo's checklist
+func (h *ChecklistHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	var req model.CreateChecklistItemRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	item, err := h.subtaskService.AddChecklistItem(todoID, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Checklist item added successfully", item)
+}
+
+// UpdateItem edits, checks or moves a checklist item
+func (h *ChecklistHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+	itemID, err := strconv.Atoi(vars["itemId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid checklist item ID")
+		return
+	}
+
+	var req model.UpdateChecklistItemRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	item, err := h.subtaskService.UpdateChecklistItem(todoID, itemID, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Checklist item updated successfully", item)
+}
+
+// DeleteItem removes an item from a todo's checklist
+func (h *ChecklistHandler) DeleteItem(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+	itemID, err := strconv.Atoi(vars["itemId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid checklist item ID")
+		return
+	}
+
+	if err := h.subtaskService.DeleteChecklistItem(todoID, itemID, claims.UserID); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Checklist item deleted successfully", nil)
+}
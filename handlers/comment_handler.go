@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type CommentHandler struct {
+	commentService *service.CommentService
+}
+
+func NewCommentHandler(commentService *service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// ListComments lists a page of a todo's comment threads
+func (h *CommentHandler) ListComments(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	page := 0
+	if value := r.URL.Query().Get("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 1 {
+			writeErrorResponse(w, http.StatusBadRequest, "page must be a positive number")
+			return
+		}
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 100 {
+			writeErrorResponse(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	result, err := h.commentService.ListComments(todoID, claims.UserID, page, limit)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Comments retrieved successfully", result)
+}
+
+// CreateComment adds a comment or a reply to a todo
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	var req model.CreateCommentRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(todoID, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Comment created successfully", comment)
+}
+
+// UpdateComment edits one of the current user's comments
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	todoID, commentID, ok := commentIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.UpdateCommentRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(todoID, commentID, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Comment updated successfully", comment)
+}
+
+// DeleteComment deletes a comment and its replies
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	todoID, commentID, ok := commentIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.commentService.DeleteComment(todoID, commentID, claims.UserID); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Comment deleted successfully", nil)
+}
+
+// GetCommentHistory lists the earlier versions of an edited comment
+func (h *CommentHandler) GetCommentHistory(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	todoID, commentID, ok := commentIDs(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := h.commentService.GetHistory(todoID, commentID, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Comment history retrieved successfully", revisions)
+}
+
+// commentIDs parses the todo and comment IDs of a comment route, writing an error response when
+// either is invalid
+func commentIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return 0, 0, false
+	}
+	commentID, err := strconv.Atoi(vars["commentId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid comment ID")
+		return 0, 0, false
+	}
+	return todoID, commentID, true
+}
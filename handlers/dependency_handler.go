@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type DependencyHandler struct {
+	dependencyService *service.DependencyService
+	todoService       *service.TodoService
+}
+
+func NewDependencyHandler(dependencyService *service.DependencyService, todoService *service.TodoService) *DependencyHandler {
+	return &DependencyHandler{
+		dependencyService: dependencyService,
+		todoService:       todoService,
+	}
+}
+
+// GetDependencies lists the todos blocking a todo and the todos it blocks
+func (h *DependencyHandler) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	dependencies, err := h.todoService.Dependencies(id, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Dependencies retrieved successfully", dependencies)
+}
+
+// AddDependency makes a todo blocked by another todo
+func (h *DependencyHandler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	var req model.CreateDependencyRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	if err := h.dependencyService.AddDependency(id, claims.UserID, req); err != nil {
+		if errors.Is(err, service.ErrDependencyCycle) {
+			writeErrorResponse(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dependencies, err := h.todoService.Dependencies(id, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve dependencies")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Dependency added successfully", dependencies)
+}
+
+// RemoveDependency stops a todo from being blocked by another todo
+func (h *DependencyHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+	blockedByID, err := strconv.Atoi(vars["blockedById"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid blocking todo ID")
+		return
+	}
+
+	if err := h.dependencyService.RemoveDependency(id, blockedByID, claims.UserID); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Dependency removed successfully", nil)
+}
+
+// GetGraph returns the dependency graph of a project's todos
+func (h *DependencyHandler) GetGraph(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = model.DependencyFormatGraph
+	}
+	if format != model.DependencyFormatGraph && format != model.DependencyFormatSorted {
+		writeErrorResponse(w, http.StatusBadRequest, "format must be graph or sorted")
+		return
+	}
+
+	graph, err := h.dependencyService.Graph(id, claims.UserID, format)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Dependency graph retrieved successfully", graph)
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+)
+
+type EmailChangeHandler struct {
+	emailChangeService *service.EmailChangeService
+}
+
+func NewEmailChangeHandler(emailChangeService *service.EmailChangeService) *EmailChangeHandler {
+	return &EmailChangeHandler{
+		emailChangeService: emailChangeService,
+	}
+}
+
+// ConfirmEmailChange applies a pending email change using the token sent to the new address
+func (h *EmailChangeHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	token, ok := readEmailChangeToken(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.emailChangeService.ConfirmChange(token, middleware.GetRequestMeta(r)); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Email address changed successfully", nil)
+}
+
+// CancelEmailChange cancels or reverts an email change using the token sent to the old address
+func (h *EmailChangeHandler) CancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	token, ok := readEmailChangeToken(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.emailChangeService.CancelChange(token, middleware.GetRequestMeta(r)); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Email address change cancelled", nil)
+}
+
+// CancelPendingEmailChange withdraws the current user's pending email change
+func (h *EmailChangeHandler) CancelPendingEmailChange(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	if err := h.emailChangeService.CancelPending(claims.UserID, middleware.GetRequestMeta(r)); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Email address change cancelled", nil)
+}
+
+func readEmailChangeToken(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req model.EmailChangeTokenRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return "", false
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return "", false
+	}
+
+	if req.Token == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Token is required")
+		return "", false
+	}
+	return req.Token, true
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type HistoryHandler struct {
+	historyService *service.HistoryService
+	todoService    *service.TodoService
+}
+
+func NewHistoryHandler(historyService *service.HistoryService, todoService *service.TodoService) *HistoryHandler {
+	return &HistoryHandler{
+		historyService: historyService,
+		todoService:    todoService,
+	}
+}
+
+// GetTodoHistory lists the kept versions of a todo with the changes of each
+func (h *HistoryHandler) GetTodoHistory(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	revisions, err := h.historyService.GetHistory(todoID, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todo history retrieved successfully", revisions)
+}
+
+// RestoreTodo rolls a todo back to an earlier version
+func (h *HistoryHandler) RestoreTodo(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid version")
+		return
+	}
+
+	todo, err := h.todoService.RestoreTodo(todoID, claims.UserID, version)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todo restored successfully", todo)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+)
+
+type PreferenceHandler struct {
+	prefService *service.PreferenceService
+}
+
+func NewPreferenceHandler(prefService *service.PreferenceService) *PreferenceHandler {
+	return &PreferenceHandler{
+		prefService: prefService,
+	}
+}
+
+// GetPreferences returns the current user's preferences
+func (h *PreferenceHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	prefs, err := h.prefService.GetPreferences(claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get preferences")
+		return
+	}
+
+	prefs.UpdatedAt = prefs.UpdatedAt.In(prefs.Location())
+	writeSuccessResponse(w, http.StatusOK, "Preferences retrieved successfully", prefs)
+}
+
+// UpdatePreferences partially updates the current user's preferences
+func (h *PreferenceHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var req model.UpdatePreferencesRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	prefs, err := h.prefService.UpdatePreferences(claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Preferences updated successfully", prefs)
+}
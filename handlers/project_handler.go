@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type ProjectHandler struct {
+	projectService *service.ProjectService
+}
+
+func NewProjectHandler(projectService *service.ProjectService) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: projectService,
+	}
+}
+
+// ListProjects lists the current user's projects; archived ones only with ?archived=true
+func (h *ProjectHandler) ListProjects(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	projects, err := h.projectService.ListProjects(claims.UserID, r.URL.Query().Get("archived") == "true")
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get projects")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Projects retrieved successfully", projects)
+}
+
+// GetProject retrieves a project with its todo counts
+func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	project, err := h.projectService.GetProject(id, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Project retrieved successfully", project)
+}
+
+// CreateProject creates a project
+func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var req model.CreateProjectRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	project, err := h.projectService.CreateProject(claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Project created successfully", project)
+}
+
+// UpdateProject updates, archives or reorders a project
+func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	var req model.UpdateProjectRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	project, err := h.projectService.UpdateProject(id, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Project updated successfully", project)
+}
+
+// DeleteProject deletes a project and keeps its todos without a project
+func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if err := h.projectService.DeleteProject(id, claims.UserID, middleware.GetRequestMeta(r)); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Project deleted successfully", nil)
+}
+
+// MoveTodos moves todos into a project or out of their project
+func (h *ProjectHandler) MoveTodos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var req model.MoveTodosRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	moved, err := h.projectService.MoveTodos(claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todos moved successfully", map[string]int64{"moved": moved})
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type TemplateHandler struct {
+	templateService *service.TemplateService
+}
+
+func NewTemplateHandler(templateService *service.TemplateService) *TemplateHandler {
+	return &TemplateHandler{
+		templateService: templateService,
+	}
+}
+
+// ListTemplates lists the templates of the current user and their organizations
+func (h *TemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	templates, err := h.templateService.ListTemplates(claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get templates")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Templates retrieved successfully", templates)
+}
+
+// GetTemplate retrieves a template with the variables it uses
+func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(id, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, "Template not found")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Template retrieved successfully", template)
+}
+
+// CreateTemplate creates a template
+func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var req model.CreateTemplateRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(claims.UserID, req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Template created successfully", template)
+}
+
+// CreateTemplateFromProject creates a template from the todos of a project
+func (h *TemplateHandler) CreateTemplateFromProject(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var req model.CreateTemplateFromProjectRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	template, err := h.templateService.CreateTemplateFromProject(claims.UserID, req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Template created successfully", template)
+}
+
+// UpdateTemplate updates a template created by the current user
+func (h *TemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	var req model.UpdateTemplateRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(id, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Template updated successfully", template)
+}
+
+// DeleteTemplate deletes a template created by the current user
+func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	if err := h.templateService.DeleteTemplate(id, claims.UserID, middleware.GetRequestMeta(r)); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Template deleted successfully", nil)
+}
+
+// InstantiateTemplate creates the todos of a template
+func (h *TemplateHandler) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	var req model.InstantiateTemplateRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+	}
+
+	instance, err := h.templateService.Instantiate(id, claims.UserID, req, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Template instantiated successfully", instance)
+}
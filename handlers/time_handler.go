@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type TimeHandler struct {
+	timeService *service.TimeService
+}
+
+func NewTimeHandler(timeService *service.TimeService) *TimeHandler {
+	return &TimeHandler{
+		timeService: timeService,
+	}
+}
+
+// StartTimer starts the current user's timer on a todo, stopping their timer on any other todo
+func (h *TimeHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	var req model.StartTimerRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+	}
+
+	entry, err := h.timeService.StartTimer(todoID, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Timer started successfully", entry)
+}
+
+// StopTimer stops the current user's running timer
+func (h *TimeHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	entry, err := h.timeService.StopTimer(claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Timer stopped successfully", entry)
+}
+
+// GetTimer retrieves the current user's running timer, if any
+func (h *TimeHandler) GetTimer(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Timer retrieved successfully", h.timeService.RunningTimer(claims.UserID))
+}
+
+// ListTimeEntries lists the time entries of a todo with their total
+func (h *TimeHandler) ListTimeEntries(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	result, err := h.timeService.TodoTime(todoID, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Time entries retrieved successfully", result)
+}
+
+// CreateTimeEntry records time spent on a todo by hand
+func (h *TimeHandler) CreateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	var req model.CreateTimeEntryRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	entry, err := h.timeService.CreateEntry(todoID, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusCreated, "Time entry created successfully", entry)
+}
+
+// UpdateTimeEntry changes the times or note of the current user's time entry
+func (h *TimeHandler) UpdateTimeEntry(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+	entryID, err := strconv.Atoi(vars["entryId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid time entry ID")
+		return
+	}
+
+	var req model.UpdateTimeEntryRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	entry, err := h.timeService.UpdateEntry(todoID, entryID, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Time entry updated successfully", entry)
+}
+
+// DeleteTimeEntry deletes a time entry
+func (h *TimeHandler) DeleteTimeEntry(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+	entryID, err := strconv.Atoi(vars["entryId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid time entry ID")
+		return
+	}
+
+	if err := h.timeService.DeleteEntry(todoID, entryID, claims.UserID); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Time entry deleted successfully", nil)
+}
+
+// GetTimeReport aggregates tracked time by day or week and by todo, project or tag, as JSON
+// or as a CSV download
+func (h *TimeHandler) GetTimeReport(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	query := r.URL.Query()
+	req := model.TimeReportRequest{
+		From:    query.Get("from"),
+		To:      query.Get("to"),
+		Period:  query.Get("period"),
+		GroupBy: query.Get("group_by"),
+	}
+	var err error
+	if req.TodoID, err = parseQueryID(query.Get("todo_id")); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, errInvalidQuery("todo_id").Error())
+		return
+	}
+	if req.ProjectID, err = parseQueryID(query.Get("project_id")); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, errInvalidQuery("project_id").Error())
+		return
+	}
+	if req.TagID, err = parseQueryID(query.Get("tag_id")); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, errInvalidQuery("tag_id").Error())
+		return
+	}
+
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report format (expected json or csv)")
+		return
+	}
+
+	report, err := h.timeService.Report(claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if format != "csv" {
+		writeSuccessResponse(w, http.StatusOK, "Time report retrieved successfully", report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="time-report-%s-%s.csv"`, report.From, report.To))
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"period", "group_id", "group", "hours", "seconds", "entries"})
+	for _, row := range report.Rows {
+		writer.Write([]string{
+			row.Period,
+			formatOptionalInt(row.ID),
+			csvCell(row.Name),
+			strconv.FormatFloat(float64(row.Seconds)/3600, 'f', 2, 64),
+			strconv.FormatInt(row.Seconds, 10),
+			strconv.Itoa(row.Entries),
+		})
+	}
+	writer.Flush()
+}
+
+// parseQueryID parses an optional ID query parameter
+func parseQueryID(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"jmrashed/apps/userApp/middleware"
 	"jmrashed/apps/userApp/model"
@@ -14,12 +18,19 @@ import (
 )
 
 type TodoHandler struct {
-	todoService *service.TodoService
+	todoService       *service.TodoService
+	prefService       *service.PreferenceService
+	recurrenceService *service.RecurrenceService
+	projectService    *service.ProjectService
 }
 
-func NewTodoHandler(todoService *service.TodoService) *TodoHandler {
+func NewTodoHandler(todoService *service.TodoService, prefService *service.PreferenceService,
+	recurrenceService *service.RecurrenceService, projectService *service.ProjectService) *TodoHandler {
 	return &TodoHandler{
-		todoService: todoService,
+		todoService:       todoService,
+		prefService:       prefService,
+		recurrenceService: recurrenceService,
+		projectService:    projectService,
 	}
 }
 
@@ -52,8 +63,14 @@ func (h *TodoHandler) CreateTodo(w http.ResponseWriter, r *http.Request) {
 	writeSuccessResponse(w, http.StatusCreated, "Todo created successfully", todo)
 }
 
-// GetTodo retrieves a todo by ID
+// GetTodo retrieves a todo the current user owns or is assigned to
 func (h *TodoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -61,12 +78,14 @@ func (h *TodoHandler) GetTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	todo, err := h.todoService.GetTodoByID(id)
+	todo, err := h.todoService.GetTodoByID(id, claims.UserID)
 	if err != nil {
 		writeErrorResponse(w, http.StatusNotFound, "Todo not found")
 		return
 	}
 
+	h.todoService.PresentTodos(claims.UserID, todo)
+
 	writeSuccessResponse(w, http.StatusOK, "Todo retrieved successfully", todo)
 }
 
@@ -78,15 +97,26 @@ func (h *TodoHandler) GetUserTodos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse query parameters
-	req := model.PaginationRequest{
-		Page:   1,
-		Limit:  10,
-		Sort:   "created_at",
-		Order:  "desc",
-		Filter: "all",
+	req, err := h.todoListRequest(r, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
+	result, err := h.todoService.GetUserTodos(claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todos retrieved successfully", result)
+}
+
+// todoListRequest parses the listing query parameters shared by todo listings, falling back
+// to the user's preferred defaults
+func (h *TodoHandler) todoListRequest(r *http.Request, userID int) (model.PaginationRequest, error) {
+	req := h.prefService.TodoListDefaults(userID)
+
 	if page := r.URL.Query().Get("page"); page != "" {
 		if p, err := strconv.Atoi(page); err == nil && p > 0 {
 			req.Page = p
@@ -115,13 +145,117 @@ func (h *TodoHandler) GetUserTodos(w http.ResponseWriter, r *http.Request) {
 		req.Filter = filter
 	}
 
+	// Due date filters; plain dates are days in the user's timezone
+	loc := h.prefService.Location(userID)
+	for param, target := range map[string]**time.Time{"due_before": &req.DueBefore, "due_after": &req.DueAfter} {
+		if value := r.URL.Query().Get(param); value != "" {
+			t, err := parseDueDate(value, loc)
+			if err != nil {
+				return req, errors.New("Invalid " + param + ": use RFC 3339 or YYYY-MM-DD")
+			}
+			*target = &t
+		}
+	}
+
+	req.Overdue = r.URL.Query().Get("overdue") == "true"
+	req.DueToday = r.URL.Query().Get("due_today") == "true"
+	req.AssignedToMe = r.URL.Query().Get("assigned_to_me") == "true"
+	req.CreatedByMe = r.URL.Query().Get("created_by_me") == "true"
+
+	// Archived todos are left out unless include_archived is true, or listed alone with only
+	switch r.URL.Query().Get("include_archived") {
+	case "", "false":
+	case "true":
+		req.IncludeArchived = true
+	case "only":
+		req.ArchivedOnly = true
+	default:
+		return req, errors.New("Invalid include_archived: use true, false or only")
+	}
+
+	// Tag filters: comma-separated tag IDs matched with tag_mode all (default) or any
+	if tags := r.URL.Query().Get("tags"); tags != "" {
+		tagIDs, err := parseIDList(tags)
+		if err != nil {
+			return req, errors.New("Invalid tags: use comma-separated tag IDs")
+		}
+		req.TagIDs = tagIDs
+	}
+	req.TagMode = r.URL.Query().Get("tag_mode")
+
+	// Project filter: a project ID, or "none" for todos outside any project
+	if project := r.URL.Query().Get("project_id"); project == "none" {
+		req.NoProject = true
+	} else if project != "" {
+		projectID, err := strconv.Atoi(project)
+		if err != nil {
+			return req, errors.New("Invalid project_id: use a project ID or none")
+		}
+		req.ProjectID = &projectID
+	}
+
+	// Parent filter: a todo's direct subtasks, or "none" for top-level todos only
+	if parent := r.URL.Query().Get("parent_id"); parent == "none" {
+		req.TopLevel = true
+	} else if parent != "" {
+		parentID, err := strconv.Atoi(parent)
+		if err != nil {
+			return req, errors.New("Invalid parent_id: use a todo ID or none")
+		}
+		req.ParentID = &parentID
+	}
+
+	req.Status = r.URL.Query().Get("status")
+
+	// Priority filter: comma-separated priority names
+	if priorities := r.URL.Query().Get("priority"); priorities != "" {
+		for _, name := range strings.Split(priorities, ",") {
+			priority, err := model.ParsePriority(strings.TrimSpace(name))
+			if err != nil {
+				return req, err
+			}
+			req.Priorities = append(req.Priorities, priority)
+		}
+	}
+
+	return req, nil
+}
+
+// GetProjectTodos lists the todos of one of the current user's projects
+func (h *TodoHandler) GetProjectTodos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	if _, err := h.projectService.GetProject(id, claims.UserID); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	req, err := h.todoListRequest(r, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	req.ProjectID = &id
+	req.NoProject = false
+
 	result, err := h.todoService.GetUserTodos(claims.UserID, req)
 	if err != nil {
 		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	writeSuccessResponse(w, http.StatusOK, "Todos retrieved successfully", result)
+	writeSuccessResponse(w, http.StatusOK, "Project todos retrieved successfully", result)
 }
 
 // UpdateTodo updates a todo
@@ -151,7 +285,7 @@ func (h *TodoHandler) UpdateTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	todo, err := h.todoService.UpdateTodo(id, claims.UserID, req)
+	todo, err := h.todoService.UpdateTodo(id, claims.UserID, req, r.URL.Query().Get("scope"))
 	if err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
@@ -175,7 +309,7 @@ func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.todoService.DeleteTodo(id, claims.UserID); err != nil {
+	if err := h.todoService.DeleteTodo(id, claims.UserID, middleware.GetRequestMeta(r), r.URL.Query().Get("scope")); err != nil {
 		writeErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -183,6 +317,153 @@ func (h *TodoHandler) DeleteTodo(w http.ResponseWriter, r *http.Request) {
 	writeSuccessResponse(w, http.StatusOK, "Todo deleted successfully", nil)
 }
 
+// MoveTodo moves a todo before or after another todo in the user's manual order
+func (h *TodoHandler) MoveTodo(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	var req model.MoveTodoRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	todo, err := h.todoService.MoveTodo(id, claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todo moved successfully", todo)
+}
+
+// GetTodoTree retrieves a todo with all of its subtasks and checklists
+func (h *TodoHandler) GetTodoTree(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	todo, err := h.todoService.GetTodoTree(id, claims.UserID)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todo tree retrieved successfully", todo)
+}
+
+// GetNextTodos lists the current user's pending todos ranked by what to work on next
+func (h *TodoHandler) GetNextTodos(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	limit := 10
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 100 {
+			writeErrorResponse(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	todos, err := h.todoService.Next(claims.UserID, limit)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Next todos retrieved successfully", todos)
+}
+
+// GetOccurrences lists the upcoming occurrences of a recurring todo
+func (h *TodoHandler) GetOccurrences(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 100 {
+			writeErrorResponse(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	result, err := h.todoService.Occurrences(id, claims.UserID, limit)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Occurrences retrieved successfully", result)
+}
+
+// PreviewRecurrence lists the occurrences a recurrence rule would produce
+func (h *TodoHandler) PreviewRecurrence(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var req model.RecurrencePreviewRequest
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid JSON format")
+		return
+	}
+
+	result, err := h.recurrenceService.Preview(claims.UserID, req)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Recurrence previewed successfully", result)
+}
+
 // GetAllTodos retrieves all todos (admin only)
 func (h *TodoHandler) GetAllTodos(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
@@ -224,4 +505,28 @@ func (h *TodoHandler) GetAllTodos(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeSuccessResponse(w, http.StatusOK, "All todos retrieved successfully", result)
-}
\ No newline at end of file
+}
+// parseDueDate parses an RFC 3339 timestamp, or a date meaning midnight in loc
+func parseDueDate(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
+
+// parseIDList parses a comma-separated list of IDs, dropping duplicates
+func parseIDList(value string) ([]int, error) {
+	seen := map[int]bool{}
+	var ids []int
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid ID %q", part)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/service"
+
+	"github.com/gorilla/mux"
+)
+
+type TrashHandler struct {
+	trashService *service.TrashService
+}
+
+func NewTrashHandler(trashService *service.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// ListTrash lists a page of the current user's deleted todos
+func (h *TrashHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	var err error
+	page := 0
+	if value := r.URL.Query().Get("page"); value != "" {
+		if page, err = strconv.Atoi(value); err != nil || page < 1 {
+			writeErrorResponse(w, http.StatusBadRequest, "page must be a positive number")
+			return
+		}
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > 100 {
+			writeErrorResponse(w, http.StatusBadRequest, "limit must be between 1 and 100")
+			return
+		}
+	}
+
+	result, err := h.trashService.ListTrash(claims.UserID, page, limit)
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve trash")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Trash retrieved successfully", result)
+}
+
+// RestoreTodo takes a todo out of the trash
+func (h *TrashHandler) RestoreTodo(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	todo, err := h.trashService.Restore(id, claims.UserID, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todo restored successfully", todo)
+}
+
+// DeleteTodoForever permanently deletes a todo from the trash
+func (h *TrashHandler) DeleteTodoForever(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid todo ID")
+		return
+	}
+
+	if err := h.trashService.DeleteForever(id, claims.UserID, middleware.GetRequestMeta(r)); err != nil {
+		writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Todo permanently deleted", nil)
+}
+
+// EmptyTrash permanently deletes all of the current user's trashed todos
+func (h *TrashHandler) EmptyTrash(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetUserFromContext(r)
+	if !ok {
+		writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+		return
+	}
+
+	purged, err := h.trashService.EmptyTrash(claims.UserID, middleware.GetRequestMeta(r))
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to empty trash")
+		return
+	}
+
+	writeSuccessResponse(w, http.StatusOK, "Trash emptied successfully", map[string]int64{"purged": purged})
+}
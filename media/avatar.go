@@ -0,0 +1,175 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// Registered decoders for the accepted upload formats
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Upload limits guarding against decompression bombs
+const (
+	MaxDimension = 8000
+	MaxPixels    = 40000000
+)
+
+// AvatarContentType is the content type of every processed avatar variant
+const AvatarContentType = "image/jpeg"
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type; use JPEG, PNG or GIF")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// allowedTypes are the sniffed content types accepted for avatars
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// AvatarVariant is one resized rendition of an avatar
+type AvatarVariant struct {
+	Size int
+	Data []byte
+}
+
+// SniffImageType detects the content type from the data itself, ignoring client-supplied headers
+func SniffImageType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if !allowedTypes[contentType] {
+		return "", ErrUnsupportedImage
+	}
+	return contentType, nil
+}
+
+// ProcessAvatar decodes an uploaded image, applies its EXIF orientation, center-crops it to a
+// square and re-encodes it as JPEG at each size. Re-encoding drops EXIF and any other metadata.
+func ProcessAvatar(data []byte, sizes []int) ([]AvatarVariant, error) {
+	contentType, err := SniffImageType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension || config.Width*config.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	square := cropSquare(src)
+	if contentType == "image/jpeg" {
+		square = orient(square, jpegOrientation(data))
+	}
+
+	variants := make([]AvatarVariant, 0, len(sizes))
+	for _, size := range sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size), &jpeg.Options{Quality: 85}); err != nil {
+			return nil, fmt.Errorf("failed to encode avatar: %w", err)
+		}
+		variants = append(variants, AvatarVariant{Size: size, Data: buf.Bytes()})
+	}
+	return variants, nil
+}
+
+// cropSquare copies the centered square of src onto a white background, flattening transparency
+func cropSquare(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	origin := image.Pt(b.Min.X+(b.Dx()-side)/2, b.Min.Y+(b.Dy()-side)/2)
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, origin, draw.Over)
+	return dst
+}
+
+// resize scales a square image to size×size by averaging the source pixels each target pixel covers
+func resize(src *image.RGBA, size int) *image.RGBA {
+	side := src.Bounds().Dx()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+
+	for dy := 0; dy < size; dy++ {
+		y0, y1 := span(dy, side, size)
+		for dx := 0; dx < size; dx++ {
+			x0, x1 := span(dx, side, size)
+
+			var r, g, b, a, n uint32
+			for y := y0; y < y1; y++ {
+				offset := src.PixOffset(x0, y)
+				for x := x0; x < x1; x++ {
+					r += uint32(src.Pix[offset])
+					g += uint32(src.Pix[offset+1])
+					b += uint32(src.Pix[offset+2])
+					a += uint32(src.Pix[offset+3])
+					offset += 4
+					n++
+				}
+			}
+			dst.SetRGBA(dx, dy, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// span returns the source pixel range [start, end) covered by target pixel i
+func span(i, srcSize, dstSize int) (int, int) {
+	start := i * srcSize / dstSize
+	end := (i + 1) * srcSize / dstSize
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// orient applies an EXIF orientation (1-8) to a square image
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	n := src.Bounds().Dx()
+	dst := image.NewRGBA(src.Bounds())
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var tx, ty int
+			switch orientation {
+			case 2:
+				tx, ty = n-1-x, y
+			case 3:
+				tx, ty = n-1-x, n-1-y
+			case 4:
+				tx, ty = x, n-1-y
+			case 5:
+				tx, ty = y, x
+			case 6:
+				tx, ty = n-1-y, x
+			case 7:
+				tx, ty = n-1-y, n-1-x
+			case 8:
+				tx, ty = y, n-1-x
+			}
+			dst.SetRGBA(tx, ty, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
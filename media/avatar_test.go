@@ -0,0 +1,107 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// withExifOrientation inserts an APP1 segment holding a big-endian orientation tag after SOI
+func withExifOrientation(jpg []byte, orientation byte) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // header, IFD0 at offset 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, // orientation SHORT
+		0, 0, 0, 0, // no next IFD
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+	segment := append([]byte{0xFF, 0xE1, byte(length >> 8), byte(length)}, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcessAvatar(t *testing.T) {
+	// 300x200 image: left half red, right half blue
+	src := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 150 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	variants, err := ProcessAvatar(encodePNG(t, src), []int{128, 32})
+	require.NoError(t, err)
+	require.Len(t, variants, 2)
+
+	for _, variant := range variants {
+		img, format, err := image.Decode(bytes.NewReader(variant.Data))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, variant.Size, img.Bounds().Dx())
+		assert.Equal(t, variant.Size, img.Bounds().Dy())
+
+		// The centered crop keeps red on the left and blue on the right
+		r, _, b, _ := img.At(2, variant.Size/2).RGBA()
+		assert.Greater(t, r, b)
+		r, _, b, _ = img.At(variant.Size-3, variant.Size/2).RGBA()
+		assert.Greater(t, b, r)
+	}
+}
+
+func TestProcessAvatarRejectsNonImages(t *testing.T) {
+	_, err := ProcessAvatar([]byte("<html><body>not an image</body></html>"), []int{64})
+	assert.Equal(t, ErrUnsupportedImage, err)
+
+	// A PNG signature with a corrupt body
+	_, err = ProcessAvatar([]byte("\x89PNG\r\n\x1a\ngarbage"), []int{64})
+	assert.Error(t, err)
+}
+
+func TestProcessAvatarAppliesAndStripsExif(t *testing.T) {
+	// Top half white, bottom half black
+	src := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			c := color.RGBA{255, 255, 255, 255}
+			if y >= 32 {
+				c = color.RGBA{0, 0, 0, 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, src, nil))
+	data := withExifOrientation(buf.Bytes(), 3)
+	assert.Equal(t, 3, jpegOrientation(data))
+
+	variants, err := ProcessAvatar(data, []int{64})
+	require.NoError(t, err)
+	assert.False(t, bytes.Contains(variants[0].Data, []byte("Exif")))
+	assert.Equal(t, 1, jpegOrientation(variants[0].Data))
+
+	// Orientation 3 rotates by 180 degrees, so the black half ends up on top
+	img, _, err := image.Decode(bytes.NewReader(variants[0].Data))
+	require.NoError(t, err)
+	top, _, _, _ := img.At(32, 4).RGBA()
+	bottom, _, _, _ := img.At(32, 60).RGBA()
+	assert.Less(t, top, bottom)
+}
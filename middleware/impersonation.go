@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"jmrashed/apps/userApp/model"
+)
+
+// ImpersonatorHeader is set on every response served with an impersonation token
+const ImpersonatorHeader = "X-Impersonated-By"
+
+// AuditRecorder records audit events
+type AuditRecorder interface {
+	Record(meta model.RequestMeta, entry model.AuditEntry) error
+}
+
+// GetRequestMeta describes the requester for audit purposes
+func GetRequestMeta(r *http.Request) model.RequestMeta {
+	meta := model.RequestMeta{
+		IPAddress: getClientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: GetRequestID(r),
+	}
+
+	if claims, ok := GetUserFromContext(r); ok {
+		meta.ActorID = claims.UserID
+		if claims.IsImpersonated() {
+			meta.ImpersonatorID = claims.Act.UserID
+		}
+	}
+
+	return meta
+}
+
+// DenyImpersonation middleware rejects sensitive actions attempted with an impersonation token
+func DenyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := GetUserFromContext(r)
+		if !ok {
+			writeErrorResponse(w, http.StatusUnauthorized, "User context not found")
+			return
+		}
+
+		if claims.IsImpersonated() {
+			writeErrorResponse(w, http.StatusForbidden, "Action not allowed while impersonating")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AuditImpersonation middleware records every request made with an impersonation token
+func AuditImpersonation(recorder AuditRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetUserFromContext(r)
+			if !ok || !claims.IsImpersonated() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			lrw := NewLoggingResponseWriter(w)
+			next.ServeHTTP(lrw, r)
+
+			entry := model.AuditEntry{
+				Action:     "impersonation.request",
+				TargetType: "user",
+				TargetID:   fmt.Sprintf("%d", claims.UserID),
+				After: map[string]interface{}{
+					"method": r.Method,
+					"path":   r.URL.Path,
+					"status": lrw.statusCode,
+				},
+			}
+			if err := recorder.Record(GetRequestMeta(r), entry); err != nil {
+				log.Printf("Warning: Failed to record impersonated request: %v", err)
+			}
+		})
+	}
+}
@@ -42,14 +42,20 @@ func Logging(next http.Handler) http.Handler {
 		// Log request details
 		duration := time.Since(start)
 		log.Printf(
-			"%s %s %d %d bytes %v %s",
+			"%s %s %d %d bytes %v %s %s",
 			r.Method,
 			r.RequestURI,
 			lrw.statusCode,
 			lrw.size,
 			duration,
 			getClientIP(r),
+			lrw.Header().Get(RequestIDHeader),
 		)
+
+		// Log impersonated requests
+		if impersonator := lrw.Header().Get(ImpersonatorHeader); impersonator != "" {
+			log.Printf("IMPERSONATION: %s %s performed by %s", r.Method, r.RequestURI, impersonator)
+		}
 		
 		// Log errors
 		if lrw.statusCode >= 400 {
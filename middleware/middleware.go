@@ -37,6 +37,10 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		if claims.IsImpersonated() {
+			w.Header().Set(ImpersonatorHeader, claims.Act.Username)
+		}
+
 		// Set user context
 		ctx := context.WithValue(r.Context(), UserContextKey, claims)
 		next.ServeHTTP(w, r.WithContext(ctx))
@@ -121,8 +125,9 @@ func GetUserFromContext(r *http.Request) (*auth.Claims, bool) {
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+RequestIDHeader)
+		w.Header().Set("Access-Control-Expose-Headers", ImpersonatorHeader+", "+RequestIDHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -293,4 +293,83 @@ func TestGetUserFromContext(t *testing.T) {
 	req2 := httptest.NewRequest("GET", "/test", nil)
 	_, ok2 := GetUserFromContext(req2)
 	assert.False(t, ok2)
-}
\ No newline at end of file
+}
+
+func TestDenyImpersonation(t *testing.T) {
+	tests := []struct {
+		name           string
+		userClaims     *auth.Claims
+		expectedStatus int
+	}{
+		{
+			name:           "Regular session",
+			userClaims:     &auth.Claims{UserID: 2, Username: "testuser"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Impersonated session",
+			userClaims: &auth.Claims{
+				UserID:   2,
+				Username: "testuser",
+				Act:      &auth.Actor{Subject: "1", UserID: 1, Username: "admin"},
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "No user context",
+			userClaims:     nil,
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create test handler
+			testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			// Create middleware
+			middleware := DenyImpersonation(testHandler)
+
+			// Create request with context
+			req := httptest.NewRequest("POST", "/test", nil)
+			if tt.userClaims != nil {
+				ctx := context.WithValue(req.Context(), UserContextKey, tt.userClaims)
+				req = req.WithContext(ctx)
+			}
+
+			// Create response recorder
+			rr := httptest.NewRecorder()
+
+			// Execute middleware
+			middleware.ServeHTTP(rr, req)
+
+			// Check status code
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = GetRequestID(r)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Generated when absent
+	req := httptest.NewRequest("GET", "/test", nil)
+	rr := httptest.NewRecorder()
+	RequestID(testHandler).ServeHTTP(rr, req)
+	assert.NotEmpty(t, seen)
+	assert.Equal(t, seen, rr.Header().Get(RequestIDHeader))
+
+	// Propagated when supplied by the client
+	req = httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set(RequestIDHeader, "client-request-id")
+	rr = httptest.NewRecorder()
+	RequestID(testHandler).ServeHTTP(rr, req)
+	assert.Equal(t, "client-request-id", seen)
+	assert.Equal(t, "client-request-id", rr.Header().Get(RequestIDHeader))
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDContextKey = contextKey("request_id")
+	RequestIDHeader     = "X-Request-ID"
+)
+
+// RequestID middleware tags every request with an ID, reusing a sane inbound X-Request-ID
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 64 {
+			requestID = uuid.New().String()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), RequestIDContextKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestID extracts the request ID from request context
+func GetRequestID(r *http.Request) string {
+	requestID, _ := r.Context().Value(RequestIDContextKey).(string)
+	return requestID
+}
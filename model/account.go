@@ -0,0 +1,86 @@
+package model
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Export job statuses
+const (
+	ExportStatusPending = "pending"
+	ExportStatusRunning = "running"
+	ExportStatusReady   = "ready"
+	ExportStatusFailed  = "failed"
+)
+
+// ExportJob tracks an asynchronous personal data export
+type ExportJob struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	Status      string     `json:"status" db:"status"`
+	FilePath    string     `json:"-" db:"file_path"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+}
+
+// UserDataExport is the content of a personal data export archive
+type UserDataExport struct {
+	ExportedAt  time.Time      `json:"exported_at"`
+	Profile     User           `json:"profile"`
+	Roles       []Role         `json:"roles"`
+	Todos       []Todo         `json:"todos"`
+	Sessions    []RefreshToken `json:"sessions"`
+	AuditEvents []AuditEvent   `json:"audit_events"`
+}
+
+// WriteArchive writes the export as a ZIP of indented JSON documents: the whole export as
+// export.json, plus one document per section
+func (e *UserDataExport) WriteArchive(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	documents := []struct {
+		name     string
+		document interface{}
+	}{
+		{"export.json", e},
+		{"profile.json", e.Profile},
+		{"roles.json", e.Roles},
+		{"todos.json", e.Todos},
+		{"sessions.json", e.Sessions},
+		{"audit_events.json", e.AuditEvents},
+	}
+	for _, doc := range documents {
+		writer, err := archive.Create(doc.name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to archive: %w", doc.name, err)
+		}
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc.document); err != nil {
+			return fmt.Errorf("failed to write %s: %w", doc.name, err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	return nil
+}
+
+// Account DTOs
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type RecoverAccountRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
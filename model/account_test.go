@@ -0,0 +1,65 @@
+package model
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserDataExportWriteArchive(t *testing.T) {
+	export := &UserDataExport{
+		ExportedAt: time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC),
+		Profile:    User{ID: 7, Username: "jane", Email: "jane@example.com", PasswordHash: "secret"},
+		Roles:      []Role{{ID: 2, Name: "user"}},
+		Todos:      []Todo{{ID: 1, UserID: 7, Title: "Write report"}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteArchive(&buf))
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	var names []string
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		files[file.Name] = data
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"export.json", "profile.json", "roles.json", "todos.json", "sessions.json", "audit_events.json"}, names)
+
+	var full struct {
+		ExportedAt time.Time `json:"exported_at"`
+		Profile    User      `json:"profile"`
+		Todos      []struct {
+			Title    string `json:"title"`
+			Priority string `json:"priority"`
+		} `json:"todos"`
+	}
+	require.NoError(t, json.Unmarshal(files["export.json"], &full))
+	assert.True(t, export.ExportedAt.Equal(full.ExportedAt))
+	assert.Equal(t, "jane", full.Profile.Username)
+	require.Len(t, full.Todos, 1)
+	assert.Equal(t, "Write report", full.Todos[0].Title)
+	assert.Equal(t, "none", full.Todos[0].Priority)
+
+	var profile User
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	assert.Equal(t, 7, profile.ID)
+	assert.NotContains(t, string(files["profile.json"]), "secret")
+
+	// Empty sections are still present, as JSON null
+	assert.Equal(t, "null", string(bytes.TrimSpace(files["sessions.json"])))
+	assert.Equal(t, "null", string(bytes.TrimSpace(files["audit_events.json"])))
+}
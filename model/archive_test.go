@@ -0,0 +1,46 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoCheckArchive(t *testing.T) {
+	now := time.Now()
+	parentID := 1
+
+	assert.NoError(t, (&Todo{Completed: true}).CheckArchive())
+	assert.EqualError(t, (&Todo{}).CheckArchive(), "only completed todos can be archived")
+	assert.EqualError(t, (&Todo{Completed: true, ParentID: &parentID}).CheckArchive(),
+		"subtasks are archived along with their top-level todo")
+	assert.EqualError(t, (&Todo{Completed: true, ArchivedAt: &now}).CheckArchive(), "todo is already archived")
+}
+
+func TestUserPreferencesAutoArchives(t *testing.T) {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	completedAt := now.AddDate(0, 0, -8)
+	recent := now.AddDate(0, 0, -6)
+	parentID := 1
+
+	prefs := DefaultUserPreferences(1)
+	prefs.AutoArchiveDays = 7
+
+	assert.True(t, prefs.AutoArchives(&Todo{Completed: true, CompletedAt: &completedAt}, now))
+	assert.False(t, prefs.AutoArchives(&Todo{Completed: true, CompletedAt: &recent}, now))
+
+	// Todos completed before completed_at was tracked fall back to updated_at
+	assert.True(t, prefs.AutoArchives(&Todo{Completed: true, UpdatedAt: completedAt}, now))
+	assert.False(t, prefs.AutoArchives(&Todo{Completed: true, UpdatedAt: recent}, now))
+
+	// Only completed top-level todos outside the archive and the trash qualify
+	assert.False(t, prefs.AutoArchives(&Todo{CompletedAt: &completedAt}, now))
+	assert.False(t, prefs.AutoArchives(&Todo{Completed: true, CompletedAt: &completedAt, ParentID: &parentID}, now))
+	assert.False(t, prefs.AutoArchives(&Todo{Completed: true, CompletedAt: &completedAt, ArchivedAt: &now}, now))
+	assert.False(t, prefs.AutoArchives(&Todo{Completed: true, CompletedAt: &completedAt, DeletedAt: &now}, now))
+
+	// Zero turns auto-archiving off
+	prefs.AutoArchiveDays = 0
+	assert.False(t, prefs.AutoArchives(&Todo{Completed: true, CompletedAt: &completedAt}, now))
+}
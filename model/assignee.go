@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Assignee is a user responsible for a todo alongside its owner
+type Assignee struct {
+	UserID     int       `json:"user_id" db:"user_id"`
+	Username   string    `json:"username"`
+	AssignedBy *int      `json:"assigned_by" db:"assigned_by"`
+	AssignedAt time.Time `json:"assigned_at" db:"created_at"`
+}
+
+// StatusOnly reports whether an update only moves a todo along its workflow, the one change
+// assignees who do not own the todo may make
+func (r UpdateTodoRequest) StatusOnly() bool {
+	return r.Title == nil && r.Content == nil && !r.DueAt.Set && !r.RemindAt.Set && r.Recurrence == nil &&
+		r.TagIDs == nil && !r.ProjectID.Set && !r.ParentID.Set && r.AutoComplete == nil && r.Priority == nil &&
+		r.AssigneeIDs == nil
+}
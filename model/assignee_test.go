@@ -0,0 +1,23 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateTodoRequestStatusOnly(t *testing.T) {
+	status := "in_progress"
+	completed := true
+	title := "Renamed"
+	assignees := []int{2}
+
+	assert.True(t, UpdateTodoRequest{}.StatusOnly())
+	assert.True(t, UpdateTodoRequest{Status: &status}.StatusOnly())
+	assert.True(t, UpdateTodoRequest{Completed: &completed}.StatusOnly())
+
+	assert.False(t, UpdateTodoRequest{Status: &status, Title: &title}.StatusOnly())
+	assert.False(t, UpdateTodoRequest{DueAt: OptionalTime{Set: true}}.StatusOnly())
+	assert.False(t, UpdateTodoRequest{ProjectID: OptionalID{Set: true}}.StatusOnly())
+	assert.False(t, UpdateTodoRequest{AssigneeIDs: &assignees}.StatusOnly())
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Attachment is a file uploaded to a todo. The file itself lives in blob storage under
+// StorageKey and is downloaded through a signed, time-limited URL.
+type Attachment struct {
+	ID          int       `json:"id" db:"id"`
+	TodoID      int       `json:"todo_id" db:"todo_id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+
+	DownloadURL  string     `json:"download_url,omitempty"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
+
+// AttachmentUsage is how much of their attachment quota a user has used
+type AttachmentUsage struct {
+	UsedBytes  int64 `json:"used_bytes"`
+	QuotaBytes int64 `json:"quota_bytes"`
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditEvent represents a security-relevant action recorded in the audit log
+type AuditEvent struct {
+	ID             int64           `json:"id" db:"id"`
+	ActorID        *int            `json:"actor_id,omitempty" db:"actor_id"`
+	ImpersonatorID *int            `json:"impersonator_id,omitempty" db:"impersonator_id"`
+	Action         string          `json:"action" db:"action"`
+	TargetType     string          `json:"target_type,omitempty" db:"target_type"`
+	TargetID       string          `json:"target_id,omitempty" db:"target_id"`
+	Before         json.RawMessage `json:"before,omitempty" db:"before_state"`
+	After          json.RawMessage `json:"after,omitempty" db:"after_state"`
+	IPAddress      string          `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent      string          `json:"user_agent,omitempty" db:"user_agent"`
+	RequestID      string          `json:"request_id,omitempty" db:"request_id"`
+	PrevHash       string          `json:"prev_hash" db:"prev_hash"`
+	Hash           string          `json:"hash" db:"hash"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+}
+
+// ChainHash computes the tamper-evident hash of the event linked to the previous entry's hash
+func (e *AuditEvent) ChainHash(prevHash string) string {
+	fields := []string{
+		prevHash,
+		formatOptionalID(e.ActorID),
+		formatOptionalID(e.ImpersonatorID),
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		string(e.Before),
+		string(e.After),
+		e.IPAddress,
+		e.UserAgent,
+		e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(hash[:])
+}
+
+func formatOptionalID(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", *id)
+}
+
+// AuditEntry describes an action to record; Before and After are marshalled to JSON
+type AuditEntry struct {
+	Action     string `validate:"required,max=100"`
+	TargetType string `validate:"max=50"`
+	TargetID   string `validate:"max=64"`
+	Before     interface{}
+	After      interface{}
+}
+
+// RequestMeta carries who made a request and from where, for audit purposes
+type RequestMeta struct {
+	ActorID        int
+	ImpersonatorID int
+	IPAddress      string
+	UserAgent      string
+	RequestID      string
+}
+
+// AuditEventFilter selects audit events for querying and export
+type AuditEventFilter struct {
+	Page           int        `json:"page" validate:"min=1"`
+	Limit          int        `json:"limit" validate:"min=1,max=100"`
+	ActorID        int        `json:"actor_id" validate:"min=0"`
+	ImpersonatorID int        `json:"impersonator_id" validate:"min=0"`
+	Action         string     `json:"action" validate:"omitempty,max=100"`
+	TargetType     string     `json:"target_type" validate:"omitempty,max=50"`
+	TargetID       string     `json:"target_id" validate:"omitempty,max=64"`
+	RequestID      string     `json:"request_id" validate:"omitempty,max=64"`
+	From           *time.Time `json:"from"`
+	To             *time.Time `json:"to"`
+}
+
+// AuditVerification reports the result of checking the audit hash chain
+type AuditVerification struct {
+	Valid          bool   `json:"valid"`
+	Checked        int64  `json:"checked"`
+	FirstInvalidID *int64 `json:"first_invalid_id,omitempty"`
+}
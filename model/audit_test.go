@@ -0,0 +1,51 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEventChainHash(t *testing.T) {
+	actorID := 1
+	event := AuditEvent{
+		ActorID:    &actorID,
+		Action:     "auth.login",
+		TargetType: "user",
+		TargetID:   "1",
+		IPAddress:  "127.0.0.1",
+		CreatedAt:  time.Date(2025, 10, 6, 12, 0, 0, 0, time.UTC),
+	}
+
+	hash := event.ChainHash("")
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, event.ChainHash(""))
+
+	// Linking to a different predecessor changes the hash
+	assert.NotEqual(t, hash, event.ChainHash(hash))
+
+	// Any tampering with recorded fields changes the hash
+	tampered := event
+	tampered.Action = "auth.logout_all"
+	assert.NotEqual(t, hash, tampered.ChainHash(""))
+
+	tampered = event
+	tampered.ActorID = nil
+	assert.NotEqual(t, hash, tampered.ChainHash(""))
+}
+
+func TestAuditEntryValidation(t *testing.T) {
+	v := validator.New()
+
+	entry := AuditEntry{Action: "todo.delete", TargetType: "todo", TargetID: "42"}
+	assert.NoError(t, v.Struct(entry))
+
+	// target_id is stored in a VARCHAR(64) column
+	entry.TargetID = strings.Repeat("x", 65)
+	assert.Error(t, v.Struct(entry))
+
+	assert.Error(t, v.Struct(AuditEntry{TargetType: "todo"}))
+}
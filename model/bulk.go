@@ -0,0 +1,114 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Bulk operation kinds
+const (
+	BulkCreate   = "create"
+	BulkUpdate   = "update"
+	BulkComplete = "complete"
+	BulkMove     = "move"
+	BulkDelete   = "delete"
+)
+
+// Bulk modes: atomic applies every operation or none, best_effort applies those it can
+const (
+	BulkAtomic     = "atomic"
+	BulkBestEffort = "best_effort"
+)
+
+// Bulk result statuses
+const (
+	BulkApplied = "applied"
+	BulkFailed  = "failed"
+	BulkSkipped = "skipped"
+)
+
+// BulkOperation is one change of a bulk request. ID names the todo of every operation but
+// create; Todo holds the todo to create, Changes the edit of an update and ProjectID the
+// destination of a move, where null takes the todo out of its project.
+type BulkOperation struct {
+	Op        string             `json:"op" validate:"required,oneof=create update complete move delete"`
+	ID        int                `json:"id,omitempty" validate:"omitempty,min=1"`
+	Todo      *CreateTodoRequest `json:"todo,omitempty"`
+	Changes   *UpdateTodoRequest `json:"changes,omitempty"`
+	ProjectID OptionalID         `json:"project_id"`
+}
+
+// Permission returns the permission the operation requires
+func (o BulkOperation) Permission() string {
+	if o.Op == BulkDelete {
+		return "delete_todos"
+	}
+	return "write_todos"
+}
+
+// Check reports an operation that lacks the fields its kind needs
+func (o BulkOperation) Check() error {
+	if o.Op == BulkCreate {
+		if o.Todo == nil {
+			return errors.New("todo is required to create a todo")
+		}
+		return nil
+	}
+	if o.ID == 0 {
+		return fmt.Errorf("id is required to %s a todo", o.Op)
+	}
+	_, err := o.Edit()
+	return err
+}
+
+// Edit returns the update an update, complete or move operation applies; delete has none
+func (o BulkOperation) Edit() (UpdateTodoRequest, error) {
+	switch o.Op {
+	case BulkUpdate:
+		if o.Changes == nil {
+			return UpdateTodoRequest{}, errors.New("changes is required to update a todo")
+		}
+		return *o.Changes, nil
+	case BulkComplete:
+		completed := true
+		return UpdateTodoRequest{Completed: &completed}, nil
+	case BulkMove:
+		if !o.ProjectID.Set {
+			return UpdateTodoRequest{}, errors.New("project_id is required to move a todo")
+		}
+		return UpdateTodoRequest{ProjectID: o.ProjectID}, nil
+	}
+	return UpdateTodoRequest{}, nil
+}
+
+// BulkSelection applies one operation to every todo matching Filter, which takes the filters
+// of a todo listing
+type BulkSelection struct {
+	Filter PaginationRequest `json:"filter"`
+	BulkOperation
+}
+
+// BulkRequest lists operations to apply together, optionally followed by those of a selection
+type BulkRequest struct {
+	Mode       string          `json:"mode,omitempty" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []BulkOperation `json:"operations" validate:"dive"`
+	Selection  *BulkSelection  `json:"selection,omitempty"`
+}
+
+// BulkResult reports the outcome of one operation; Todo is the created or changed todo
+type BulkResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     int    `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Todo   *Todo  `json:"todo,omitempty"`
+}
+
+// BulkResponse reports the outcome of a bulk request
+type BulkResponse struct {
+	Mode    string       `json:"mode"`
+	Applied int          `json:"applied"`
+	Failed  int          `json:"failed"`
+	Results []BulkResult `json:"results"`
+}
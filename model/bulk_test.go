@@ -0,0 +1,64 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkOperationEdit(t *testing.T) {
+	req, err := BulkOperation{Op: BulkComplete, ID: 3}.Edit()
+	require.NoError(t, err)
+	require.NotNil(t, req.Completed)
+	assert.True(t, *req.Completed)
+
+	var move BulkOperation
+	require.NoError(t, json.Unmarshal([]byte(`{"op": "move", "id": 3, "project_id": null}`), &move))
+	req, err = move.Edit()
+	require.NoError(t, err)
+	assert.True(t, req.ProjectID.Set)
+	assert.Nil(t, req.ProjectID.ID)
+
+	_, err = BulkOperation{Op: BulkMove, ID: 3}.Edit()
+	assert.Error(t, err)
+	_, err = BulkOperation{Op: BulkUpdate, ID: 3}.Edit()
+	assert.Error(t, err)
+}
+
+func TestBulkOperationCheck(t *testing.T) {
+	assert.NoError(t, BulkOperation{Op: BulkCreate, Todo: &CreateTodoRequest{Title: "Milk"}}.Check())
+	assert.Error(t, BulkOperation{Op: BulkCreate}.Check())
+	assert.NoError(t, BulkOperation{Op: BulkDelete, ID: 3}.Check())
+	assert.Error(t, BulkOperation{Op: BulkDelete}.Check())
+
+	assert.Equal(t, "delete_todos", BulkOperation{Op: BulkDelete}.Permission())
+	assert.Equal(t, "write_todos", BulkOperation{Op: BulkMove}.Permission())
+}
+
+func TestBulkRequestValidation(t *testing.T) {
+	v := validator.New()
+
+	var req BulkRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"mode": "best_effort", "operations": [
+		{"op": "create", "todo": {"title": "Milk"}},
+		{"op": "update", "id": 4, "changes": {"title": "Bread"}}
+	]}`), &req))
+	assert.NoError(t, v.Struct(req))
+
+	req.Operations[0].Todo.Title = ""
+	assert.Error(t, v.Struct(req))
+
+	assert.Error(t, v.Struct(BulkRequest{Operations: []BulkOperation{{Op: "archive", ID: 4}}}))
+	assert.Error(t, v.Struct(BulkRequest{Mode: "eventually"}))
+}
+
+func TestBulkSelectionJSON(t *testing.T) {
+	var selection BulkSelection
+	require.NoError(t, json.Unmarshal([]byte(`{"filter": {"filter": "pending", "tag_ids": [7]}, "op": "complete"}`), &selection))
+	assert.Equal(t, BulkComplete, selection.Op)
+	assert.Equal(t, "pending", selection.Filter.Filter)
+	assert.Equal(t, []int{7}, selection.Filter.TagIDs)
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Comment is a Markdown comment on a todo. Top-level comments start threads; replies set
+// ParentID to the comment they answer and are listed under it.
+type Comment struct {
+	ID        int        `json:"id" db:"id"`
+	TodoID    int        `json:"todo_id" db:"todo_id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	Username  string     `json:"username"`
+	ParentID  *int       `json:"parent_id" db:"parent_id"`
+	Content   string     `json:"content" db:"content"`
+	Mentions  []Mention  `json:"mentions"`
+	Replies   []*Comment `json:"replies,omitempty"`
+	EditedAt  *time.Time `json:"edited_at" db:"edited_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Mention is a user mentioned in a comment who has access to its todo
+type Mention struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// CommentRevision is an earlier version of an edited comment
+type CommentRevision struct {
+	ID        int       `json:"id" db:"id"`
+	CommentID int       `json:"comment_id" db:"comment_id"`
+	Content   string    `json:"content" db:"content"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// In converts the comment's timestamps, and those of its replies, to loc for presentation
+func (c *Comment) In(loc *time.Location) {
+	c.CreatedAt = c.CreatedAt.In(loc)
+	c.UpdatedAt = c.UpdatedAt.In(loc)
+	if c.EditedAt != nil {
+		edited := c.EditedAt.In(loc)
+		c.EditedAt = &edited
+	}
+	for _, reply := range c.Replies {
+		reply.In(loc)
+	}
+}
+
+// Comment DTOs
+type CreateCommentRequest struct {
+	Content  string `json:"content" validate:"required,min=1,max=10000"`
+	ParentID *int   `json:"parent_id,omitempty" validate:"omitempty,min=1"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" validate:"required,min=1,max=10000"`
+}
+
+// maxMentions caps how many users a single comment can mention
+const maxMentions = 50
+
+var (
+	mentionPattern   = regexp.MustCompile(`(^|[^\w@.])@([A-Za-z0-9_.-]*[A-Za-z0-9_])`)
+	codeBlockPattern = regexp.MustCompile("(?s)```.*?(```|$)")
+	codeSpanPattern  = regexp.MustCompile("`[^`\n]*`")
+)
+
+// ParseMentions returns the distinct usernames mentioned as @username in Markdown content,
+// in order of appearance. Mentions inside code and email addresses are ignored.
+func ParseMentions(content string) []string {
+	content = codeBlockPattern.ReplaceAllString(content, " ")
+	content = codeSpanPattern.ReplaceAllString(content, " ")
+
+	seen := map[string]bool{}
+	var usernames []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		username := match[2]
+		key := strings.ToLower(username)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		usernames = append(usernames, username)
+		if len(usernames) == maxMentions {
+			break
+		}
+	}
+	return usernames
+}
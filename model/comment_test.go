@@ -0,0 +1,28 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"@alice can you look at this?", []string{"alice"}},
+		{"Thanks @bob, and @carol.", []string{"bob", "carol"}},
+		{"@dave @Dave @dave", []string{"dave"}},
+		{"(cc @erin_ops)", []string{"erin_ops"}},
+		{"mail frank@example.com", nil},
+		{"@@grace", nil},
+		{"run `@heidi` first", nil},
+		{"```\n@ivan\n```\nthen @judy", []string{"judy"}},
+		{"no mentions here", nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ParseMentions(tt.content), tt.content)
+	}
+}
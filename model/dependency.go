@@ -0,0 +1,153 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dependency records that a todo is blocked by another todo until that one is completed
+type Dependency struct {
+	TodoID      int       `json:"todo_id" db:"todo_id"`
+	BlockedByID int       `json:"blocked_by_id" db:"blocked_by_id"`
+	CreatedBy   *int      `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// DependencyEdge is an edge of a dependency graph, pointing from a todo to the todo blocking it
+type DependencyEdge struct {
+	TodoID      int `json:"todo_id"`
+	BlockedByID int `json:"blocked_by_id"`
+}
+
+// DependencyNode is a todo in a dependency graph
+type DependencyNode struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	Completed bool   `json:"completed"`
+	Blocked   bool   `json:"blocked"`
+	BlockedBy []int  `json:"blocked_by"`
+}
+
+// DependencyGraph holds the todos of a project and the dependencies between them
+type DependencyGraph struct {
+	ProjectID int              `json:"project_id"`
+	Nodes     []DependencyNode `json:"nodes"`
+	Edges     []DependencyEdge `json:"edges"`
+}
+
+// TodoDependencies holds the todos blocking a todo and the todos it blocks
+type TodoDependencies struct {
+	BlockedBy []*Todo `json:"blocked_by"`
+	Blocking  []*Todo `json:"blocking"`
+}
+
+// Dependency graph formats
+const (
+	DependencyFormatGraph  = "graph"
+	DependencyFormatSorted = "sorted"
+)
+
+// FindCycle returns the cycle that making todoID blocked by blockedByID would close, as the
+// todo IDs along it starting and ending with todoID, or nil if there is none
+func FindCycle(edges []DependencyEdge, todoID, blockedByID int) []int {
+	if todoID == blockedByID {
+		return []int{todoID, todoID}
+	}
+
+	blockers := map[int][]int{}
+	for _, edge := range edges {
+		blockers[edge.TodoID] = append(blockers[edge.TodoID], edge.BlockedByID)
+	}
+
+	// Search the todos blocking blockedByID, directly or not, for todoID
+	via := map[int]int{blockedByID: blockedByID}
+	queue := []int{blockedByID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, next := range blockers[id] {
+			if _, seen := via[next]; seen {
+				continue
+			}
+			via[next] = id
+			if next != todoID {
+				queue = append(queue, next)
+				continue
+			}
+
+			path := []int{todoID}
+			for step := id; ; step = via[step] {
+				path = append(path, step)
+				if step == blockedByID {
+					break
+				}
+			}
+			// The walk back ran from todoID to blockedByID; the cycle runs the other way
+			for i, j := 1, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return append(path, todoID)
+		}
+	}
+	return nil
+}
+
+// FormatCycle formats a cycle found by FindCycle, such as "3 -> 5 -> 3"
+func FormatCycle(path []int) string {
+	ids := make([]string, len(path))
+	for i, id := range path {
+		ids[i] = strconv.Itoa(id)
+	}
+	return strings.Join(ids, " -> ")
+}
+
+// SortDependencies orders ids so that every todo comes after the todos blocking it, keeping
+// the given order otherwise. Edges to todos outside ids are ignored. An error is returned
+// when the edges hold a cycle.
+func SortDependencies(ids []int, edges []DependencyEdge) ([]int, error) {
+	index := make(map[int]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	waiting := make([]int, len(ids))
+	blocking := map[int][]int{}
+	for _, edge := range edges {
+		_, todoOK := index[edge.TodoID]
+		_, blockerOK := index[edge.BlockedByID]
+		if !todoOK || !blockerOK {
+			continue
+		}
+		waiting[index[edge.TodoID]]++
+		blocking[edge.BlockedByID] = append(blocking[edge.BlockedByID], edge.TodoID)
+	}
+
+	// Repeatedly take the first todo, in the given order, no longer waiting on any other
+	sorted := make([]int, 0, len(ids))
+	done := make([]bool, len(ids))
+	for len(sorted) < len(ids) {
+		next := -1
+		for i := range ids {
+			if !done[i] && waiting[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("dependencies contain a cycle")
+		}
+		done[next] = true
+		sorted = append(sorted, ids[next])
+		for _, id := range blocking[ids[next]] {
+			waiting[index[id]]--
+		}
+	}
+	return sorted, nil
+}
+
+// Dependency DTOs
+type CreateDependencyRequest struct {
+	BlockedByID int `json:"blocked_by_id" validate:"required,min=1"`
+}
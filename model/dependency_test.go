@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindCycle(t *testing.T) {
+	// 2 is blocked by 1, 3 by 2 and 4 by 3
+	edges := []DependencyEdge{{2, 1}, {3, 2}, {4, 3}}
+
+	assert.Nil(t, FindCycle(edges, 5, 4))
+	assert.Nil(t, FindCycle(edges, 4, 1))
+	assert.Equal(t, []int{7, 7}, FindCycle(edges, 7, 7))
+	assert.Equal(t, []int{1, 2, 1}, FindCycle(edges, 1, 2))
+	assert.Equal(t, []int{1, 4, 3, 2, 1}, FindCycle(edges, 1, 4))
+}
+
+func TestFindCycleBranches(t *testing.T) {
+	// 1 is blocked by 2 and 3; 3 by 4
+	edges := []DependencyEdge{{1, 2}, {1, 3}, {3, 4}}
+
+	assert.Nil(t, FindCycle(edges, 2, 4))
+	assert.Equal(t, []int{4, 1, 3, 4}, FindCycle(edges, 4, 1))
+}
+
+func TestFormatCycle(t *testing.T) {
+	assert.Equal(t, "3 -> 5 -> 3", FormatCycle([]int{3, 5, 3}))
+}
+
+func TestSortDependencies(t *testing.T) {
+	// 1 is blocked by 3, 2 by 1; 9 lies outside the sorted todos
+	edges := []DependencyEdge{{1, 3}, {2, 1}, {4, 9}}
+
+	sorted, err := SortDependencies([]int{1, 2, 3, 4}, edges)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 1, 2, 4}, sorted)
+
+	sorted, err = SortDependencies([]int{4, 3, 2, 1}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 3, 2, 1}, sorted)
+
+	_, err = SortDependencies([]int{1, 2}, []DependencyEdge{{1, 2}, {2, 1}})
+	assert.Error(t, err)
+}
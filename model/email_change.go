@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Email change statuses
+const (
+	EmailChangePending    = "pending"
+	EmailChangeConfirmed  = "confirmed"
+	EmailChangeCancelled  = "cancelled"
+	EmailChangeReverted   = "reverted"
+	EmailChangeSuperseded = "superseded"
+)
+
+// EmailChange is a requested change of a user's email address. It is applied only once the
+// new address confirms it; the old address can cancel it, or revert it after confirmation.
+type EmailChange struct {
+	ID               int        `json:"id" db:"id"`
+	UserID           int        `json:"user_id" db:"user_id"`
+	OldEmail         string     `json:"old_email" db:"old_email"`
+	NewEmail         string     `json:"new_email" db:"new_email"`
+	ConfirmTokenHash string     `json:"-" db:"confirm_token_hash"`
+	CancelTokenHash  string     `json:"-" db:"cancel_token_hash"`
+	Status           string     `json:"status" db:"status"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	CancelExpiresAt  time.Time  `json:"cancel_expires_at" db:"cancel_expires_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// Email change DTOs
+type EmailChangeTokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,234 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// TodoSnapshot is the editable state of a todo at one version of its history. Times are UTC
+// and ID lists sorted so snapshots compare field by field.
+type TodoSnapshot struct {
+	Title        string     `json:"title"`
+	Content      string     `json:"content"`
+	Completed    bool       `json:"completed"`
+	Status       string     `json:"status"`
+	Priority     string     `json:"priority"`
+	DueAt        *time.Time `json:"due_at"`
+	RemindAt     *time.Time `json:"remind_at"`
+	ProjectID    *int       `json:"project_id"`
+	ParentID     *int       `json:"parent_id"`
+	AutoComplete bool       `json:"auto_complete"`
+	TagIDs       []int      `json:"tag_ids"`
+	AssigneeIDs  []int      `json:"assignee_ids"`
+}
+
+// FieldChange is one field of a todo changed by a revision
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// TodoRevision is one version of a todo: the changes made by an edit and the todo's state
+// after it. RestoredFrom is set when the edit restored an earlier version.
+type TodoRevision struct {
+	ID           int           `json:"id" db:"id"`
+	TodoID       int           `json:"todo_id" db:"todo_id"`
+	Version      int           `json:"version" db:"version"`
+	ActorID      *int          `json:"actor_id" db:"actor_id"`
+	Actor        string        `json:"actor,omitempty"`
+	RestoredFrom *int          `json:"restored_from,omitempty" db:"restored_from"`
+	Changes      []FieldChange `json:"changes" db:"changes"`
+	Snapshot     TodoSnapshot  `json:"snapshot" db:"snapshot"`
+	CreatedAt    time.Time     `json:"created_at" db:"created_at"`
+}
+
+// SnapshotTodo captures the editable state of a todo with its tags and assignees loaded
+func SnapshotTodo(todo *Todo) TodoSnapshot {
+	snapshot := TodoSnapshot{
+		Title:        todo.Title,
+		Content:      todo.Content,
+		Completed:    todo.Completed,
+		Status:       todo.Status,
+		Priority:     todo.Priority.String(),
+		DueAt:        storedTime(todo.DueAt),
+		RemindAt:     storedTime(todo.RemindAt),
+		ProjectID:    copyID(todo.ProjectID),
+		ParentID:     copyID(todo.ParentID),
+		AutoComplete: todo.AutoComplete,
+		TagIDs:       []int{},
+		AssigneeIDs:  []int{},
+	}
+	for _, tag := range todo.Tags {
+		snapshot.TagIDs = append(snapshot.TagIDs, tag.ID)
+	}
+	for _, assignee := range todo.Assignees {
+		snapshot.AssigneeIDs = append(snapshot.AssigneeIDs, assignee.UserID)
+	}
+	sort.Ints(snapshot.TagIDs)
+	sort.Ints(snapshot.AssigneeIDs)
+	return snapshot
+}
+
+// Diff lists the fields that differ from s in next, in a fixed field order
+func (s TodoSnapshot) Diff(next TodoSnapshot) []FieldChange {
+	changes := []FieldChange{}
+	add := func(field string, changed bool, from, to interface{}) {
+		if changed {
+			changes = append(changes, FieldChange{Field: field, From: from, To: to})
+		}
+	}
+
+	add("title", s.Title != next.Title, s.Title, next.Title)
+	add("content", s.Content != next.Content, s.Content, next.Content)
+	add("completed", s.Completed != next.Completed, s.Completed, next.Completed)
+	add("status", s.Status != next.Status, s.Status, next.Status)
+	add("priority", s.Priority != next.Priority, s.Priority, next.Priority)
+	add("due_at", !sameTime(s.DueAt, next.DueAt), s.DueAt, next.DueAt)
+	add("remind_at", !sameTime(s.RemindAt, next.RemindAt), s.RemindAt, next.RemindAt)
+	add("project_id", !sameOptionalID(s.ProjectID, next.ProjectID), s.ProjectID, next.ProjectID)
+	add("parent_id", !sameOptionalID(s.ParentID, next.ParentID), s.ParentID, next.ParentID)
+	add("auto_complete", s.AutoComplete != next.AutoComplete, s.AutoComplete, next.AutoComplete)
+	add("tag_ids", !sameIDs(s.TagIDs, next.TagIDs), s.TagIDs, next.TagIDs)
+	add("assignee_ids", !sameIDs(s.AssigneeIDs, next.AssigneeIDs), s.AssigneeIDs, next.AssigneeIDs)
+	return changes
+}
+
+// RestoreRequest builds the update that takes a todo from its current state back to s. Only
+// fields that differ are included, so unchanged reminders and relations are left alone;
+// completion follows the restored status.
+func (s TodoSnapshot) RestoreRequest(current TodoSnapshot) UpdateTodoRequest {
+	var req UpdateTodoRequest
+	if s.Title != current.Title {
+		title := s.Title
+		req.Title = &title
+	}
+	if s.Content != current.Content {
+		content := s.Content
+		req.Content = &content
+	}
+	if s.Status != current.Status {
+		status := s.Status
+		req.Status = &status
+	} else if s.Completed != current.Completed {
+		completed := s.Completed
+		req.Completed = &completed
+	}
+	if s.Priority != current.Priority {
+		priority := s.Priority
+		req.Priority = &priority
+	}
+	if !sameTime(s.DueAt, current.DueAt) {
+		req.DueAt = OptionalTime{Set: true, Time: utcTime(s.DueAt)}
+	}
+	if !sameTime(s.RemindAt, current.RemindAt) {
+		req.RemindAt = OptionalTime{Set: true, Time: utcTime(s.RemindAt)}
+	}
+	if !sameOptionalID(s.ProjectID, current.ProjectID) {
+		req.ProjectID = OptionalID{Set: true, ID: copyID(s.ProjectID)}
+	}
+	if !sameOptionalID(s.ParentID, current.ParentID) {
+		req.ParentID = OptionalID{Set: true, ID: copyID(s.ParentID)}
+	}
+	if s.AutoComplete != current.AutoComplete {
+		autoComplete := s.AutoComplete
+		req.AutoComplete = &autoComplete
+	}
+	if !sameIDs(s.TagIDs, current.TagIDs) {
+		tagIDs := append([]int{}, s.TagIDs...)
+		req.TagIDs = &tagIDs
+	}
+	if !sameIDs(s.AssigneeIDs, current.AssigneeIDs) {
+		assigneeIDs := append([]int{}, s.AssigneeIDs...)
+		req.AssigneeIDs = &assigneeIDs
+	}
+	return req
+}
+
+// In converts the revision's timestamps, including changed due and reminder times, to loc for
+// presentation
+func (r *TodoRevision) In(loc *time.Location) {
+	r.CreatedAt = r.CreatedAt.In(loc)
+	r.Snapshot.DueAt = localTime(r.Snapshot.DueAt, loc)
+	r.Snapshot.RemindAt = localTime(r.Snapshot.RemindAt, loc)
+	for i, change := range r.Changes {
+		if change.Field != "due_at" && change.Field != "remind_at" {
+			continue
+		}
+		if from, ok := changedTime(change.From); ok {
+			r.Changes[i].From = localTime(from, loc)
+		}
+		if to, ok := changedTime(change.To); ok {
+			r.Changes[i].To = localTime(to, loc)
+		}
+	}
+}
+
+// changedTime reads a time from a change value, which is a string once the change has been
+// stored and loaded again
+func changedTime(value interface{}) (*time.Time, bool) {
+	switch v := value.(type) {
+	case *time.Time:
+		return v, v != nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return &t, err == nil
+	}
+	return nil, false
+}
+
+func utcTime(t *time.Time) *time.Time {
+	return localTime(t, time.UTC)
+}
+
+// storedTime normalizes a time to the UTC seconds the database keeps, so a snapshot of a todo
+// just saved matches one of the same todo loaded again
+func storedTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	stored := t.UTC().Truncate(time.Second)
+	return &stored
+}
+
+func localTime(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(loc)
+	return &local
+}
+
+func copyID(id *int) *int {
+	if id == nil {
+		return nil
+	}
+	copied := *id
+	return &copied
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func sameOptionalID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
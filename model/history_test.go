@@ -0,0 +1,116 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotTodo(t *testing.T) {
+	dhaka := time.FixedZone("Asia/Dhaka", 6*60*60)
+	due := time.Date(2025, 10, 1, 9, 0, 0, 500, dhaka)
+	projectID := 3
+	todo := &Todo{
+		Title:     "Pay rent",
+		Status:    "todo",
+		Priority:  PriorityHigh,
+		DueAt:     &due,
+		ProjectID: &projectID,
+		Tags:      []Tag{{ID: 9}, {ID: 4}},
+		Assignees: []Assignee{{UserID: 7}},
+	}
+
+	snapshot := SnapshotTodo(todo)
+	assert.Equal(t, "high", snapshot.Priority)
+	assert.Equal(t, time.Date(2025, 10, 1, 3, 0, 0, 0, time.UTC), *snapshot.DueAt)
+	assert.Equal(t, []int{4, 9}, snapshot.TagIDs)
+	assert.Equal(t, []int{7}, snapshot.AssigneeIDs)
+	assert.Equal(t, []int{}, SnapshotTodo(&Todo{}).TagIDs)
+
+	// The snapshot does not share state with the todo
+	projectID = 5
+	assert.Equal(t, 3, *snapshot.ProjectID)
+}
+
+func TestTodoSnapshotDiff(t *testing.T) {
+	due := time.Date(2025, 10, 1, 3, 0, 0, 0, time.UTC)
+	sameDue := due.In(time.FixedZone("Asia/Dhaka", 6*60*60))
+	before := TodoSnapshot{Title: "Pay rent", Status: "todo", Priority: "none", DueAt: &due, TagIDs: []int{1}}
+
+	assert.Empty(t, before.Diff(before))
+
+	after := before
+	after.DueAt = &sameDue
+	assert.Empty(t, before.Diff(after), "equal instants in other zones are unchanged")
+
+	after.Title = "Pay the rent"
+	after.Completed = true
+	after.DueAt = nil
+	after.TagIDs = []int{1, 2}
+	changes := before.Diff(after)
+	require.Len(t, changes, 4)
+	assert.Equal(t, FieldChange{Field: "title", From: "Pay rent", To: "Pay the rent"}, changes[0])
+	assert.Equal(t, "completed", changes[1].Field)
+	assert.Equal(t, "due_at", changes[2].Field)
+	assert.Equal(t, FieldChange{Field: "tag_ids", From: []int{1}, To: []int{1, 2}}, changes[3])
+}
+
+func TestTodoSnapshotRestoreRequest(t *testing.T) {
+	due := time.Date(2025, 10, 1, 3, 0, 0, 0, time.UTC)
+	projectID := 3
+	target := TodoSnapshot{Title: "Pay rent", Status: "todo", Priority: "none", DueAt: &due, ProjectID: &projectID, TagIDs: []int{1}}
+	current := TodoSnapshot{Title: "Pay the rent", Status: "done", Completed: true, Priority: "none", TagIDs: []int{1}}
+
+	req := target.RestoreRequest(current)
+	require.NotNil(t, req.Title)
+	assert.Equal(t, "Pay rent", *req.Title)
+	require.NotNil(t, req.Status)
+	assert.Equal(t, "todo", *req.Status)
+	assert.Nil(t, req.Completed, "completion follows the status")
+	assert.Nil(t, req.Content)
+	assert.Nil(t, req.Priority)
+	assert.Equal(t, OptionalTime{Set: true, Time: &due}, req.DueAt)
+	assert.False(t, req.RemindAt.Set)
+	assert.Equal(t, OptionalID{Set: true, ID: &projectID}, req.ProjectID)
+	assert.False(t, req.ParentID.Set)
+	assert.Nil(t, req.TagIDs)
+
+	// Nothing to restore yields an empty request
+	assert.Equal(t, UpdateTodoRequest{}, current.RestoreRequest(current))
+
+	// Same status but different completion, as after a workflow change
+	completedTarget := current
+	completedTarget.Completed = false
+	req = completedTarget.RestoreRequest(current)
+	require.NotNil(t, req.Completed)
+	assert.False(t, *req.Completed)
+}
+
+func TestTodoRevisionIn(t *testing.T) {
+	dhaka := time.FixedZone("Asia/Dhaka", 6*60*60)
+	due := time.Date(2025, 10, 1, 3, 0, 0, 0, time.UTC)
+	revision := TodoRevision{
+		Changes:  []FieldChange{{Field: "due_at", From: nil, To: &due}, {Field: "title", From: "a", To: "b"}},
+		Snapshot: TodoSnapshot{DueAt: &due},
+	}
+
+	// Changes loaded from storage hold times as strings
+	data, err := json.Marshal(revision.Changes)
+	require.NoError(t, err)
+	var stored []FieldChange
+	require.NoError(t, json.Unmarshal(data, &stored))
+	revision.Changes = stored
+
+	revision.In(dhaka)
+	to, ok := revision.Changes[0].To.(*time.Time)
+	require.True(t, ok)
+	assert.Equal(t, dhaka, to.Location())
+	assert.True(t, to.Equal(due))
+	assert.Nil(t, revision.Changes[0].From)
+	assert.Equal(t, "b", revision.Changes[1].To)
+	assert.Equal(t, dhaka, revision.Snapshot.DueAt.Location())
+	assert.Equal(t, time.UTC, due.Location(), "the original time is not modified")
+}
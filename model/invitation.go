@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// Registration modes
+const (
+	RegistrationModeOpen            = "open"
+	RegistrationModeInviteOnly      = "invite-only"
+	RegistrationModeDomainAllowlist = "domain-allowlist"
+	RegistrationModeClosed          = "closed"
+)
+
+// RegistrationSettings controls who may register
+type RegistrationSettings struct {
+	Mode           string   `json:"mode" validate:"required,oneof=open invite-only domain-allowlist closed"`
+	AllowedDomains []string `json:"allowed_domains" validate:"dive,fqdn"`
+}
+
+// Organization represents a group of users
+type Organization struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Invitation represents an invite to register with a pre-assigned role
+type Invitation struct {
+	ID             int        `json:"id" db:"id"`
+	Email          string     `json:"email,omitempty" db:"email"`
+	RoleID         int        `json:"role_id" db:"role_id"`
+	OrganizationID *int       `json:"organization_id,omitempty" db:"organization_id"`
+	CreatedBy      *int       `json:"created_by,omitempty" db:"created_by"`
+	ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt         *time.Time `json:"used_at,omitempty" db:"used_at"`
+	UsedBy         *int       `json:"used_by,omitempty" db:"used_by"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	Code           string     `json:"code,omitempty"` // Only returned when the invitation is created
+}
+
+// Invitation DTOs
+type CreateInvitationRequest struct {
+	Email          string `json:"email" validate:"omitempty,email"`
+	RoleID         int    `json:"role_id" validate:"required,min=1"`
+	OrganizationID *int   `json:"organization_id" validate:"omitempty,min=1"`
+	ExpiresInHours int    `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
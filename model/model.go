@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // User represents a user in the system
 type User struct {
@@ -12,6 +15,16 @@ type User struct {
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 	Roles        []Role    `json:"roles,omitempty"`
+
+	AvatarURL           string     `json:"avatar_url,omitempty" db:"avatar_url"`
+	PendingEmail        string     `json:"pending_email,omitempty"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" db:"deletion_scheduled_at"`
+}
+
+// AvatarResponse lists the URLs of an uploaded avatar, keyed by pixel size
+type AvatarResponse struct {
+	AvatarURL string            `json:"avatar_url"`
+	Sizes     map[string]string `json:"sizes"`
 }
 
 // Role represents a role in the system
@@ -51,13 +64,127 @@ type Todo struct {
 	Completed bool      `json:"completed" db:"completed"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	DueAt       *time.Time `json:"due_at" db:"due_at"`
+	RemindAt    *time.Time `json:"remind_at" db:"remind_at"`
+	RemindedAt  *time.Time `json:"reminded_at,omitempty" db:"reminded_at"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+	Overdue     bool       `json:"overdue"`
+
+	SeriesID     *int       `json:"series_id,omitempty" db:"series_id"`
+	OccurrenceAt *time.Time `json:"occurrence_at,omitempty" db:"occurrence_at"`
+
+	ProjectID *int  `json:"project_id" db:"project_id"`
+	Tags      []Tag `json:"tags"`
+
+	ParentID     *int            `json:"parent_id" db:"parent_id"`
+	AutoComplete bool            `json:"auto_complete" db:"auto_complete"`
+	Progress     *Progress       `json:"progress,omitempty"`
+	Checklist    []ChecklistItem `json:"checklist,omitempty"`
+	Children     []*Todo         `json:"children,omitempty"`
+
+	// Status is a key of the todo's workflow and is kept consistent with Completed;
+	// BoardPosition orders the todo within its board column
+	Status        string `json:"status" db:"status"`
+	BoardPosition int    `json:"board_position" db:"board_position"`
+
+	// Position is a fractional ranking key ordering the user's todos manually
+	Position string `json:"position" db:"position"`
+
+	Priority Priority `json:"priority" db:"priority"`
+	// Score is set when todos are ranked by GET /todos/next
+	Score *float64 `json:"score,omitempty"`
+
+	Assignees    []Assignee `json:"assignees"`
+	CommentCount int        `json:"comment_count"`
+
+	// BlockedBy lists the todos this one depends on; Blocked is set while any of them is open
+	BlockedBy []int `json:"blocked_by"`
+	Blocked   bool  `json:"blocked"`
+
+	// ArchivedAt is set while the todo is archived
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+
+	// DeletedAt is set while the todo is in the trash, which is purged at PurgeAt
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"`
+}
+
+// In converts the todo's timestamps to loc for presentation
+func (t *Todo) In(loc *time.Location) {
+	t.CreatedAt = t.CreatedAt.In(loc)
+	t.UpdatedAt = t.UpdatedAt.In(loc)
+	for _, ts := range []*time.Time{t.DueAt, t.RemindAt, t.RemindedAt, t.CompletedAt, t.OccurrenceAt, t.ArchivedAt, t.DeletedAt, t.PurgeAt} {
+		if ts != nil {
+			*ts = ts.In(loc)
+		}
+	}
+}
+
+// IsOverdue reports whether an open todo is past its due date
+func (t *Todo) IsOverdue(now time.Time) bool {
+	return !t.Completed && t.DueAt != nil && t.DueAt.Before(now)
+}
+
+// CheckArchive reports why a todo cannot be archived; only completed top-level todos outside
+// the archive can be, and their subtasks are archived along with them
+func (t *Todo) CheckArchive() error {
+	switch {
+	case t.ArchivedAt != nil:
+		return errors.New("todo is already archived")
+	case t.ParentID != nil:
+		return errors.New("subtasks are archived along with their top-level todo")
+	case !t.Completed:
+		return errors.New("only completed todos can be archived")
+	}
+	return nil
+}
+
+// SchedulePurge sets when a trashed todo is purged, retentionDays after it was deleted. Without
+// a retention period trashed todos are kept, and PurgeAt stays empty.
+func (t *Todo) SchedulePurge(retentionDays int) {
+	t.PurgeAt = nil
+	if t.DeletedAt == nil || retentionDays <= 0 {
+		return
+	}
+	purgeAt := t.DeletedAt.AddDate(0, 0, retentionDays)
+	t.PurgeAt = &purgeAt
+}
+
+// TodoLinks are the tags, checklist items and assignees saved in the same transaction as
+// their todo. Tags and assignees are only replaced when their list is set.
+type TodoLinks struct {
+	TagIDs      *[]int
+	Checklist   []string // Appended to the todo's checklist
+	AssigneeIDs *[]int
+	AssignedBy  int
+
+	// Assigned and Unassigned are filled in on save with the users whose assignment changed
+	Assigned   []int
+	Unassigned []int
+}
+
+// TodoWrite is a planned change to a todo, saved in one transaction along with the others
+// planned with it. A todo without an ID is created at the end of its board column and of its
+// owner's manual order; Delete moves the todo and its subtasks to the trash; any other todo is
+// updated, and moved to the end of its board column when Reposition is set.
+type TodoWrite struct {
+	Todo       *Todo
+	Links      *TodoLinks
+	Delete     bool
+	Reposition bool
+
+	// Series is started first when set, and the todo becomes its occurrence at FirstAt
+	Series  *TodoSeries
+	FirstAt time.Time
 }
 
 // Request/Response DTOs
 type RegisterRequest struct {
-	Username string `json:"username" validate:"required,min=3,max=50"`
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Username   string `json:"username" validate:"required,min=3,max=50"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required,min=6"`
+	InviteCode string `json:"invite_code,omitempty"`
 }
 
 type LoginRequest struct {
@@ -72,30 +199,122 @@ type AuthResponse struct {
 	ExpiresIn    int64  `json:"expires_in"`
 }
 
+type ImpersonationResponse struct {
+	User           User   `json:"user"`
+	AccessToken    string `json:"access_token"`
+	ExpiresIn      int64  `json:"expires_in"`
+	ImpersonatorID int    `json:"impersonator_id"`
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 // Todo DTOs
 type CreateTodoRequest struct {
-	Title   string `json:"title" validate:"required,min=1,max=200"`
-	Content string `json:"content" validate:"max=1000"`
+	Title    string     `json:"title" validate:"required,min=1,max=200"`
+	Content  string     `json:"content" validate:"max=1000"`
+	DueAt    *time.Time `json:"due_at,omitempty"`
+	RemindAt *time.Time `json:"remind_at,omitempty"`
+
+	// Recurrence makes the todo the first occurrence of a repeating series; requires DueAt
+	Recurrence *RecurrenceRequest `json:"recurrence,omitempty"`
+
+	TagIDs    []int `json:"tag_ids,omitempty" validate:"max=50"`
+	ProjectID *int  `json:"project_id,omitempty"`
+
+	// ParentID makes the todo a subtask; AutoComplete completes it once all of its own subtasks are done
+	ParentID     *int     `json:"parent_id,omitempty"`
+	AutoComplete bool     `json:"auto_complete,omitempty"`
+	Checklist    []string `json:"checklist,omitempty" validate:"max=100,dive,min=1,max=500"`
+
+	// Status defaults to the first open status of the todo's workflow
+	Status string `json:"status,omitempty" validate:"omitempty,max=50"`
+
+	Priority string `json:"priority,omitempty" validate:"omitempty,oneof=none low medium high urgent"`
+
+	// AssigneeIDs are users made responsible for the todo alongside its owner
+	AssigneeIDs []int `json:"assignee_ids,omitempty" validate:"max=20"`
 }
 
 type UpdateTodoRequest struct {
-	Title     *string `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
-	Content   *string `json:"content,omitempty" validate:"omitempty,max=1000"`
-	Completed *bool   `json:"completed,omitempty"`
+	Title     *string      `json:"title,omitempty" validate:"omitempty,min=1,max=200"`
+	Content   *string      `json:"content,omitempty" validate:"omitempty,max=1000"`
+	Completed *bool        `json:"completed,omitempty"`
+	DueAt     OptionalTime `json:"due_at"`
+	RemindAt  OptionalTime `json:"remind_at"`
+
+	// Recurrence changes the rule of a series (scope "series") or makes a todo recurring
+	Recurrence *RecurrenceRequest `json:"recurrence,omitempty"`
+
+	// TagIDs replaces the todo's tags when present; an empty list removes them all
+	TagIDs *[]int `json:"tag_ids,omitempty" validate:"omitempty,max=50"`
+
+	// ProjectID moves the todo to a project; null removes it from its project
+	ProjectID OptionalID `json:"project_id"`
+
+	// ParentID moves the todo under another todo; null makes it a top-level todo
+	ParentID     OptionalID `json:"parent_id"`
+	AutoComplete *bool      `json:"auto_complete,omitempty"`
+
+	// Status moves the todo along its workflow; moving to a done status completes it
+	Status *string `json:"status,omitempty" validate:"omitempty,min=1,max=50"`
+
+	Priority *string `json:"priority,omitempty" validate:"omitempty,oneof=none low medium high urgent"`
+
+	// AssigneeIDs replaces the todo's assignees when present; an empty list removes them all
+	AssigneeIDs *[]int `json:"assignee_ids,omitempty" validate:"omitempty,max=20"`
+}
+
+// MoveTodoRequest places a todo right before or right after another todo in the user's manual
+// order; with both anchors the todo goes between them
+type MoveTodoRequest struct {
+	BeforeID *int `json:"before_id,omitempty" validate:"omitempty,min=1"`
+	AfterID  *int `json:"after_id,omitempty" validate:"omitempty,min=1"`
 }
 
 // Pagination
 type PaginationRequest struct {
 	Page     int    `json:"page" validate:"min=1"`
 	Limit    int    `json:"limit" validate:"min=1,max=100"`
-	Sort     string `json:"sort" validate:"omitempty,oneof=id title created_at updated_at"`
+	Sort     string `json:"sort" validate:"omitempty,oneof=id title created_at updated_at due_at position priority"`
 	Order    string `json:"order" validate:"omitempty,oneof=asc desc"`
 	Search   string `json:"search" validate:"omitempty,max=100"`
 	Filter   string `json:"filter" validate:"omitempty,oneof=completed pending all"`
+
+	// Due date filters: DueAfter is inclusive, DueBefore exclusive. DueToday is
+	// narrowed to the requesting user's local day by the service.
+	DueBefore *time.Time `json:"due_before,omitempty"`
+	DueAfter  *time.Time `json:"due_after,omitempty"`
+	Overdue   bool       `json:"overdue,omitempty"`
+	DueToday  bool       `json:"due_today,omitempty"`
+
+	// Tag filters: TagMode "all" (default) requires every tag, "any" at least one
+	TagIDs  []int  `json:"tag_ids,omitempty" validate:"max=20"`
+	TagMode string `json:"tag_mode,omitempty" validate:"omitempty,oneof=all any"`
+
+	// Project filters: ProjectID limits the listing to one project, NoProject to todos outside any project
+	ProjectID *int `json:"project_id,omitempty"`
+	NoProject bool `json:"no_project,omitempty"`
+
+	// Hierarchy filters: ParentID lists the subtasks of one todo, TopLevel only todos without a parent
+	ParentID *int `json:"parent_id,omitempty"`
+	TopLevel bool `json:"top_level,omitempty"`
+
+	Status string `json:"status,omitempty" validate:"omitempty,max=50"`
+
+	// Priorities limits the listing to todos with any of the given priorities
+	Priorities []Priority `json:"priorities,omitempty" validate:"max=5"`
+
+	// Involvement filters: listings cover todos the user owns or is assigned to; AssignedToMe
+	// keeps the assigned ones, CreatedByMe the owned ones
+	AssignedToMe bool `json:"assigned_to_me,omitempty"`
+	CreatedByMe  bool `json:"created_by_me,omitempty"`
+
+	// Archive filters: archived todos are left out unless IncludeArchived; ArchivedOnly lists
+	// nothing but them
+	IncludeArchived bool `json:"include_archived,omitempty"`
+	ArchivedOnly    bool `json:"archived_only,omitempty"`
 }
 
 type PaginatedResponse struct {
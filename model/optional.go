@@ -0,0 +1,51 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OptionalTime distinguishes an omitted JSON field (Set is false) from an explicit
+// null (Set is true, Time is nil) in partial updates
+type OptionalTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+// UnmarshalJSON is only called when the field is present
+func (o *OptionalTime) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Time = nil
+		return nil
+	}
+
+	var t time.Time
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	o.Time = &t
+	return nil
+}
+
+// OptionalID is the OptionalTime counterpart for nullable references such as a todo's project
+type OptionalID struct {
+	Set bool
+	ID  *int
+}
+
+// UnmarshalJSON is only called when the field is present
+func (o *OptionalID) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.ID = nil
+		return nil
+	}
+
+	var id int
+	if err := json.Unmarshal(data, &id); err != nil {
+		return err
+	}
+	o.ID = &id
+	return nil
+}
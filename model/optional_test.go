@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionalTime(t *testing.T) {
+	var req UpdateTodoRequest
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"title": "x"}`), &req))
+	assert.False(t, req.DueAt.Set)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"due_at": null}`), &req))
+	assert.True(t, req.DueAt.Set)
+	assert.Nil(t, req.DueAt.Time)
+
+	req = UpdateTodoRequest{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"due_at": "2025-10-06T09:00:00+06:00"}`), &req))
+	assert.True(t, req.DueAt.Set)
+	assert.Equal(t, "2025-10-06T03:00:00Z", req.DueAt.Time.UTC().Format("2006-01-02T15:04:05Z07:00"))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"due_at": "tomorrow"}`), &req))
+}
+
+func TestOptionalID(t *testing.T) {
+	var req UpdateTodoRequest
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"title": "x"}`), &req))
+	assert.False(t, req.ProjectID.Set)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"project_id": null}`), &req))
+	assert.True(t, req.ProjectID.Set)
+	assert.Nil(t, req.ProjectID.ID)
+
+	req = UpdateTodoRequest{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"project_id": 4}`), &req))
+	assert.True(t, req.ProjectID.Set)
+	assert.Equal(t, 4, *req.ProjectID.ID)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"project_id": "inbox"}`), &req))
+}
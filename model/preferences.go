@@ -0,0 +1,119 @@
+package model
+
+import "time"
+
+// Date formats clients may render dates with
+const (
+	DateFormatISO = "YYYY-MM-DD"
+	DateFormatEU  = "DD/MM/YYYY"
+	DateFormatUS  = "MM/DD/YYYY"
+)
+
+// Notification kinds users can opt out of
+const (
+	NotificationReminder   = "reminder"
+	NotificationAssignment = "assignment"
+	NotificationMention    = "mention"
+)
+
+// UserPreferences holds per-user display, listing and notification preferences
+type UserPreferences struct {
+	UserID                  int       `json:"user_id" db:"user_id"`
+	DisplayName             string    `json:"display_name" db:"display_name"`
+	Timezone                string    `json:"timezone" db:"timezone"`
+	Locale                  string    `json:"locale" db:"locale"`
+	DateFormat              string    `json:"date_format" db:"date_format"`
+	DefaultSort             string    `json:"default_sort" db:"default_sort"`
+	DefaultOrder            string    `json:"default_order" db:"default_order"`
+	DefaultFilter           string    `json:"default_filter" db:"default_filter"`
+	DefaultLimit            int       `json:"default_limit" db:"default_limit"`
+	EmailNotifications      bool      `json:"email_notifications" db:"email_notifications"`
+	ReminderNotifications   bool      `json:"reminder_notifications" db:"reminder_notifications"`
+	AssignmentNotifications bool      `json:"assignment_notifications" db:"assignment_notifications"`
+	MentionNotifications    bool      `json:"mention_notifications" db:"mention_notifications"`
+	AutoArchiveDays         int       `json:"auto_archive_days" db:"auto_archive_days"`       // 0 turns auto-archiving off
+	EnforceDependencies     bool      `json:"enforce_dependencies" db:"enforce_dependencies"` // Refuse completing blocked todos
+	UpdatedAt               time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AutoArchives reports whether a todo is due for auto-archiving at now: auto-archiving is on
+// and the todo is a completed top-level todo, outside the archive and the trash, completed
+// more than AutoArchiveDays ago
+func (p *UserPreferences) AutoArchives(todo *Todo, now time.Time) bool {
+	if p.AutoArchiveDays <= 0 || todo.CheckArchive() != nil || todo.DeletedAt != nil {
+		return false
+	}
+	completedAt := todo.UpdatedAt
+	if todo.CompletedAt != nil {
+		completedAt = *todo.CompletedAt
+	}
+	return completedAt.Before(now.UTC().AddDate(0, 0, -p.AutoArchiveDays))
+}
+
+// DefaultUserPreferences returns the preferences of a user who has never changed them
+func DefaultUserPreferences(userID int) UserPreferences {
+	return UserPreferences{
+		UserID:                  userID,
+		Timezone:                "UTC",
+		Locale:                  "en-US",
+		DateFormat:              DateFormatISO,
+		DefaultSort:             "created_at",
+		DefaultOrder:            "desc",
+		DefaultFilter:           "all",
+		DefaultLimit:            10,
+		EmailNotifications:      true,
+		ReminderNotifications:   true,
+		AssignmentNotifications: true,
+		MentionNotifications:    true,
+	}
+}
+
+// WantsNotification reports whether the user accepts emailed notifications of a kind
+func (p *UserPreferences) WantsNotification(kind string) bool {
+	if !p.EmailNotifications {
+		return false
+	}
+	switch kind {
+	case NotificationReminder:
+		return p.ReminderNotifications
+	case NotificationAssignment:
+		return p.AssignmentNotifications
+	case NotificationMention:
+		return p.MentionNotifications
+	default:
+		return true
+	}
+}
+
+// Location returns the preferred timezone, falling back to UTC
+func (p *UserPreferences) Location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Preference DTOs
+type UpdatePreferencesRequest struct {
+	DisplayName             *string `json:"display_name,omitempty" validate:"omitempty,max=100"`
+	Timezone                *string `json:"timezone,omitempty" validate:"omitempty,timezone"`
+	Locale                  *string `json:"locale,omitempty" validate:"omitempty,bcp47_language_tag"`
+	DateFormat              *string `json:"date_format,omitempty" validate:"omitempty,oneof=YYYY-MM-DD DD/MM/YYYY MM/DD/YYYY"`
+	DefaultSort             *string `json:"default_sort,omitempty" validate:"omitempty,oneof=id title created_at updated_at due_at position priority"`
+	DefaultOrder            *string `json:"default_order,omitempty" validate:"omitempty,oneof=asc desc"`
+	DefaultFilter           *string `json:"default_filter,omitempty" validate:"omitempty,oneof=completed pending all"`
+	DefaultLimit            *int    `json:"default_limit,omitempty" validate:"omitempty,min=1,max=100"`
+	EmailNotifications      *bool   `json:"email_notifications,omitempty"`
+	ReminderNotifications   *bool   `json:"reminder_notifications,omitempty"`
+	AssignmentNotifications *bool   `json:"assignment_notifications,omitempty"`
+	MentionNotifications    *bool   `json:"mention_notifications,omitempty"`
+	AutoArchiveDays         *int    `json:"auto_archive_days,omitempty" validate:"omitempty,min=0,max=3650"`
+	EnforceDependencies     *bool   `json:"enforce_dependencies,omitempty"`
+}
+
+type UpdateProfileRequest struct {
+	Username        string `json:"username" validate:"omitempty,min=3,max=50"`
+	Email           string `json:"email" validate:"omitempty,email"`
+	CurrentPassword string `json:"current_password"`
+}
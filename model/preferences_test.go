@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserPreferencesLocation(t *testing.T) {
+	prefs := DefaultUserPreferences(1)
+	assert.Equal(t, time.UTC, prefs.Location())
+
+	prefs.Timezone = "Asia/Dhaka"
+	assert.Equal(t, "Asia/Dhaka", prefs.Location().String())
+
+	// Unknown zones fall back to UTC
+	prefs.Timezone = "Mars/Olympus"
+	assert.Equal(t, time.UTC, prefs.Location())
+}
+
+func TestUpdatePreferencesRequestValidation(t *testing.T) {
+	validate := validator.New()
+	timezone := "Europe/Berlin"
+	locale := "de-DE"
+	limit := 25
+
+	assert.NoError(t, validate.Struct(UpdatePreferencesRequest{Timezone: &timezone, Locale: &locale, DefaultLimit: &limit}))
+
+	badTimezone := "Nowhere/City"
+	assert.Error(t, validate.Struct(UpdatePreferencesRequest{Timezone: &badTimezone}))
+
+	badSort := "password"
+	assert.Error(t, validate.Struct(UpdatePreferencesRequest{DefaultSort: &badSort}))
+
+	badLimit := 500
+	assert.Error(t, validate.Struct(UpdatePreferencesRequest{DefaultLimit: &badLimit}))
+}
+
+func TestUserPreferencesWantsNotification(t *testing.T) {
+	prefs := DefaultUserPreferences(1)
+	assert.True(t, prefs.WantsNotification(NotificationReminder))
+
+	prefs.ReminderNotifications = false
+	assert.False(t, prefs.WantsNotification(NotificationReminder))
+	assert.True(t, prefs.WantsNotification(NotificationMention))
+
+	// Email notifications are a master switch
+	prefs.EmailNotifications = false
+	assert.False(t, prefs.WantsNotification(NotificationMention))
+}
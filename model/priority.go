@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Priority ranks how important a todo is; it is stored as its level and shown by name
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+// priorityNames are indexed by level
+var priorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
+// ParsePriority converts a priority name into a Priority
+func ParsePriority(name string) (Priority, error) {
+	for level, candidate := range priorityNames {
+		if candidate == name {
+			return Priority(level), nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("invalid priority %q: use one of %s", name, strings.Join(priorityNames, ", "))
+}
+
+func (p Priority) String() string {
+	if p < PriorityNone || p > PriorityUrgent {
+		return priorityNames[PriorityNone]
+	}
+	return priorityNames[p]
+}
+
+func (p Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// NextWeights weigh the factors that rank pending todos for GET /todos/next. Each factor is
+// scaled to between 0 and 1 before it is weighed.
+type NextWeights struct {
+	Priority float64 // Higher priorities first
+	Due      float64 // Overdue and soon due todos first
+	Age      float64 // Older todos first, up to a month old
+	Position float64 // Todos higher in the manual order first
+}
+
+// Score ranks a pending todo that is at index of count todos in the user's manual order
+func (w NextWeights) Score(todo *Todo, index, count int, now time.Time) float64 {
+	score := w.Priority * float64(todo.Priority) / float64(PriorityUrgent)
+
+	if todo.DueAt != nil {
+		until := todo.DueAt.Sub(now)
+		if until <= 0 {
+			score += w.Due
+		} else {
+			score += w.Due / (1 + until.Hours()/24)
+		}
+	}
+
+	if age := now.Sub(todo.CreatedAt).Hours() / 24 / 30; age >= 1 {
+		score += w.Age
+	} else if age > 0 {
+		score += w.Age * age
+	}
+
+	if count > 0 {
+		score += w.Position * (1 - float64(index)/float64(count))
+	}
+	return score
+}
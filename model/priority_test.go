@@ -0,0 +1,43 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePriority(t *testing.T) {
+	priority, err := ParsePriority("high")
+	assert.NoError(t, err)
+	assert.Equal(t, PriorityHigh, priority)
+
+	_, err = ParsePriority("critical")
+	assert.Error(t, err)
+
+	data, err := json.Marshal(struct{ P Priority }{PriorityUrgent})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"P":"urgent"}`, string(data))
+}
+
+func TestNextWeightsScore(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	w := NextWeights{Priority: 1, Due: 1, Age: 1, Position: 1}
+
+	overdue := now.Add(-time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+
+	// Each factor on its own
+	assert.InDelta(t, 0.5, NextWeights{Priority: 1}.Score(&Todo{Priority: PriorityMedium, CreatedAt: now}, 0, 1, now), 1e-9)
+	assert.InDelta(t, 1, NextWeights{Due: 1}.Score(&Todo{DueAt: &overdue, CreatedAt: now}, 0, 1, now), 1e-9)
+	assert.InDelta(t, 0.5, NextWeights{Due: 1}.Score(&Todo{DueAt: &tomorrow, CreatedAt: now}, 0, 1, now), 1e-9)
+	assert.InDelta(t, 0.5, NextWeights{Age: 1}.Score(&Todo{CreatedAt: now.AddDate(0, 0, -15)}, 0, 1, now), 1e-9)
+	assert.InDelta(t, 1, NextWeights{Age: 1}.Score(&Todo{CreatedAt: now.AddDate(-1, 0, 0)}, 0, 1, now), 1e-9)
+	assert.InDelta(t, 0.75, NextWeights{Position: 1}.Score(&Todo{CreatedAt: now}, 1, 4, now), 1e-9)
+
+	// An urgent overdue todo outranks a plain one at the top of the manual order
+	urgent := w.Score(&Todo{Priority: PriorityUrgent, DueAt: &overdue, CreatedAt: now}, 3, 4, now)
+	plain := w.Score(&Todo{CreatedAt: now}, 0, 4, now)
+	assert.Greater(t, urgent, plain)
+}
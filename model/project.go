@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Project groups a user's todos into a named list
+type Project struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Color       string    `json:"color" db:"color"`
+	Archived    bool      `json:"archived" db:"archived"`
+	Position    int       `json:"position" db:"position"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+
+	PendingCount   int `json:"pending_count"`
+	CompletedCount int `json:"completed_count"`
+}
+
+// DefaultProjectColor is used when a project is created without a color
+const DefaultProjectColor = "#3b82f6"
+
+// Project DTOs
+type CreateProjectRequest struct {
+	Name        string `json:"name" validate:"required,min=1,max=100"`
+	Description string `json:"description" validate:"max=1000"`
+	Color       string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+	Position    *int   `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
+type UpdateProjectRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Color       *string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+	Archived    *bool   `json:"archived,omitempty"`
+	Position    *int    `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
+// MoveTodosRequest moves todos into a project, or out of any project when ProjectID is null
+type MoveTodosRequest struct {
+	TodoIDs   []int `json:"todo_ids" validate:"required,min=1,max=100"`
+	ProjectID *int  `json:"project_id"`
+}
@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+// Edit scopes for changes to an occurrence of a recurring todo
+const (
+	EditScopeThis   = "this"
+	EditScopeSeries = "series"
+)
+
+// TodoSeries is the template the occurrences of a recurring todo are generated from.
+// Each occurrence is an ordinary todo linked by SeriesID; the next one is created when
+// the current one is completed.
+type TodoSeries struct {
+	ID       int       `json:"id" db:"id"`
+	UserID   int       `json:"user_id" db:"user_id"`
+	Rule     string    `json:"rule" db:"rule"`
+	StartsAt time.Time `json:"starts_at" db:"starts_at"`
+	Timezone string    `json:"timezone" db:"timezone"`
+
+	// Exceptions are skipped dates (YYYY-MM-DD) in the series timezone
+	Exceptions []string `json:"exceptions" db:"exceptions"`
+
+	Title   string `json:"title" db:"title"`
+	Content string `json:"content" db:"content"`
+
+	// RemindOffset is how many seconds before its due time each occurrence reminds
+	RemindOffset *int `json:"remind_offset_seconds,omitempty" db:"remind_offset_seconds"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Location returns the timezone occurrences are expanded in, falling back to UTC
+func (s *TodoSeries) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// HasException reports whether the local date of t is skipped
+func (s *TodoSeries) HasException(t time.Time) bool {
+	date := t.In(s.Location()).Format("2006-01-02")
+	for _, exception := range s.Exceptions {
+		if exception == date {
+			return true
+		}
+	}
+	return false
+}
+
+// Occurrence builds the todo for the occurrence of the series at t
+func (s *TodoSeries) Occurrence(t time.Time) *Todo {
+	due := t.UTC()
+	seriesID := s.ID
+	todo := &Todo{
+		UserID:       s.UserID,
+		Title:        s.Title,
+		Content:      s.Content,
+		DueAt:        &due,
+		SeriesID:     &seriesID,
+		OccurrenceAt: &due,
+	}
+	if s.RemindOffset != nil {
+		remind := due.Add(-time.Duration(*s.RemindOffset) * time.Second)
+		todo.RemindAt = &remind
+	}
+	return todo
+}
+
+// Schedule moves a todo to the occurrence of the series at t
+func (s *TodoSeries) Schedule(todo *Todo, t time.Time) {
+	occurrence := s.Occurrence(t)
+	todo.SeriesID = occurrence.SeriesID
+	todo.OccurrenceAt = occurrence.OccurrenceAt
+	todo.DueAt = occurrence.DueAt
+	todo.RemindAt = occurrence.RemindAt
+	todo.RemindedAt = nil
+}
+
+// Recurrence DTOs
+type RecurrenceRequest struct {
+	Rule       string   `json:"rule" validate:"required,max=255"`
+	Exceptions []string `json:"exceptions,omitempty" validate:"max=500,dive,datetime=2006-01-02"`
+}
+
+type RecurrencePreviewRequest struct {
+	Rule       string     `json:"rule" validate:"required,max=255"`
+	Exceptions []string   `json:"exceptions,omitempty" validate:"max=500,dive,datetime=2006-01-02"`
+	Start      *time.Time `json:"start,omitempty"`
+	Limit      int        `json:"limit,omitempty" validate:"omitempty,min=1,max=100"`
+}
+
+type OccurrencesResponse struct {
+	Series      *TodoSeries `json:"series,omitempty"`
+	Occurrences []time.Time `json:"occurrences"`
+}
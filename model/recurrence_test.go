@@ -0,0 +1,35 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoSeriesOccurrence(t *testing.T) {
+	offset := 3600
+	series := &TodoSeries{ID: 7, UserID: 3, Title: "Take out bins", Content: "Both of them", RemindOffset: &offset}
+
+	at := time.Date(2025, 6, 2, 9, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	todo := series.Occurrence(at)
+
+	assert.Equal(t, 3, todo.UserID)
+	assert.Equal(t, "Take out bins", todo.Title)
+	assert.Equal(t, 7, *todo.SeriesID)
+	assert.Equal(t, time.UTC, todo.DueAt.Location())
+	assert.True(t, todo.DueAt.Equal(at))
+	assert.True(t, todo.OccurrenceAt.Equal(at))
+	assert.True(t, todo.RemindAt.Equal(at.Add(-time.Hour)))
+
+	series.ID = 8
+	assert.Equal(t, 7, *todo.SeriesID)
+}
+
+func TestTodoSeriesHasException(t *testing.T) {
+	series := &TodoSeries{Timezone: "America/New_York", Exceptions: []string{"2025-06-01"}}
+
+	// 02:00 UTC on June 2nd is still June 1st in New York
+	assert.True(t, series.HasException(time.Date(2025, 6, 2, 2, 0, 0, 0, time.UTC)))
+	assert.False(t, series.HasException(time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)))
+}
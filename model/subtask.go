@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// ChecklistItem is a lightweight step embedded in a todo
+type ChecklistItem struct {
+	ID        int       `json:"id" db:"id"`
+	TodoID    int       `json:"todo_id" db:"todo_id"`
+	Content   string    `json:"content" db:"content"`
+	Checked   bool      `json:"checked" db:"checked"`
+	Position  int       `json:"position" db:"position"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Progress counts the finished steps of a todo: its direct subtasks and checklist items
+type Progress struct {
+	Done    int `json:"done"`
+	Total   int `json:"total"`
+	Percent int `json:"percent"`
+}
+
+// Add counts done of total further steps
+func (p *Progress) Add(done, total int) {
+	p.Done += done
+	p.Total += total
+	if p.Total > 0 {
+		p.Percent = p.Done * 100 / p.Total
+	}
+}
+
+// Checklist DTOs
+type CreateChecklistItemRequest struct {
+	Content  string `json:"content" validate:"required,min=1,max=500"`
+	Position *int   `json:"position,omitempty" validate:"omitempty,min=0"`
+}
+
+type UpdateChecklistItemRequest struct {
+	Content  *string `json:"content,omitempty" validate:"omitempty,min=1,max=500"`
+	Checked  *bool   `json:"checked,omitempty"`
+	Position *int    `json:"position,omitempty" validate:"omitempty,min=0"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressAdd(t *testing.T) {
+	var p Progress
+	assert.Equal(t, 0, p.Percent)
+
+	p.Add(2, 3) // subtasks
+	p.Add(1, 2) // checklist items
+	assert.Equal(t, Progress{Done: 3, Total: 5, Percent: 60}, p)
+
+	p.Add(0, 1)
+	assert.Equal(t, 50, p.Percent)
+}
+
+func TestCreateTodoChecklistValidation(t *testing.T) {
+	v := validator.New()
+
+	assert.NoError(t, v.Struct(CreateTodoRequest{Title: "Trip", Checklist: []string{"Passport", "Tickets"}}))
+	assert.Error(t, v.Struct(CreateTodoRequest{Title: "Trip", Checklist: []string{""}}))
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Tag tag matching modes for todo listings
+const (
+	TagModeAll = "all"
+	TagModeAny = "any"
+)
+
+// DefaultTagColor is used when a tag is created without a color
+const DefaultTagColor = "#6b7280"
+
+// Tag is a user-defined label that can be attached to any number of todos
+type Tag struct {
+	ID         int       `json:"id" db:"id"`
+	UserID     int       `json:"user_id" db:"user_id"`
+	Name       string    `json:"name" db:"name"`
+	Color      string    `json:"color" db:"color"`
+	UsageCount int       `json:"usage_count"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Tag DTOs
+type CreateTagRequest struct {
+	Name  string `json:"name" validate:"required,min=1,max=50"`
+	Color string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+}
+
+type UpdateTagRequest struct {
+	Name  *string `json:"name,omitempty" validate:"omitempty,min=1,max=50"`
+	Color *string `json:"color,omitempty" validate:"omitempty,hexcolor"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagRequestValidation(t *testing.T) {
+	validate := validator.New()
+
+	assert.NoError(t, validate.Struct(CreateTagRequest{Name: "chores", Color: "#ff8800"}))
+	assert.NoError(t, validate.Struct(CreateTagRequest{Name: "chores"}))
+	assert.Error(t, validate.Struct(CreateTagRequest{Name: ""}))
+	assert.Error(t, validate.Struct(CreateTagRequest{Name: "chores", Color: "orange"}))
+
+	color := "red"
+	assert.Error(t, validate.Struct(UpdateTagRequest{Color: &color}))
+	assert.NoError(t, validate.Struct(UpdateTagRequest{}))
+}
+
+func TestPaginationTagMode(t *testing.T) {
+	validate := validator.New()
+	req := PaginationRequest{Page: 1, Limit: 10, TagIDs: []int{1, 2}, TagMode: TagModeAny}
+	assert.NoError(t, validate.Struct(req))
+
+	req.TagMode = "some"
+	assert.Error(t, validate.Struct(req))
+}
@@ -0,0 +1,262 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Template describes a set of todos that can be created again and again. Templates belong to
+// their creator and, with an OrganizationID, are shared with the organization's members.
+// ProjectName, when set, names a new project created for each instance.
+type Template struct {
+	ID             int            `json:"id" db:"id"`
+	UserID         int            `json:"user_id" db:"user_id"`
+	OrganizationID *int           `json:"organization_id" db:"organization_id"`
+	Name           string         `json:"name" db:"name"`
+	Description    string         `json:"description" db:"description"`
+	ProjectName    string         `json:"project_name" db:"project_name"`
+	Items          []TemplateItem `json:"items" db:"items"`
+	Variables      []string       `json:"variables"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// TemplateItem is one todo of a template with its subtasks. DueOffset places the due date
+// relative to when the template is instantiated, e.g. "3d", "2w" or "36h". Tags are matched
+// by name among the instantiating user's tags and created when missing.
+type TemplateItem struct {
+	Title     string         `json:"title" validate:"required,min=1,max=200"`
+	Content   string         `json:"content,omitempty" validate:"max=1000"`
+	DueOffset string         `json:"due_offset,omitempty" validate:"omitempty,max=20"`
+	Priority  string         `json:"priority,omitempty" validate:"omitempty,oneof=none low medium high urgent"`
+	Tags      []string       `json:"tags,omitempty" validate:"max=20,dive,min=1,max=50"`
+	Subtasks  []TemplateItem `json:"subtasks,omitempty" validate:"max=100,dive"`
+}
+
+// templateVariable matches a {{name}} placeholder
+var templateVariable = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// CountItems returns the number of todos the template creates, subtasks included
+func CountItems(items []TemplateItem) int {
+	count := len(items)
+	for _, item := range items {
+		count += CountItems(item.Subtasks)
+	}
+	return count
+}
+
+// ItemDepth returns how many levels of subtasks the deepest item has below the top level
+func ItemDepth(items []TemplateItem) int {
+	depth := 0
+	for _, item := range items {
+		if len(item.Subtasks) > 0 {
+			if d := ItemDepth(item.Subtasks) + 1; d > depth {
+				depth = d
+			}
+		}
+	}
+	return depth
+}
+
+// FindVariables lists the variable names used by a template, sorted
+func (t *Template) FindVariables() []string {
+	seen := map[string]bool{}
+	collect := func(text string) {
+		for _, match := range templateVariable.FindAllStringSubmatch(text, -1) {
+			seen[match[1]] = true
+		}
+	}
+
+	collect(t.ProjectName)
+	var walk func(items []TemplateItem)
+	walk = func(items []TemplateItem) {
+		for _, item := range items {
+			collect(item.Title)
+			collect(item.Content)
+			for _, tag := range item.Tags {
+				collect(tag)
+			}
+			walk(item.Subtasks)
+		}
+	}
+	walk(t.Items)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Substitute replaces the {{name}} placeholders of text with their values
+func Substitute(text string, values map[string]string) (string, error) {
+	var missing string
+	result := templateVariable.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := templateVariable.FindStringSubmatch(placeholder)[1]
+		value, ok := values[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("missing value for variable %q", missing)
+	}
+	return result, nil
+}
+
+// ParseOffset parses a due offset: a number of days ("3d") or weeks ("2w"), or a duration
+// such as "36h" or "90m"
+func ParseOffset(offset string) (time.Duration, error) {
+	if offset == "" {
+		return 0, nil
+	}
+
+	unit := offset[len(offset)-1]
+	if unit == 'd' || unit == 'w' {
+		n, err := strconv.Atoi(offset[:len(offset)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid due offset %q", offset)
+		}
+		days := time.Duration(n) * 24 * time.Hour
+		if unit == 'w' {
+			days *= 7
+		}
+		return days, nil
+	}
+
+	d, err := time.ParseDuration(offset)
+	if err != nil {
+		return 0, fmt.Errorf("invalid due offset %q", offset)
+	}
+	return d, nil
+}
+
+// FormatOffset formats a due offset in the largest whole unit of days, hours or minutes
+func FormatOffset(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "0d"
+	case d%(24*time.Hour) == 0:
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	case d%time.Hour == 0:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	default:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	}
+}
+
+// TemplateItemsFromTodos builds template items from todos with their tags loaded, nesting
+// subtasks under their parents. Due dates become offsets from start, never negative.
+func TemplateItemsFromTodos(todos []*Todo, start time.Time) []TemplateItem {
+	included := make(map[int]bool, len(todos))
+	for _, todo := range todos {
+		included[todo.ID] = true
+	}
+	children := map[int][]*Todo{}
+	var roots []*Todo
+	for _, todo := range todos {
+		if todo.ParentID != nil && included[*todo.ParentID] {
+			children[*todo.ParentID] = append(children[*todo.ParentID], todo)
+		} else {
+			roots = append(roots, todo)
+		}
+	}
+
+	var build func(todos []*Todo) []TemplateItem
+	build = func(todos []*Todo) []TemplateItem {
+		items := make([]TemplateItem, len(todos))
+		for i, todo := range todos {
+			item := TemplateItem{Title: todo.Title, Content: todo.Content, Subtasks: build(children[todo.ID])}
+			if todo.Priority != PriorityNone {
+				item.Priority = todo.Priority.String()
+			}
+			if todo.DueAt != nil {
+				offset := todo.DueAt.Sub(start).Truncate(time.Minute)
+				if offset < 0 {
+					offset = 0
+				}
+				item.DueOffset = FormatOffset(offset)
+			}
+			for _, tag := range todo.Tags {
+				item.Tags = append(item.Tags, tag.Name)
+			}
+			items[i] = item
+		}
+		return items
+	}
+	return build(roots)
+}
+
+// TemplateInstance is what instantiating a template created: the todos, top-level ones first
+// followed by their subtasks, and the new project if the template names one
+type TemplateInstance struct {
+	Project *Project `json:"project,omitempty"`
+	Todos   []*Todo  `json:"todos"`
+}
+
+// TemplatePlan is a template instance ready to be saved. Parents holds the index of each
+// todo's parent within Todos, or -1; parents come before their subtasks. Tags holds each
+// todo's tag names.
+type TemplatePlan struct {
+	UserID  int
+	Project *Project
+	Todos   []*Todo
+	Parents []int
+	Tags    [][]string
+}
+
+// Template DTOs
+type CreateTemplateRequest struct {
+	Name           string         `json:"name" validate:"required,min=1,max=100"`
+	Description    string         `json:"description" validate:"max=1000"`
+	OrganizationID *int           `json:"organization_id,omitempty"`
+	ProjectName    string         `json:"project_name,omitempty" validate:"max=100"`
+	Items          []TemplateItem `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+type UpdateTemplateRequest struct {
+	Name        *string         `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Description *string         `json:"description,omitempty" validate:"omitempty,max=1000"`
+	ProjectName *string         `json:"project_name,omitempty" validate:"omitempty,max=100"`
+	Items       *[]TemplateItem `json:"items,omitempty" validate:"omitempty,min=1,max=100,dive"`
+}
+
+// CreateTemplateFromProjectRequest turns the todos of a project into a template; the template
+// creates a project named after the source project unless ProjectName says otherwise
+type CreateTemplateFromProjectRequest struct {
+	ProjectID      int     `json:"project_id" validate:"required,min=1"`
+	Name           string  `json:"name" validate:"required,min=1,max=100"`
+	Description    string  `json:"description" validate:"max=1000"`
+	OrganizationID *int    `json:"organization_id,omitempty"`
+	ProjectName    *string `json:"project_name,omitempty" validate:"omitempty,max=100"`
+}
+
+// InstantiateTemplateRequest creates the todos of a template. Variables fill in its
+// placeholders; ProjectID puts the todos into an existing project instead of the one the
+// template names; due offsets count from StartAt, which defaults to now.
+type InstantiateTemplateRequest struct {
+	Variables map[string]string `json:"variables,omitempty" validate:"max=50,dive,max=200"`
+	ProjectID *int              `json:"project_id,omitempty"`
+	StartAt   *time.Time        `json:"start_at,omitempty"`
+}
+
+// TrimTags trims tag names and drops empty and repeated ones
+func TrimTags(names []string) []string {
+	var tags []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		tags = append(tags, name)
+	}
+	return tags
+}
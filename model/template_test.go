@@ -0,0 +1,117 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOffset(t *testing.T) {
+	for offset, want := range map[string]time.Duration{
+		"":    0,
+		"3d":  72 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"90m": 90 * time.Minute,
+		"-1d": -24 * time.Hour,
+	} {
+		got, err := ParseOffset(offset)
+		require.NoError(t, err, offset)
+		assert.Equal(t, want, got, offset)
+	}
+
+	for _, offset := range []string{"d", "3x", "soon"} {
+		_, err := ParseOffset(offset)
+		assert.Error(t, err, offset)
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	assert.Equal(t, "0d", FormatOffset(0))
+	assert.Equal(t, "3d", FormatOffset(72*time.Hour))
+	assert.Equal(t, "36h", FormatOffset(36*time.Hour))
+	assert.Equal(t, "90m", FormatOffset(90*time.Minute))
+
+	for _, d := range []time.Duration{72 * time.Hour, 36 * time.Hour, 90 * time.Minute} {
+		parsed, err := ParseOffset(FormatOffset(d))
+		require.NoError(t, err)
+		assert.Equal(t, d, parsed)
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	values := map[string]string{"name": "Ada", "team": "Platform"}
+
+	text, err := Substitute("Welcome {{name}} to {{ team }}", values)
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome Ada to Platform", text)
+
+	text, err = Substitute("No placeholders", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "No placeholders", text)
+
+	_, err = Substitute("Hello {{manager}}", values)
+	assert.EqualError(t, err, `missing value for variable "manager"`)
+}
+
+func TestTemplateFindVariables(t *testing.T) {
+	template := Template{
+		ProjectName: "Onboarding {{name}}",
+		Items: []TemplateItem{
+			{Title: "Laptop for {{name}}", Tags: []string{"{{team}}"}, Subtasks: []TemplateItem{
+				{Title: "Order", Content: "Ask {{manager}}"},
+			}},
+		},
+	}
+	assert.Equal(t, []string{"manager", "name", "team"}, template.FindVariables())
+}
+
+func TestCountItemsAndDepth(t *testing.T) {
+	items := []TemplateItem{
+		{Title: "A", Subtasks: []TemplateItem{{Title: "A1", Subtasks: []TemplateItem{{Title: "A1a"}}}, {Title: "A2"}}},
+		{Title: "B"},
+	}
+	assert.Equal(t, 5, CountItems(items))
+	assert.Equal(t, 2, ItemDepth(items))
+	assert.Equal(t, 0, ItemDepth([]TemplateItem{{Title: "C"}}))
+}
+
+func TestTemplateItemsFromTodos(t *testing.T) {
+	start := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	due := start.Add(72 * time.Hour)
+	past := start.Add(-time.Hour)
+	parentID, outsideID := 1, 99
+
+	items := TemplateItemsFromTodos([]*Todo{
+		{ID: 1, Title: "Laptop", DueAt: &due, Priority: PriorityHigh, Tags: []Tag{{Name: "it"}}},
+		{ID: 2, Title: "Order", ParentID: &parentID, DueAt: &past},
+		{ID: 3, Title: "Badge", ParentID: &outsideID},
+	}, start)
+
+	require.Len(t, items, 2)
+	assert.Equal(t, TemplateItem{Title: "Laptop", DueOffset: "3d", Priority: "high", Tags: []string{"it"},
+		Subtasks: []TemplateItem{{Title: "Order", DueOffset: "0d", Subtasks: []TemplateItem{}}}}, items[0])
+	assert.Equal(t, "Badge", items[1].Title)
+}
+
+func TestCreateTemplateValidation(t *testing.T) {
+	v := validator.New()
+
+	valid := CreateTemplateRequest{Name: "Onboarding", Items: []TemplateItem{
+		{Title: "Laptop", Priority: "high", Subtasks: []TemplateItem{{Title: "Order"}}},
+	}}
+	assert.NoError(t, v.Struct(valid))
+
+	assert.Error(t, v.Struct(CreateTemplateRequest{Name: "Empty"}))
+	nested := valid
+	nested.Items = []TemplateItem{{Title: "Laptop", Subtasks: []TemplateItem{{Title: ""}}}}
+	assert.Error(t, v.Struct(nested))
+}
+
+func TestTrimTags(t *testing.T) {
+	assert.Equal(t, []string{"Work", "home"}, TrimTags([]string{" Work ", "", "work", "home"}))
+	assert.Nil(t, TrimTags(nil))
+}
@@ -0,0 +1,204 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeEntry is time a user spent on a todo, either tracked with a timer or entered by hand.
+// EndedAt is nil while the entry's timer is running.
+type TimeEntry struct {
+	ID        int        `json:"id" db:"id"`
+	TodoID    int        `json:"todo_id" db:"todo_id"`
+	TodoTitle string     `json:"todo_title"`
+	ProjectID *int       `json:"project_id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	Username  string     `json:"username"`
+	StartedAt time.Time  `json:"started_at" db:"started_at"`
+	EndedAt   *time.Time `json:"ended_at" db:"ended_at"`
+	Note      string     `json:"note" db:"note"`
+	Running   bool       `json:"running"`
+	Seconds   int64      `json:"seconds"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+
+	// ProjectName and Tags describe the entry's todo for reports
+	ProjectName string `json:"-"`
+	Tags        []Tag  `json:"-"`
+}
+
+// Measure sets whether the entry is running and how many seconds it covers, counting a
+// running timer up to now
+func (e *TimeEntry) Measure(now time.Time) {
+	end := now
+	if e.EndedAt != nil {
+		end = *e.EndedAt
+	}
+	e.Running = e.EndedAt == nil
+	e.Seconds = int64(end.Sub(e.StartedAt) / time.Second)
+	if e.Seconds < 0 {
+		e.Seconds = 0
+	}
+}
+
+// In converts the entry's timestamps to loc for presentation
+func (e *TimeEntry) In(loc *time.Location) {
+	e.StartedAt = e.StartedAt.In(loc)
+	e.CreatedAt = e.CreatedAt.In(loc)
+	e.UpdatedAt = e.UpdatedAt.In(loc)
+	if e.EndedAt != nil {
+		ended := e.EndedAt.In(loc)
+		e.EndedAt = &ended
+	}
+}
+
+// Time report periods and groupings
+const (
+	TimePeriodDay  = "day"
+	TimePeriodWeek = "week"
+
+	TimeGroupTodo    = "todo"
+	TimeGroupProject = "project"
+	TimeGroupTag     = "tag"
+)
+
+// TimeReportRow is the time tracked in one period, for one todo, project or tag when the
+// report is grouped. Untagged time and time outside any project is grouped without an ID.
+type TimeReportRow struct {
+	Period  string `json:"period,omitempty"`
+	ID      *int   `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Seconds int64  `json:"seconds"`
+	Entries int    `json:"entries"`
+}
+
+// TimeReport aggregates time entries between two dates, inclusive
+type TimeReport struct {
+	From         string          `json:"from"`
+	To           string          `json:"to"`
+	Period       string          `json:"period,omitempty"`
+	GroupBy      string          `json:"group_by,omitempty"`
+	Rows         []TimeReportRow `json:"rows"`
+	TotalSeconds int64           `json:"total_seconds"`
+}
+
+// TodoTime holds the time entries of a todo and their total
+type TodoTime struct {
+	Entries      []TimeEntry `json:"entries"`
+	TotalSeconds int64       `json:"total_seconds"`
+}
+
+// PeriodStart returns the local date starting the day or the Monday-based week of t
+func PeriodStart(t time.Time, period string, loc *time.Location) string {
+	t = t.In(loc)
+	if period == TimePeriodWeek {
+		offset := (int(t.Weekday()) + 6) % 7
+		t = t.AddDate(0, 0, -offset)
+	}
+	return t.Format("2006-01-02")
+}
+
+// AggregateTime sums measured time entries by the day or week they started in, when period is
+// set, and by todo, project or tag, when groupBy is set. An entry counts towards each of its
+// todo's tags. Rows are ordered by period, then by name.
+func AggregateTime(entries []TimeEntry, period, groupBy string, loc *time.Location) []TimeReportRow {
+	type key struct {
+		period string
+		id     int
+		name   string
+	}
+	rows := map[key]*TimeReportRow{}
+	var order []key
+
+	for _, entry := range entries {
+		var start string
+		if period != "" {
+			start = PeriodStart(entry.StartedAt, period, loc)
+		}
+		for _, group := range entryGroups(entry, groupBy) {
+			k := key{start, 0, group.Name}
+			if group.ID != nil {
+				k.id = *group.ID
+			}
+			row, ok := rows[k]
+			if !ok {
+				row = &TimeReportRow{Period: start, ID: group.ID, Name: group.Name}
+				rows[k] = row
+				order = append(order, k)
+			}
+			row.Seconds += entry.Seconds
+			row.Entries++
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].period != order[j].period {
+			return order[i].period < order[j].period
+		}
+		if order[i].name != order[j].name {
+			return order[i].name < order[j].name
+		}
+		return order[i].id < order[j].id
+	})
+	result := make([]TimeReportRow, len(order))
+	for i, k := range order {
+		result[i] = *rows[k]
+	}
+	return result
+}
+
+// entryGroups returns the todos, projects or tags an entry is reported under
+func entryGroups(entry TimeEntry, groupBy string) []TimeReportRow {
+	switch groupBy {
+	case TimeGroupTodo:
+		id := entry.TodoID
+		return []TimeReportRow{{ID: &id, Name: entry.TodoTitle}}
+	case TimeGroupProject:
+		if entry.ProjectID == nil {
+			return []TimeReportRow{{Name: "No project"}}
+		}
+		id := *entry.ProjectID
+		return []TimeReportRow{{ID: &id, Name: entry.ProjectName}}
+	case TimeGroupTag:
+		if len(entry.Tags) == 0 {
+			return []TimeReportRow{{Name: "No tag"}}
+		}
+		groups := make([]TimeReportRow, len(entry.Tags))
+		for i, tag := range entry.Tags {
+			id := tag.ID
+			groups[i] = TimeReportRow{ID: &id, Name: tag.Name}
+		}
+		return groups
+	default:
+		return []TimeReportRow{{}}
+	}
+}
+
+// Time tracking DTOs
+type StartTimerRequest struct {
+	Note string `json:"note,omitempty" validate:"max=500"`
+}
+
+type CreateTimeEntryRequest struct {
+	StartedAt time.Time `json:"started_at" validate:"required"`
+	EndedAt   time.Time `json:"ended_at" validate:"required"`
+	Note      string    `json:"note,omitempty" validate:"max=500"`
+}
+
+type UpdateTimeEntryRequest struct {
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	Note      *string    `json:"note,omitempty" validate:"omitempty,max=500"`
+}
+
+// TimeReportRequest selects the time entries of a report by the local dates they started on,
+// From and To included. Entries may be narrowed to one todo, project or tag.
+type TimeReportRequest struct {
+	From      string `json:"from" validate:"omitempty,datetime=2006-01-02"`
+	To        string `json:"to" validate:"omitempty,datetime=2006-01-02"`
+	Period    string `json:"period" validate:"omitempty,oneof=day week"`
+	GroupBy   string `json:"group_by" validate:"omitempty,oneof=todo project tag"`
+	TodoID    *int   `json:"todo_id,omitempty" validate:"omitempty,min=1"`
+	ProjectID *int   `json:"project_id,omitempty" validate:"omitempty,min=1"`
+	TagID     *int   `json:"tag_id,omitempty" validate:"omitempty,min=1"`
+}
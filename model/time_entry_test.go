@@ -0,0 +1,81 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeEntryMeasure(t *testing.T) {
+	start := time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Minute)
+
+	stopped := TimeEntry{StartedAt: start, EndedAt: &end}
+	stopped.Measure(start.Add(5 * time.Hour))
+	assert.False(t, stopped.Running)
+	assert.Equal(t, int64(5400), stopped.Seconds)
+
+	running := TimeEntry{StartedAt: start}
+	running.Measure(start.Add(10*time.Minute + 500*time.Millisecond))
+	assert.True(t, running.Running)
+	assert.Equal(t, int64(600), running.Seconds)
+}
+
+func TestPeriodStart(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Sunday 23:30 UTC is already Monday in Berlin
+	sunday := time.Date(2025, 11, 9, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, "2025-11-09", PeriodStart(sunday, TimePeriodDay, time.UTC))
+	assert.Equal(t, "2025-11-03", PeriodStart(sunday, TimePeriodWeek, time.UTC))
+	assert.Equal(t, "2025-11-10", PeriodStart(sunday, TimePeriodDay, berlin))
+	assert.Equal(t, "2025-11-10", PeriodStart(sunday, TimePeriodWeek, berlin))
+}
+
+func TestAggregateTime(t *testing.T) {
+	project := 7
+	monday := time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)
+	entries := []TimeEntry{
+		{TodoID: 1, TodoTitle: "Design", ProjectID: &project, ProjectName: "Acme", StartedAt: monday, Seconds: 3600,
+			Tags: []Tag{{ID: 1, Name: "billable"}, {ID: 2, Name: "design"}}},
+		{TodoID: 1, TodoTitle: "Design", ProjectID: &project, ProjectName: "Acme", StartedAt: monday.AddDate(0, 0, 1), Seconds: 1800,
+			Tags: []Tag{{ID: 1, Name: "billable"}}},
+		{TodoID: 2, TodoTitle: "Email", StartedAt: monday.AddDate(0, 0, 7), Seconds: 600},
+	}
+
+	total := AggregateTime(entries, "", "", time.UTC)
+	require.Len(t, total, 1)
+	assert.Equal(t, int64(6000), total[0].Seconds)
+	assert.Equal(t, 3, total[0].Entries)
+
+	weeks := AggregateTime(entries, TimePeriodWeek, "", time.UTC)
+	require.Len(t, weeks, 2)
+	assert.Equal(t, TimeReportRow{Period: "2025-11-03", Seconds: 5400, Entries: 2}, weeks[0])
+	assert.Equal(t, TimeReportRow{Period: "2025-11-10", Seconds: 600, Entries: 1}, weeks[1])
+
+	projects := AggregateTime(entries, "", TimeGroupProject, time.UTC)
+	require.Len(t, projects, 2)
+	assert.Equal(t, "Acme", projects[0].Name)
+	assert.Equal(t, project, *projects[0].ID)
+	assert.Equal(t, int64(5400), projects[0].Seconds)
+	assert.Equal(t, "No project", projects[1].Name)
+	assert.Nil(t, projects[1].ID)
+
+	tags := AggregateTime(entries, "", TimeGroupTag, time.UTC)
+	require.Len(t, tags, 3)
+	assert.Equal(t, "No tag", tags[0].Name)
+	assert.Equal(t, "billable", tags[1].Name)
+	assert.Equal(t, int64(5400), tags[1].Seconds)
+	assert.Equal(t, "design", tags[2].Name)
+	assert.Equal(t, int64(3600), tags[2].Seconds)
+
+	days := AggregateTime(entries, TimePeriodDay, TimeGroupTodo, time.UTC)
+	require.Len(t, days, 3)
+	assert.Equal(t, "2025-11-03", days[0].Period)
+	assert.Equal(t, "Design", days[0].Name)
+	assert.Equal(t, "2025-11-10", days[2].Period)
+	assert.Equal(t, "Email", days[2].Name)
+}
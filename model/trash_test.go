@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoSchedulePurge(t *testing.T) {
+	deletedAt := time.Date(2025, 10, 20, 14, 30, 0, 0, time.UTC)
+	todo := Todo{DeletedAt: &deletedAt}
+
+	todo.SchedulePurge(30)
+	require.NotNil(t, todo.PurgeAt)
+	assert.Equal(t, time.Date(2025, 11, 19, 14, 30, 0, 0, time.UTC), *todo.PurgeAt)
+
+	// Without retention trashed todos are kept
+	todo.SchedulePurge(0)
+	assert.Nil(t, todo.PurgeAt)
+
+	// Todos outside the trash are never purged
+	open := Todo{}
+	open.SchedulePurge(30)
+	assert.Nil(t, open.PurgeAt)
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Statuses of the default workflow, used by todos outside a project and by projects
+// without a workflow of their own
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusBlocked    = "blocked"
+	StatusInReview   = "in_review"
+	StatusDone       = "done"
+)
+
+// WorkflowStatus is a column of a workflow. Done statuses mark their todos completed.
+// Transitions lists the statuses a todo may move to next; empty allows any.
+type WorkflowStatus struct {
+	Key         string   `json:"key" validate:"required,max=50"`
+	Name        string   `json:"name" validate:"required,min=1,max=100"`
+	Done        bool     `json:"done"`
+	Transitions []string `json:"transitions" validate:"max=20"`
+}
+
+// Workflow is an ordered list of statuses
+type Workflow []WorkflowStatus
+
+// DefaultWorkflow returns the workflow of todos outside a project with a workflow of its own
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		{Key: StatusTodo, Name: "To do", Transitions: []string{}},
+		{Key: StatusInProgress, Name: "In progress", Transitions: []string{}},
+		{Key: StatusBlocked, Name: "Blocked", Transitions: []string{}},
+		{Key: StatusInReview, Name: "In review", Transitions: []string{}},
+		{Key: StatusDone, Name: "Done", Done: true, Transitions: []string{}},
+	}
+}
+
+var statusKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Validate checks that keys are unique and well-formed, transitions point at statuses of
+// the workflow, and there is at least one open and one done status
+func (w Workflow) Validate() error {
+	seen := make(map[string]bool, len(w))
+	for _, status := range w {
+		if !statusKeyPattern.MatchString(status.Key) {
+			return fmt.Errorf("invalid status key %q: use lowercase letters, digits and underscores", status.Key)
+		}
+		if seen[status.Key] {
+			return fmt.Errorf("duplicate status key %q", status.Key)
+		}
+		seen[status.Key] = true
+	}
+
+	for _, status := range w {
+		for _, next := range status.Transitions {
+			if !seen[next] {
+				return fmt.Errorf("status %q allows a transition to unknown status %q", status.Key, next)
+			}
+		}
+	}
+
+	if w.Initial() == nil || w.FirstDone() == nil {
+		return errors.New("a workflow needs at least one open and one done status")
+	}
+	return nil
+}
+
+// Find returns the status with the given key, or nil
+func (w Workflow) Find(key string) *WorkflowStatus {
+	for i := range w {
+		if w[i].Key == key {
+			return &w[i]
+		}
+	}
+	return nil
+}
+
+// Initial returns the first open status, which new todos start in
+func (w Workflow) Initial() *WorkflowStatus {
+	for i := range w {
+		if !w[i].Done {
+			return &w[i]
+		}
+	}
+	return nil
+}
+
+// FirstDone returns the first done status, which completed todos move to
+func (w Workflow) FirstDone() *WorkflowStatus {
+	for i := range w {
+		if w[i].Done {
+			return &w[i]
+		}
+	}
+	return nil
+}
+
+// CanTransition reports whether a todo may move from one status to another. Staying in
+// place is always allowed, and so is leaving a status that is not part of the workflow.
+func (w Workflow) CanTransition(from, to string) bool {
+	if from == to {
+		return true
+	}
+	current := w.Find(from)
+	if current == nil || len(current.Transitions) == 0 {
+		return true
+	}
+	for _, next := range current.Transitions {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Fit returns status if it belongs to the workflow and agrees with completed, otherwise the
+// first open or done status
+func (w Workflow) Fit(status string, completed bool) string {
+	if current := w.Find(status); current != nil && current.Done == completed {
+		return status
+	}
+	if completed {
+		return w.FirstDone().Key
+	}
+	return w.Initial().Key
+}
+
+// Keys returns the keys of the workflow's open or done statuses
+func (w Workflow) Keys(done bool) []string {
+	keys := []string{}
+	for _, status := range w {
+		if status.Done == done {
+			keys = append(keys, status.Key)
+		}
+	}
+	return keys
+}
+
+// Workflow DTOs
+type UpdateWorkflowRequest struct {
+	Statuses []WorkflowStatus `json:"statuses" validate:"required,min=2,max=20,dive"`
+}
+
+// BoardColumn holds the todos in one status, in board order
+type BoardColumn struct {
+	Status WorkflowStatus `json:"status"`
+	Todos  []*Todo        `json:"todos"`
+}
+
+// Board shows a project's todos grouped by workflow status
+type Board struct {
+	Project *Project      `json:"project"`
+	Columns []BoardColumn `json:"columns"`
+}
+
+// BoardMoveRequest moves a todo to Position (0 is the top) in the column of Status
+type BoardMoveRequest struct {
+	TodoID   int    `json:"todo_id" validate:"required,min=1"`
+	Status   string `json:"status" validate:"required,max=50"`
+	Position int    `json:"position" validate:"min=0"`
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowValidate(t *testing.T) {
+	assert.NoError(t, DefaultWorkflow().Validate())
+
+	valid := Workflow{
+		{Key: "backlog", Name: "Backlog", Transitions: []string{"doing"}},
+		{Key: "doing", Name: "Doing"},
+		{Key: "shipped", Name: "Shipped", Done: true, Transitions: []string{"doing"}},
+	}
+	assert.NoError(t, valid.Validate())
+
+	for name, workflow := range map[string]Workflow{
+		"bad key":            {{Key: "In Progress", Name: "x"}, {Key: "done", Name: "Done", Done: true}},
+		"duplicate key":      {{Key: "todo", Name: "a"}, {Key: "todo", Name: "b"}, {Key: "done", Name: "Done", Done: true}},
+		"unknown transition": {{Key: "todo", Name: "To do", Transitions: []string{"qa"}}, {Key: "done", Name: "Done", Done: true}},
+		"no done status":     {{Key: "todo", Name: "To do"}, {Key: "doing", Name: "Doing"}},
+		"no open status":     {{Key: "done", Name: "Done", Done: true}, {Key: "closed", Name: "Closed", Done: true}},
+	} {
+		assert.Error(t, workflow.Validate(), name)
+	}
+}
+
+func TestWorkflowTransitions(t *testing.T) {
+	workflow := Workflow{
+		{Key: "backlog", Name: "Backlog", Transitions: []string{"doing"}},
+		{Key: "doing", Name: "Doing"},
+		{Key: "shipped", Name: "Shipped", Done: true},
+	}
+
+	assert.True(t, workflow.CanTransition("backlog", "doing"))
+	assert.False(t, workflow.CanTransition("backlog", "shipped"))
+	assert.True(t, workflow.CanTransition("backlog", "backlog"))
+	assert.True(t, workflow.CanTransition("doing", "backlog"))
+	assert.True(t, workflow.CanTransition("legacy", "shipped"))
+}
+
+func TestWorkflowFit(t *testing.T) {
+	workflow := DefaultWorkflow()
+
+	assert.Equal(t, StatusInReview, workflow.Fit(StatusInReview, false))
+	assert.Equal(t, StatusDone, workflow.Fit(StatusInReview, true))
+	assert.Equal(t, StatusTodo, workflow.Fit(StatusDone, false))
+	assert.Equal(t, StatusTodo, workflow.Fit("qa", false))
+	assert.Equal(t, StatusDone, workflow.Fit("", true))
+
+	assert.Equal(t, []string{StatusDone}, workflow.Keys(true))
+	assert.Len(t, workflow.Keys(false), 4)
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification drivers
+const (
+	DriverLog  = "log"
+	DriverSMTP = "smtp"
+)
+
+// Message is a plain-text notification addressed to an email address
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers notifications to users
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// Config selects and configures a Notifier implementation
+type Config struct {
+	Driver       string
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// New creates the Notifier selected by config.Driver
+func New(config Config) (Notifier, error) {
+	switch config.Driver {
+	case DriverLog, "":
+		return LogNotifier{}, nil
+	case DriverSMTP:
+		if config.SMTPHost == "" || config.From == "" {
+			return nil, fmt.Errorf("SMTP host and sender address are required")
+		}
+		return NewSMTPNotifier(config), nil
+	default:
+		return nil, fmt.Errorf("unknown notification driver %q", config.Driver)
+	}
+}
+
+// LogNotifier writes notifications to the application log, for development
+type LogNotifier struct{}
+
+// Send logs the message
+func (LogNotifier) Send(msg Message) error {
+	log.Printf("NOTIFY to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// SMTPNotifier sends notifications as email, using STARTTLS when the server offers it
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPNotifier creates an SMTP notifier; authentication is used when a username is configured
+func NewSMTPNotifier(config Config) *SMTPNotifier {
+	notifier := &SMTPNotifier{
+		addr: config.SMTPHost + ":" + strconv.Itoa(config.SMTPPort),
+		from: config.From,
+	}
+	if config.SMTPUsername != "" {
+		notifier.auth = smtp.PlainAuth("", config.SMTPUsername, config.SMTPPassword, config.SMTPHost)
+	}
+	return notifier
+}
+
+// Send delivers the message by email
+func (n *SMTPNotifier) Send(msg Message) error {
+	to := sanitizeHeader(msg.To)
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{to}, buildMessage(n.from, msg, time.Now())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// buildMessage renders an RFC 5322 plain-text message
+func buildMessage(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + sanitizeHeader(from) + "\r\n")
+	b.WriteString("To: " + sanitizeHeader(msg.To) + "\r\n")
+	b.WriteString("Subject: " + sanitizeHeader(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// sanitizeHeader strips line breaks so values cannot inject extra headers
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
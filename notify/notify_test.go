@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildMessage(t *testing.T) {
+	msg := Message{
+		To:      "user@example.com",
+		Subject: "Hello\r\nBcc: attacker@example.com",
+		Body:    "line one\nline two",
+	}
+
+	raw := string(buildMessage("noreply@example.com", msg, time.Date(2025, 10, 6, 12, 0, 0, 0, time.UTC)))
+	headers := raw[:strings.Index(raw, "\r\n\r\n")]
+
+	assert.Contains(t, headers, "To: user@example.com\r\n")
+	assert.Contains(t, headers, "Subject: HelloBcc: attacker@example.com")
+	assert.NotContains(t, headers, "\r\nBcc:")
+	assert.True(t, strings.HasSuffix(raw, "\r\n\r\nline one\r\nline two"))
+}
+
+func TestNew(t *testing.T) {
+	notifier, err := New(Config{Driver: DriverLog})
+	assert.NoError(t, err)
+	assert.IsType(t, LogNotifier{}, notifier)
+
+	_, err = New(Config{Driver: DriverSMTP})
+	assert.Error(t, err)
+
+	_, err = New(Config{Driver: "pigeon"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+// Package rank generates fractional ordering keys: strings of base-36 digits that sort in
+// byte order, so an item can be moved between two others by giving it a key that sorts
+// between theirs without touching any other item. Keys never end in the zero digit, which
+// guarantees there is always room for another key before any key.
+package rank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// digits are in ascending byte order
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// Between returns a key that sorts strictly between a and b. An empty a means before
+// every key and an empty b after every key.
+func Between(a, b string) (string, error) {
+	if err := check(a); err != nil {
+		return "", err
+	}
+	if err := check(b); err != nil {
+		return "", err
+	}
+	if b != "" && a >= b {
+		return "", fmt.Errorf("rank %q does not sort before %q", a, b)
+	}
+	return midpoint(a, b), nil
+}
+
+// Spread returns n evenly spaced keys in ascending order, all of the same minimal length
+// before trailing zeros are dropped
+func Spread(n int) []string {
+	width, capacity := 1, base
+	for capacity <= n {
+		width++
+		capacity *= base
+	}
+	step := capacity / (n + 1)
+
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strings.TrimRight(encode((i+1)*step, width), digits[:1])
+	}
+	return keys
+}
+
+// midpoint finds a key between a and b, which must be valid keys with a < b
+func midpoint(a, b string) string {
+	if b != "" {
+		// Keep the common prefix, reading missing digits of a as zeros
+		n := 0
+		for n < len(b) && digitAt(a, n) == index(b[n]) {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(suffix(a, n), b[n:])
+		}
+	}
+
+	digitA := digitAt(a, 0)
+	digitB := base
+	if b != "" {
+		digitB = index(b[0])
+	}
+	if digitB-digitA > 1 {
+		return string(digits[(digitA+digitB+1)/2])
+	}
+
+	// The first digits are adjacent: a key of b's first digit alone sorts between them
+	// when b continues, otherwise extend a
+	if len(b) > 1 {
+		return b[:1]
+	}
+	return string(digits[digitA]) + midpoint(suffix(a, 1), "")
+}
+
+// check validates a key, where the empty key stands for an open end
+func check(key string) error {
+	for i := 0; i < len(key); i++ {
+		if index(key[i]) < 0 {
+			return fmt.Errorf("invalid rank %q", key)
+		}
+	}
+	if strings.HasSuffix(key, digits[:1]) {
+		return errors.New("rank must not end in " + digits[:1])
+	}
+	return nil
+}
+
+func encode(value, width int) string {
+	buf := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = digits[value%base]
+		value /= base
+	}
+	return string(buf)
+}
+
+func index(c byte) int {
+	return strings.IndexByte(digits, c)
+}
+
+// digitAt returns the digit of key at i, or zero past its end
+func digitAt(key string, i int) int {
+	if i < len(key) {
+		return index(key[i])
+	}
+	return 0
+}
+
+func suffix(key string, n int) string {
+	if n < len(key) {
+		return key[n:]
+	}
+	return ""
+}
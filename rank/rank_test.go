@@ -0,0 +1,94 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBetween(t *testing.T) {
+	for _, tc := range []struct{ a, b, want string }{
+		{"", "", "i"},
+		{"i", "", "r"},
+		{"", "i", "9"},
+		{"a", "b", "ai"},
+		{"a", "a1", "a0i"},
+		{"az", "b", "azi"},
+		{"a1", "b", "aj"},
+		{"00001", "00002", "00001i"},
+		{"", "0001", "0000i"},
+	} {
+		got, err := Between(tc.a, tc.b)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.want, got, "between %q and %q", tc.a, tc.b)
+		assert.True(t, tc.a < got, "%q < %q", tc.a, got)
+		if tc.b != "" {
+			assert.True(t, got < tc.b, "%q < %q", got, tc.b)
+		}
+	}
+}
+
+func TestBetweenInvalid(t *testing.T) {
+	for _, tc := range []struct{ a, b string }{
+		{"b", "a"},
+		{"a", "a"},
+		{"a0", ""},
+		{"A", ""},
+		{"", "a-b"},
+	} {
+		_, err := Between(tc.a, tc.b)
+		assert.Error(t, err, "between %q and %q", tc.a, tc.b)
+	}
+}
+
+func TestBetweenRepeatedInserts(t *testing.T) {
+	// Always inserting right after the first key grows keys slowly and keeps order
+	first, err := Between("", "")
+	assert.NoError(t, err)
+	keys := []string{first, "z"}
+	for i := 0; i < 200; i++ {
+		key, err := Between(keys[0], keys[1])
+		assert.NoError(t, err)
+		keys = append([]string{keys[0], key}, keys[1:]...)
+	}
+	assert.True(t, sort.StringsAreSorted(keys))
+	assert.Less(t, len(keys[1]), 50)
+
+	// Random inserts keep every key unique and in order
+	keys = []string{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		at := rng.Intn(len(keys) + 1)
+		var a, b string
+		if at > 0 {
+			a = keys[at-1]
+		}
+		if at < len(keys) {
+			b = keys[at]
+		}
+		key, err := Between(a, b)
+		assert.NoError(t, err)
+		keys = append(keys[:at], append([]string{key}, keys[at:]...)...)
+	}
+	assert.True(t, sort.StringsAreSorted(keys))
+}
+
+func TestSpread(t *testing.T) {
+	keys := Spread(3)
+	assert.Equal(t, []string{"9", "i", "r"}, keys)
+
+	keys = Spread(1000)
+	assert.Len(t, keys, 1000)
+	assert.True(t, sort.StringsAreSorted(keys))
+	for i, key := range keys {
+		assert.LessOrEqual(t, len(key), 2)
+		assert.NoError(t, check(key))
+		if i > 0 {
+			assert.NotEqual(t, keys[i-1], key)
+		}
+	}
+
+	assert.Empty(t, Spread(0))
+}
@@ -0,0 +1,474 @@
+// Package recurrence implements the subset of RFC 5545 recurrence rules used for repeating
+// todos: FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY,
+// BYMONTH and WKST.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the base unit a rule repeats in
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods bounds expansion of rules that can never produce another occurrence,
+// such as FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30
+const maxPeriods = 100000
+
+var weekdayCodes = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// WeekdayNum is a BYDAY entry; N selects the nth (negative: from the end) weekday of the
+// month or year, and 0 means every such weekday
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	UntilDate  bool // UNTIL was a date and covers that whole day in the series timezone
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+// Parse parses an RRULE value such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10"
+func Parse(value string) (*Rule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return nil, errors.New("recurrence rule is empty")
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		name, val := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate rule part %s", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			switch Frequency(val) {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = Frequency(val)
+			default:
+				err = fmt.Errorf("unsupported FREQ %q", val)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parseInt(val, 1, 1000)
+		case "COUNT":
+			rule.Count, err = parseInt(val, 1, 10000)
+		case "UNTIL":
+			rule.Until, rule.UntilDate, err = parseUntil(val)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(val, 1, 12)
+			for _, m := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(m))
+			}
+		case "WKST":
+			day, ok := weekdayCodes[val]
+			if !ok {
+				err = fmt.Errorf("invalid WKST %q", val)
+			}
+			rule.WeekStart = day
+		default:
+			err = fmt.Errorf("unsupported rule part %s", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, errors.New("COUNT and UNTIL cannot be combined")
+	}
+	for _, d := range rule.ByDay {
+		if d.N != 0 && rule.Freq != Monthly && rule.Freq != Yearly {
+			return nil, errors.New("numbered BYDAY values require FREQ=MONTHLY or FREQ=YEARLY")
+		}
+	}
+	if len(rule.ByMonthDay) > 0 && rule.Freq == Weekly {
+		return nil, errors.New("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+	return rule, nil
+}
+
+// String formats the rule in canonical RRULE form
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.UntilDate {
+			parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+		}
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, d := range r.ByDay {
+			days[i] = weekdayCode(d.Day)
+			if d.N != 0 {
+				days[i] = strconv.Itoa(d.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]int, len(r.ByMonth))
+		for i, m := range r.ByMonth {
+			months[i] = int(m)
+		}
+		parts = append(parts, "BYMONTH="+joinInts(months))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCode(r.WeekStart))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Iterate calls fn with each occurrence at or after start, in order, until fn returns false
+// or the rule ends. Occurrences keep start's time of day in start's location, so a rule
+// stays at the same local time across daylight saving changes. start itself is only an
+// occurrence if it matches the rule.
+func (r *Rule) Iterate(start time.Time, fn func(time.Time) bool) {
+	loc := start.Location()
+	until := r.Until
+	if r.UntilDate {
+		until = time.Date(r.Until.Year(), r.Until.Month(), r.Until.Day(), 23, 59, 59, 0, loc)
+	}
+
+	emitted := 0
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range r.expand(start, period) {
+			if t.Before(start) {
+				continue
+			}
+			if !until.IsZero() && t.After(until) {
+				return
+			}
+			if !fn(t) {
+				return
+			}
+			emitted++
+			if r.Count > 0 && emitted >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// After returns up to limit occurrences strictly after t. Occurrences whose local date
+// (YYYY-MM-DD in start's location) is listed in exceptions are skipped; as in RFC 5545,
+// they still count towards COUNT.
+func (r *Rule) After(start, t time.Time, limit int, exceptions []string) []time.Time {
+	excluded := make(map[string]bool, len(exceptions))
+	for _, date := range exceptions {
+		excluded[date] = true
+	}
+
+	var occurrences []time.Time
+	if limit <= 0 {
+		return occurrences
+	}
+	r.Iterate(start, func(occurrence time.Time) bool {
+		if occurrence.After(t) && !excluded[occurrence.Format("2006-01-02")] {
+			occurrences = append(occurrences, occurrence)
+		}
+		return len(occurrences) < limit
+	})
+	return occurrences
+}
+
+// expand returns the sorted candidate occurrences of the nth period after start
+func (r *Rule) expand(start time.Time, n int) []time.Time {
+	loc := start.Location()
+	h, m, s := start.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, h, m, s, 0, loc)
+	}
+	step := n * r.Interval
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := at(start.Year(), start.Month(), start.Day()+step)
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+
+	case Weekly:
+		offset := (int(start.Weekday()) - int(r.WeekStart) + 7) % 7
+		weekStart := at(start.Year(), start.Month(), start.Day()-offset+7*step)
+		for i := 0; i < 7; i++ {
+			day := at(weekStart.Year(), weekStart.Month(), weekStart.Day()+i)
+			matchesDay := day.Weekday() == start.Weekday()
+			if len(r.ByDay) > 0 {
+				matchesDay = r.matchesWeekday(day)
+			}
+			if matchesDay && r.matchesMonth(day) {
+				days = append(days, day)
+			}
+		}
+
+	case Monthly:
+		first := at(start.Year(), start.Month()+time.Month(step), 1)
+		if r.matchesMonth(first) {
+			days = r.expandMonth(first, start.Day(), at)
+		}
+
+	case Yearly:
+		year := start.Year() + step
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, month := range r.ByMonth {
+				days = append(days, r.expandMonth(at(year, month, 1), start.Day(), at)...)
+			}
+		case len(r.ByDay) > 0 || len(r.ByMonthDay) > 0:
+			if len(r.ByMonthDay) > 0 {
+				for month := time.January; month <= time.December; month++ {
+					days = append(days, r.expandMonth(at(year, month, 1), start.Day(), at)...)
+				}
+			} else {
+				days = r.expandWeekdays(at(year, time.January, 1), at(year+1, time.January, 1), at)
+			}
+		default:
+			day := at(year, start.Month(), start.Day())
+			if day.Day() == start.Day() {
+				days = append(days, day)
+			}
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return dedupe(days)
+}
+
+// expandMonth returns the days of the month starting at first selected by BYMONTHDAY and
+// BYDAY, or the start day of month when neither is set
+func (r *Rule) expandMonth(first time.Time, startDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	next := at(first.Year(), first.Month()+1, 1)
+	daysInMonth := next.AddDate(0, 0, -1).Day()
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if startDay > daysInMonth {
+			return nil
+		}
+		return []time.Time{at(first.Year(), first.Month(), startDay)}
+	}
+
+	var days []time.Time
+	if len(r.ByMonthDay) > 0 {
+		for _, md := range r.ByMonthDay {
+			day := md
+			if md < 0 {
+				day = daysInMonth + md + 1
+			}
+			if day < 1 || day > daysInMonth {
+				continue
+			}
+			t := at(first.Year(), first.Month(), day)
+			if len(r.ByDay) == 0 || r.matchesWeekdayIn(t, first, next) {
+				days = append(days, t)
+			}
+		}
+		return days
+	}
+	return r.expandWeekdays(first, next, at)
+}
+
+// expandWeekdays returns the days in [from, to) selected by BYDAY
+func (r *Rule) expandWeekdays(from, to time.Time, at func(int, time.Month, int) time.Time) []time.Time {
+	var days []time.Time
+	for d := from; d.Before(to); d = at(d.Year(), d.Month(), d.Day()+1) {
+		if r.matchesWeekdayIn(d, from, to) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// matchesWeekdayIn reports whether day matches BYDAY, counting ordinals within [from, to)
+func (r *Rule) matchesWeekdayIn(day, from, to time.Time) bool {
+	for _, wd := range r.ByDay {
+		if day.Weekday() != wd.Day {
+			continue
+		}
+		if wd.N == 0 {
+			return true
+		}
+		if wd.N > 0 && daysBetween(from, day)/7+1 == wd.N {
+			return true
+		}
+		if wd.N < 0 && daysBetween(day, to.AddDate(0, 0, -1))/7+1 == -wd.N {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if day.Weekday() == wd.Day {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if day.Month() == m {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, md := range r.ByMonthDay {
+		if md == day.Day() || (md < 0 && daysInMonth+md+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+// daysBetween counts calendar days from a to b, ignoring daylight saving shifts
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
+
+func dedupe(days []time.Time) []time.Time {
+	out := days[:0]
+	for i, d := range days {
+		if i == 0 || !d.Equal(days[i-1]) {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func parseInt(value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return n, nil
+}
+
+func parseIntList(value string, min, max int) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := parseInt(item, min, max)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid value %q", item)
+		}
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var days []WeekdayNum
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY value %q", item)
+		}
+		day, ok := weekdayCodes[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY value %q", item)
+		}
+		n := 0
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid BYDAY value %q", item)
+			}
+		}
+		days = append(days, WeekdayNum{N: n, Day: day})
+	}
+	return days, nil
+}
+
+func parseUntil(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func weekdayCode(day time.Weekday) string {
+	for code, d := range weekdayCodes {
+		if d == day {
+			return code
+		}
+	}
+	return ""
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,142 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, rule string, start time.Time, limit int) []string {
+	r, err := Parse(rule)
+	require.NoError(t, err)
+
+	var out []string
+	for _, occurrence := range r.After(start, start.Add(-time.Nanosecond), limit, nil) {
+		out = append(out, occurrence.Format("2006-01-02 15:04"))
+	}
+	return out
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	r, err := Parse("RRULE:freq=monthly;interval=2;byday=1MO,-1FR;count=5")
+	require.NoError(t, err)
+	assert.Equal(t, Monthly, r.Freq)
+	assert.Equal(t, 2, r.Interval)
+	assert.Equal(t, []WeekdayNum{{1, time.Monday}, {-1, time.Friday}}, r.ByDay)
+	assert.Equal(t, "FREQ=MONTHLY;INTERVAL=2;COUNT=5;BYDAY=1MO,-1FR", r.String())
+
+	r, err = Parse("FREQ=WEEKLY;UNTIL=20251231T235959Z;WKST=SU")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;UNTIL=20251231T235959Z;WKST=SU", r.String())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;COUNT=3;UNTIL=20250101",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=3",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;BYHOUR=9",
+	} {
+		_, err := Parse(rule)
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestDaily(t *testing.T) {
+	start := time.Date(2025, 3, 30, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"2025-03-30 09:00", "2025-04-01 09:00", "2025-04-03 09:00"},
+		collect(t, "FREQ=DAILY;INTERVAL=2;COUNT=3", start, 10))
+}
+
+func TestWeeklyByDay(t *testing.T) {
+	// Wednesday start; Monday is before start in the first week and must be skipped
+	start := time.Date(2025, 1, 1, 18, 30, 0, 0, time.UTC)
+	assert.Equal(t, []string{
+		"2025-01-01 18:30", "2025-01-03 18:30", "2025-01-06 18:30", "2025-01-08 18:30", "2025-01-10 18:30",
+	}, collect(t, "FREQ=WEEKLY;BYDAY=MO,WE,FR", start, 5))
+
+	assert.Equal(t, []string{"2025-01-01 18:30", "2025-01-15 18:30", "2025-01-29 18:30"},
+		collect(t, "FREQ=WEEKLY;INTERVAL=2", start, 3))
+}
+
+func TestMonthly(t *testing.T) {
+	start := time.Date(2025, 1, 31, 8, 0, 0, 0, time.UTC)
+	// Months without a 31st are skipped, as RFC 5545 requires
+	assert.Equal(t, []string{"2025-01-31 08:00", "2025-03-31 08:00", "2025-05-31 08:00"},
+		collect(t, "FREQ=MONTHLY", start, 3))
+
+	assert.Equal(t, []string{"2025-01-31 08:00", "2025-02-28 08:00", "2025-03-31 08:00"},
+		collect(t, "FREQ=MONTHLY;BYMONTHDAY=-1", start, 3))
+
+	start = time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"2025-01-06 08:00", "2025-01-31 08:00", "2025-02-03 08:00", "2025-02-28 08:00"},
+		collect(t, "FREQ=MONTHLY;BYDAY=1MO,-1FR", start, 4))
+
+	// Friday the 13th
+	assert.Equal(t, []string{"2025-06-13 08:00", "2026-02-13 08:00", "2026-03-13 08:00"},
+		collect(t, "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", start, 3))
+}
+
+func TestYearly(t *testing.T) {
+	start := time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"2024-02-29 12:00", "2028-02-29 12:00"},
+		collect(t, "FREQ=YEARLY", start, 2))
+
+	// US Thanksgiving
+	start = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"2025-11-27 12:00", "2026-11-26 12:00"},
+		collect(t, "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", start, 2))
+
+	// Last Sunday of the year
+	assert.Equal(t, []string{"2025-12-28 12:00"},
+		collect(t, "FREQ=YEARLY;BYDAY=-1SU", start, 1))
+}
+
+func TestUntil(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, []string{"2025-01-01 09:00", "2025-01-02 09:00", "2025-01-03 09:00"},
+		collect(t, "FREQ=DAILY;UNTIL=20250103", start, 10))
+	assert.Equal(t, []string{"2025-01-01 09:00", "2025-01-02 09:00"},
+		collect(t, "FREQ=DAILY;UNTIL=20250103T085959Z", start, 10))
+}
+
+func TestAfterSkipsExceptionsButCountsThem(t *testing.T) {
+	r, err := Parse("FREQ=DAILY;COUNT=4")
+	require.NoError(t, err)
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	occurrences := r.After(start, start, 10, []string{"2025-01-03"})
+	require.Len(t, occurrences, 2)
+	assert.Equal(t, time.Date(2025, 1, 2, 9, 0, 0, 0, time.UTC), occurrences[0])
+	assert.Equal(t, time.Date(2025, 1, 4, 9, 0, 0, 0, time.UTC), occurrences[1])
+}
+
+func TestKeepsLocalTimeAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	r, err := Parse("FREQ=WEEKLY")
+	require.NoError(t, err)
+	start := time.Date(2025, 3, 2, 9, 0, 0, 0, loc)
+	occurrences := r.After(start, start.Add(-time.Nanosecond), 2, nil)
+	require.Len(t, occurrences, 2)
+	for _, occurrence := range occurrences {
+		assert.Equal(t, 9, occurrence.Hour())
+	}
+	// Clocks spring forward on 2025-03-09, so that week is an hour short
+	assert.Equal(t, 7*24*time.Hour-time.Hour, occurrences[1].Sub(occurrences[0]))
+}
+
+func TestImpossibleRuleTerminates(t *testing.T) {
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	assert.Empty(t, collect(t, "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", start, 1))
+}
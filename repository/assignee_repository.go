@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type AssigneeRepository struct {
+	db *sql.DB
+}
+
+func NewAssigneeRepository(db *sql.DB) *AssigneeRepository {
+	return &AssigneeRepository{db: db}
+}
+
+// CountAssignable counts the users among userIDs that can be assigned todos owned by ownerID:
+// active users who are the owner, share an organization with them, or already share a todo
+// with them as an assignee
+func (r *AssigneeRepository) CountAssignable(ownerID int, userIDs []int) (int, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM users u
+			  WHERE u.id IN (%s) AND u.is_active = true AND (
+			    u.id = ?
+			    OR EXISTS (SELECT 1 FROM organization_members m
+			               JOIN organization_members o ON o.organization_id = m.organization_id
+			               WHERE m.user_id = u.id AND o.user_id = ?)
+			    OR EXISTS (SELECT 1 FROM todo_assignees ta JOIN todos t ON t.id = ta.todo_id
+			               WHERE t.deleted_at IS NULL
+			               AND ((ta.user_id = u.id AND t.user_id = ?) OR (ta.user_id = ? AND t.user_id = u.id)))
+			  )`, placeholders(len(userIDs)))
+	args := append(idArgs(userIDs), ownerID, ownerID, ownerID, ownerID)
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count assignable users: %w", err)
+	}
+	return count, nil
+}
+
+// setTodoAssignees replaces the assignees of a todo within tx and returns the users it added
+// and removed
+func setTodoAssignees(tx *sql.Tx, todoID, assignedBy int, userIDs []int) (added, removed []int, err error) {
+	rows, err := tx.Query(`SELECT user_id FROM todo_assignees WHERE todo_id = ? FOR UPDATE`, todoID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query todo assignees: %w", err)
+	}
+	current := map[int]bool{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("failed to scan todo assignee: %w", err)
+		}
+		current[userID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to query todo assignees: %w", err)
+	}
+
+	wanted := make(map[int]bool, len(userIDs))
+	for _, userID := range userIDs {
+		wanted[userID] = true
+		if current[userID] {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO todo_assignees (todo_id, user_id, assigned_by) VALUES (?, ?, ?)`,
+			todoID, userID, nullableID(assignedBy)); err != nil {
+			return nil, nil, fmt.Errorf("failed to assign todo: %w", err)
+		}
+		added = append(added, userID)
+	}
+	for userID := range current {
+		if wanted[userID] {
+			continue
+		}
+		if _, err := tx.Exec(`DELETE FROM todo_assignees WHERE todo_id = ? AND user_id = ?`, todoID, userID); err != nil {
+			return nil, nil, fmt.Errorf("failed to unassign todo: %w", err)
+		}
+		removed = append(removed, userID)
+	}
+
+	return added, removed, nil
+}
+
+// IsAssignee reports whether a user is assigned to a todo
+func (r *AssigneeRepository) IsAssignee(todoID, userID int) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM todo_assignees WHERE todo_id = ? AND user_id = ?)`
+	if err := r.db.QueryRow(query, todoID, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check todo assignee: %w", err)
+	}
+	return exists, nil
+}
+
+// GetAssigneesForTodos retrieves the assignees of several todos, keyed by todo ID
+func (r *AssigneeRepository) GetAssigneesForTodos(todoIDs []int) (map[int][]model.Assignee, error) {
+	assignees := make(map[int][]model.Assignee, len(todoIDs))
+	if len(todoIDs) == 0 {
+		return assignees, nil
+	}
+
+	query := fmt.Sprintf(`SELECT ta.todo_id, ta.user_id, u.username, ta.assigned_by, ta.created_at
+			  FROM todo_assignees ta JOIN users u ON u.id = ta.user_id
+			  WHERE ta.todo_id IN (%s) ORDER BY ta.created_at, ta.user_id`, placeholders(len(todoIDs)))
+
+	rows, err := r.db.Query(query, idArgs(todoIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todo assignees: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var assignee model.Assignee
+		var assignedBy sql.NullInt64
+		if err := rows.Scan(&todoID, &assignee.UserID, &assignee.Username, &assignedBy, &assignee.AssignedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan todo assignee: %w", err)
+		}
+		assignee.AssignedBy = scanNullableID(assignedBy)
+		assignees[todoID] = append(assignees[todoID], assignee)
+	}
+	return assignees, rows.Err()
+}
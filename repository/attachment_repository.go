@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+const attachmentColumns = `id, todo_id, user_id, filename, content_type, size, storage_key, created_at`
+
+// attachmentSelect selects the attachments of todos that are not in the trash
+const attachmentSelect = `SELECT a.id, a.todo_id, a.user_id, a.filename, a.content_type, a.size, a.storage_key, a.created_at
+			  FROM todo_attachments a JOIN todos t ON t.id = a.todo_id AND t.deleted_at IS NULL`
+
+// CreateAttachment records an uploaded attachment. Nothing is created, and false is returned,
+// when it would take the uploader's stored bytes over quota; uploads by the same user are
+// serialized on their user row.
+func (r *AttachmentRepository) CreateAttachment(attachment *model.Attachment, quota int64) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked int
+	if err := tx.QueryRow(`SELECT id FROM users WHERE id = ? FOR UPDATE`, attachment.UserID).Scan(&locked); err != nil {
+		return false, fmt.Errorf("failed to lock user: %w", err)
+	}
+	var used int64
+	err = tx.QueryRow(`SELECT COALESCE(SUM(size), 0) FROM todo_attachments WHERE user_id = ? AND todo_id IS NOT NULL`,
+		attachment.UserID).Scan(&used)
+	if err != nil {
+		return false, fmt.Errorf("failed to get attachment usage: %w", err)
+	}
+	if used+attachment.Size > quota {
+		return false, nil
+	}
+
+	result, err := tx.Exec(`INSERT INTO todo_attachments (todo_id, user_id, filename, content_type, size, storage_key)
+			  VALUES (?, ?, ?, ?, ?, ?)`, attachment.TodoID, attachment.UserID, attachment.Filename,
+		attachment.ContentType, attachment.Size, attachment.StorageKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get attachment ID: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit attachment: %w", err)
+	}
+
+	attachment.ID = int(id)
+	return true, nil
+}
+
+// GetAttachment retrieves an attachment of a todo that is not in the trash
+func (r *AttachmentRepository) GetAttachment(id, todoID int) (*model.Attachment, error) {
+	query := attachmentSelect + ` WHERE a.id = ? AND a.todo_id = ?`
+	return scanAttachment(r.db.QueryRow(query, id, todoID))
+}
+
+// GetAttachmentByID retrieves an attachment that still belongs to a todo outside the trash
+func (r *AttachmentRepository) GetAttachmentByID(id int) (*model.Attachment, error) {
+	query := attachmentSelect + ` WHERE a.id = ?`
+	return scanAttachment(r.db.QueryRow(query, id))
+}
+
+// GetAttachments retrieves the attachments of a todo that is not in the trash, oldest first
+func (r *AttachmentRepository) GetAttachments(todoID int) ([]model.Attachment, error) {
+	query := attachmentSelect + ` WHERE a.todo_id = ? ORDER BY a.created_at, a.id`
+
+	rows, err := r.db.Query(query, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// DeleteAttachment deletes the record of an attachment; the caller removes its blob
+func (r *AttachmentRepository) DeleteAttachment(id, todoID int) error {
+	result, err := r.db.Exec(`DELETE FROM todo_attachments WHERE id = ? AND todo_id = ?`, id, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("attachment not found")
+	}
+	return nil
+}
+
+// GetUsage returns the bytes a user's attachments take up
+func (r *AttachmentRepository) GetUsage(userID int) (int64, error) {
+	var used int64
+	err := r.db.QueryRow(`SELECT COALESCE(SUM(size), 0) FROM todo_attachments WHERE user_id = ? AND todo_id IS NOT NULL`,
+		userID).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get attachment usage: %w", err)
+	}
+	return used, nil
+}
+
+// GetOrphans retrieves up to limit attachments whose todo or uploader has been deleted
+func (r *AttachmentRepository) GetOrphans(limit int) ([]model.Attachment, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todo_attachments WHERE todo_id IS NULL OR user_id IS NULL ORDER BY id LIMIT ?`,
+		attachmentColumns)
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []model.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, rows.Err()
+}
+
+// DeleteOrphan deletes the record of an orphaned attachment once its blob is gone
+func (r *AttachmentRepository) DeleteOrphan(id int) error {
+	if _, err := r.db.Exec(`DELETE FROM todo_attachments WHERE id = ? AND (todo_id IS NULL OR user_id IS NULL)`, id); err != nil {
+		return fmt.Errorf("failed to delete orphaned attachment: %w", err)
+	}
+	return nil
+}
+
+func scanAttachment(row rowScanner) (*model.Attachment, error) {
+	attachment := &model.Attachment{}
+	var todoID, userID sql.NullInt64
+	err := row.Scan(&attachment.ID, &todoID, &userID, &attachment.Filename, &attachment.ContentType,
+		&attachment.Size, &attachment.StorageKey, &attachment.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	attachment.TodoID = int(todoID.Int64)
+	attachment.UserID = int(userID.Int64)
+	return attachment, nil
+}
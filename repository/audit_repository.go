@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// auditChainHead names the app_settings row that serializes writers of the audit log
+const auditChainHead = "audit_chain_head"
+
+const auditEventColumns = `id, actor_id, impersonator_id, action, target_type, target_id, before_state, after_state,
+	ip_address, user_agent, request_id, prev_hash, hash, created_at`
+
+// CreateEvent appends an event to the audit log, linking it to the hash of the latest entry
+func (r *AuditRepository) CreateEvent(event *model.AuditEvent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the chain head row so concurrent writers append one at a time, even to an empty log
+	var head string
+	if err := tx.QueryRow(`SELECT value FROM app_settings WHERE name = ? FOR UPDATE`, auditChainHead).Scan(&head); err != nil {
+		return fmt.Errorf("failed to lock audit chain head: %w", err)
+	}
+
+	var prevHash string
+	err = tx.QueryRow(`SELECT hash FROM audit_events ORDER BY id DESC LIMIT 1 FOR UPDATE`).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+
+	event.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	event.PrevHash = prevHash
+	event.Hash = event.ChainHash(prevHash)
+
+	query := `INSERT INTO audit_events (actor_id, impersonator_id, action, target_type, target_id, before_state, after_state,
+			  ip_address, user_agent, request_id, prev_hash, hash, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, event.ActorID, event.ImpersonatorID, event.Action, event.TargetType, event.TargetID,
+		nullableJSON(event.Before), nullableJSON(event.After), event.IPAddress, event.UserAgent, event.RequestID,
+		event.PrevHash, event.Hash, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get audit event ID: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit event: %w", err)
+	}
+
+	event.ID = id
+	return nil
+}
+
+// ListEvents retrieves audit events matching the filter, newest first, with pagination
+func (r *AuditRepository) ListEvents(filter model.AuditEventFilter) ([]model.AuditEvent, int64, error) {
+	whereClause, args := buildAuditWhereClause(filter)
+
+	// Count total records
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM audit_events %s", whereClause)
+	var total int64
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	offset := (filter.Page - 1) * filter.Limit
+	query := fmt.Sprintf(`SELECT %s FROM audit_events %s ORDER BY id DESC LIMIT ? OFFSET ?`, auditEventColumns, whereClause)
+	args = append(args, filter.Limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model.AuditEvent
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		events = append(events, *event)
+	}
+
+	return events, total, rows.Err()
+}
+
+// EachEvent calls fn for every audit event matching the filter in chain order, ignoring pagination
+func (r *AuditRepository) EachEvent(filter model.AuditEventFilter, fn func(event *model.AuditEvent) error) error {
+	whereClause, args := buildAuditWhereClause(filter)
+	query := fmt.Sprintf(`SELECT %s FROM audit_events %s ORDER BY id ASC`, auditEventColumns, whereClause)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// DeleteEventsBefore removes events older than the cutoff for retention purposes
+func (r *AuditRepository) DeleteEventsBefore(cutoff time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM audit_events WHERE created_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit events: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// buildAuditWhereClause translates a filter into a WHERE clause and its arguments
+func buildAuditWhereClause(filter model.AuditEventFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.ActorID > 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorID)
+	}
+	if filter.ImpersonatorID > 0 {
+		conditions = append(conditions, "impersonator_id = ?")
+		args = append(args, filter.ImpersonatorID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		conditions = append(conditions, "request_id = ?")
+		args = append(args, filter.RequestID)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// scanAuditEvent scans a row selected with auditEventColumns
+func scanAuditEvent(rows *sql.Rows) (*model.AuditEvent, error) {
+	event := &model.AuditEvent{}
+	var actorID, impersonatorID sql.NullInt64
+	var targetType, targetID, before, after, ip, userAgent, requestID sql.NullString
+
+	err := rows.Scan(
+		&event.ID, &actorID, &impersonatorID, &event.Action, &targetType, &targetID, &before, &after,
+		&ip, &userAgent, &requestID, &event.PrevHash, &event.Hash, &event.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan audit event: %w", err)
+	}
+
+	if actorID.Valid {
+		id := int(actorID.Int64)
+		event.ActorID = &id
+	}
+	if impersonatorID.Valid {
+		id := int(impersonatorID.Int64)
+		event.ImpersonatorID = &id
+	}
+	if before.Valid {
+		event.Before = []byte(before.String)
+	}
+	if after.Valid {
+		event.After = []byte(after.String)
+	}
+	event.TargetType = targetType.String
+	event.TargetID = targetID.String
+	event.IPAddress = ip.String
+	event.UserAgent = userAgent.String
+	event.RequestID = requestID.String
+
+	return event, nil
+}
+
+// nullableJSON maps an empty JSON document to NULL
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type ChecklistRepository struct {
+	db *sql.DB
+}
+
+func NewChecklistRepository(db *sql.DB) *ChecklistRepository {
+	return &ChecklistRepository{db: db}
+}
+
+const checklistColumns = `id, todo_id, content, checked, position, created_at, updated_at`
+
+// CreateItem adds a checklist item; a negative position appends it after the todo's other items
+func (r *ChecklistRepository) CreateItem(item *model.ChecklistItem) error {
+	if item.Position < 0 {
+		err := r.db.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM checklist_items WHERE todo_id = ?`, item.TodoID).
+			Scan(&item.Position)
+		if err != nil {
+			return fmt.Errorf("failed to get checklist position: %w", err)
+		}
+	}
+
+	return insertChecklistItem(r.db, item)
+}
+
+// insertChecklistItem inserts a checklist item at its position
+func insertChecklistItem(db execer, item *model.ChecklistItem) error {
+	result, err := db.Exec(`INSERT INTO checklist_items (todo_id, content, checked, position) VALUES (?, ?, ?, ?)`,
+		item.TodoID, item.Content, item.Checked, item.Position)
+	if err != nil {
+		return fmt.Errorf("failed to create checklist item: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get checklist item ID: %w", err)
+	}
+
+	item.ID = int(id)
+	return nil
+}
+
+// GetItem retrieves a checklist item of a todo
+func (r *ChecklistRepository) GetItem(id, todoID int) (*model.ChecklistItem, error) {
+	query := fmt.Sprintf(`SELECT %s FROM checklist_items WHERE id = ? AND todo_id = ?`, checklistColumns)
+	return scanChecklistItem(r.db.QueryRow(query, id, todoID))
+}
+
+// UpdateItem saves a checklist item's content, state and position
+func (r *ChecklistRepository) UpdateItem(item *model.ChecklistItem) error {
+	result, err := r.db.Exec(`UPDATE checklist_items SET content = ?, checked = ?, position = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND todo_id = ?`, item.Content, item.Checked, item.Position, item.ID, item.TodoID)
+	if err != nil {
+		return fmt.Errorf("failed to update checklist item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("checklist item not found")
+	}
+
+	return nil
+}
+
+// DeleteItem deletes a checklist item of a todo
+func (r *ChecklistRepository) DeleteItem(id, todoID int) error {
+	result, err := r.db.Exec(`DELETE FROM checklist_items WHERE id = ? AND todo_id = ?`, id, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete checklist item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("checklist item not found")
+	}
+
+	return nil
+}
+
+// GetItemsForTodos retrieves the checklists of several todos in order, keyed by todo ID
+func (r *ChecklistRepository) GetItemsForTodos(todoIDs []int) (map[int][]model.ChecklistItem, error) {
+	items := make(map[int][]model.ChecklistItem, len(todoIDs))
+	if len(todoIDs) == 0 {
+		return items, nil
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM checklist_items WHERE todo_id IN (%s) ORDER BY position, id`,
+		checklistColumns, placeholders(len(todoIDs)))
+	rows, err := r.db.Query(query, idArgs(todoIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query checklist items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := scanChecklistItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items[item.TodoID] = append(items[item.TodoID], *item)
+	}
+	return items, rows.Err()
+}
+
+// CountItems counts the checklist items of each given todo and how many of them are checked
+func (r *ChecklistRepository) CountItems(todoIDs []int) (map[int][2]int, error) {
+	counts := make(map[int][2]int, len(todoIDs))
+	if len(todoIDs) == 0 {
+		return counts, nil
+	}
+
+	query := fmt.Sprintf(`SELECT todo_id, COUNT(CASE WHEN checked = true THEN 1 END), COUNT(*) FROM checklist_items
+			  WHERE todo_id IN (%s) GROUP BY todo_id`, placeholders(len(todoIDs)))
+	rows, err := r.db.Query(query, idArgs(todoIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count checklist items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID, done, total int
+		if err := rows.Scan(&todoID, &done, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan checklist count: %w", err)
+		}
+		counts[todoID] = [2]int{done, total}
+	}
+	return counts, rows.Err()
+}
+
+func scanChecklistItem(row rowScanner) (*model.ChecklistItem, error) {
+	item := &model.ChecklistItem{}
+	err := row.Scan(&item.ID, &item.TodoID, &item.Content, &item.Checked, &item.Position, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist item: %w", err)
+	}
+	return item, nil
+}
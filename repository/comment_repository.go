@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+const commentSelect = `SELECT c.id, c.todo_id, c.user_id, u.username, c.parent_id, c.content, c.edited_at,
+			  c.created_at, c.updated_at FROM todo_comments c JOIN users u ON u.id = c.user_id`
+
+// CreateComment adds a comment to a todo
+func (r *CommentRepository) CreateComment(comment *model.Comment) error {
+	result, err := r.db.Exec(`INSERT INTO todo_comments (todo_id, user_id, parent_id, content) VALUES (?, ?, ?, ?)`,
+		comment.TodoID, comment.UserID, comment.ParentID, comment.Content)
+	if err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get comment ID: %w", err)
+	}
+
+	comment.ID = int(id)
+	return nil
+}
+
+// GetComment retrieves a comment on a todo
+func (r *CommentRepository) GetComment(id, todoID int) (*model.Comment, error) {
+	return scanComment(r.db.QueryRow(commentSelect+` WHERE c.id = ? AND c.todo_id = ?`, id, todoID))
+}
+
+// UpdateComment saves new content for a comment, keeping the previous content as a revision
+func (r *CommentRepository) UpdateComment(comment *model.Comment, previous string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO todo_comment_revisions (comment_id, content) VALUES (?, ?)`, comment.ID, previous); err != nil {
+		return fmt.Errorf("failed to save comment revision: %w", err)
+	}
+
+	result, err := tx.Exec(`UPDATE todo_comments SET content = ?, edited_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND todo_id = ?`, comment.Content, comment.ID, comment.TodoID)
+	if err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("comment not found")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit comment: %w", err)
+	}
+	return nil
+}
+
+// DeleteComment deletes a comment along with its replies and revisions
+func (r *CommentRepository) DeleteComment(id, todoID int) error {
+	result, err := r.db.Exec(`DELETE FROM todo_comments WHERE id = ? AND todo_id = ?`, id, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("comment not found")
+	}
+	return nil
+}
+
+// GetThreads retrieves a page of a todo's top-level comments, oldest first, and their total count
+func (r *CommentRepository) GetThreads(todoID, page, limit int) ([]model.Comment, int64, error) {
+	var total int64
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM todo_comments WHERE todo_id = ? AND parent_id IS NULL`, todoID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	query := commentSelect + ` WHERE c.todo_id = ? AND c.parent_id IS NULL ORDER BY c.created_at, c.id LIMIT ? OFFSET ?`
+	rows, err := r.db.Query(query, todoID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []model.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		comments = append(comments, *comment)
+	}
+	return comments, total, rows.Err()
+}
+
+// GetReplies retrieves the replies to several comments, oldest first, keyed by parent ID
+func (r *CommentRepository) GetReplies(parentIDs []int) (map[int][]model.Comment, error) {
+	replies := make(map[int][]model.Comment, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return replies, nil
+	}
+
+	query := fmt.Sprintf(commentSelect+` WHERE c.parent_id IN (%s) ORDER BY c.created_at, c.id`, placeholders(len(parentIDs)))
+	rows, err := r.db.Query(query, idArgs(parentIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comment replies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		reply, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		replies[*reply.ParentID] = append(replies[*reply.ParentID], *reply)
+	}
+	return replies, rows.Err()
+}
+
+// GetRevisions retrieves the earlier versions of a comment, newest first
+func (r *CommentRepository) GetRevisions(commentID int) ([]model.CommentRevision, error) {
+	rows, err := r.db.Query(`SELECT id, comment_id, content, created_at FROM todo_comment_revisions
+			  WHERE comment_id = ? ORDER BY created_at DESC, id DESC`, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comment revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []model.CommentRevision{}
+	for rows.Next() {
+		var revision model.CommentRevision
+		if err := rows.Scan(&revision.ID, &revision.CommentID, &revision.Content, &revision.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan comment revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// FindUsersWithAccess resolves usernames to the active users among them who own or are
+// assigned to a todo
+func (r *CommentRepository) FindUsersWithAccess(todoID int, usernames []string) ([]model.Mention, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	query := fmt.Sprintf(`SELECT u.id, u.username FROM users u JOIN todos t ON t.id = ? AND t.deleted_at IS NULL
+			  WHERE u.username IN (%s) AND u.is_active = true AND (
+			    u.id = t.user_id
+			    OR EXISTS (SELECT 1 FROM todo_assignees ta WHERE ta.todo_id = t.id AND ta.user_id = u.id)
+			  ) ORDER BY u.username`, placeholders(len(usernames)))
+	args := append([]interface{}{todoID}, stringArgs(usernames)...)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query mentioned users: %w", err)
+	}
+	defer rows.Close()
+
+	var mentions []model.Mention
+	for rows.Next() {
+		var mention model.Mention
+		if err := rows.Scan(&mention.UserID, &mention.Username); err != nil {
+			return nil, fmt.Errorf("failed to scan mentioned user: %w", err)
+		}
+		mentions = append(mentions, mention)
+	}
+	return mentions, rows.Err()
+}
+
+// SetMentions replaces the users mentioned in a comment and returns the ones it added
+func (r *CommentRepository) SetMentions(commentID int, userIDs []int) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT user_id FROM todo_comment_mentions WHERE comment_id = ? FOR UPDATE`, commentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comment mentions: %w", err)
+	}
+	current := map[int]bool{}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan comment mention: %w", err)
+		}
+		current[userID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query comment mentions: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM todo_comment_mentions WHERE comment_id = ?`, commentID); err != nil {
+		return nil, fmt.Errorf("failed to clear comment mentions: %w", err)
+	}
+	var added []int
+	for _, userID := range userIDs {
+		if _, err := tx.Exec(`INSERT IGNORE INTO todo_comment_mentions (comment_id, user_id) VALUES (?, ?)`, commentID, userID); err != nil {
+			return nil, fmt.Errorf("failed to save comment mention: %w", err)
+		}
+		if !current[userID] {
+			added = append(added, userID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit comment mentions: %w", err)
+	}
+	return added, nil
+}
+
+// GetMentions retrieves the users mentioned in several comments, keyed by comment ID
+func (r *CommentRepository) GetMentions(commentIDs []int) (map[int][]model.Mention, error) {
+	mentions := make(map[int][]model.Mention, len(commentIDs))
+	if len(commentIDs) == 0 {
+		return mentions, nil
+	}
+
+	query := fmt.Sprintf(`SELECT m.comment_id, u.id, u.username FROM todo_comment_mentions m
+			  JOIN users u ON u.id = m.user_id
+			  WHERE m.comment_id IN (%s) ORDER BY u.username`, placeholders(len(commentIDs)))
+	rows, err := r.db.Query(query, idArgs(commentIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comment mentions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID int
+		var mention model.Mention
+		if err := rows.Scan(&commentID, &mention.UserID, &mention.Username); err != nil {
+			return nil, fmt.Errorf("failed to scan comment mention: %w", err)
+		}
+		mentions[commentID] = append(mentions[commentID], mention)
+	}
+	return mentions, rows.Err()
+}
+
+// CountComments counts the comments, replies included, on several todos, keyed by todo ID
+func (r *CommentRepository) CountComments(todoIDs []int) (map[int]int, error) {
+	counts := make(map[int]int, len(todoIDs))
+	if len(todoIDs) == 0 {
+		return counts, nil
+	}
+
+	query := fmt.Sprintf(`SELECT todo_id, COUNT(*) FROM todo_comments WHERE todo_id IN (%s) GROUP BY todo_id`,
+		placeholders(len(todoIDs)))
+	rows, err := r.db.Query(query, idArgs(todoIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count comments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID, count int
+		if err := rows.Scan(&todoID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan comment count: %w", err)
+		}
+		counts[todoID] = count
+	}
+	return counts, rows.Err()
+}
+
+func scanComment(row rowScanner) (*model.Comment, error) {
+	comment := &model.Comment{}
+	var parentID sql.NullInt64
+	var editedAt sql.NullTime
+	err := row.Scan(&comment.ID, &comment.TodoID, &comment.UserID, &comment.Username, &parentID, &comment.Content,
+		&editedAt, &comment.CreatedAt, &comment.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	comment.ParentID = scanNullableID(parentID)
+	comment.EditedAt = scanNullableTime(editedAt)
+	return comment, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type DependencyRepository struct {
+	db *sql.DB
+}
+
+func NewDependencyRepository(db *sql.DB) *DependencyRepository {
+	return &DependencyRepository{db: db}
+}
+
+// AddDependency makes a todo of userID blocked by another of their todos. Nothing is added,
+// and the cycle is returned, when the dependency would close a cycle among the user's todos,
+// trashed ones included; changes by the same user are serialized on their user row. Adding an
+// existing dependency does nothing.
+func (r *DependencyRepository) AddDependency(userID int, dependency *model.Dependency) ([]int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked int
+	if err := tx.QueryRow(`SELECT id FROM users WHERE id = ? FOR UPDATE`, userID).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	rows, err := tx.Query(`SELECT d.todo_id, d.blocked_by_id FROM todo_dependencies d
+			  JOIN todos t ON t.id = d.todo_id WHERE t.user_id = ?`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todo dependencies: %w", err)
+	}
+	var edges []model.DependencyEdge
+	for rows.Next() {
+		var edge model.DependencyEdge
+		if err := rows.Scan(&edge.TodoID, &edge.BlockedByID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan todo dependency: %w", err)
+		}
+		edges = append(edges, edge)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query todo dependencies: %w", err)
+	}
+
+	if cycle := model.FindCycle(edges, dependency.TodoID, dependency.BlockedByID); cycle != nil {
+		return cycle, nil
+	}
+
+	_, err = tx.Exec(`INSERT IGNORE INTO todo_dependencies (todo_id, blocked_by_id, created_by) VALUES (?, ?, ?)`,
+		dependency.TodoID, dependency.BlockedByID, nullableID(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to add todo dependency: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit todo dependency: %w", err)
+	}
+	return nil, nil
+}
+
+// DeleteDependency removes a dependency of a todo owned by userID
+func (r *DependencyRepository) DeleteDependency(todoID, blockedByID, userID int) error {
+	result, err := r.db.Exec(`DELETE d FROM todo_dependencies d JOIN todos t ON t.id = d.todo_id
+			  WHERE d.todo_id = ? AND d.blocked_by_id = ? AND t.user_id = ?`, todoID, blockedByID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo dependency: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("dependency not found or access denied")
+	}
+	return nil
+}
+
+// GetBlockers retrieves the IDs of the todos blocking each of several todos, and which of
+// those todos have a blocker that is not completed yet. Blockers in the trash are left out.
+func (r *DependencyRepository) GetBlockers(todoIDs []int) (map[int][]int, map[int]bool, error) {
+	blockers := make(map[int][]int, len(todoIDs))
+	blocked := make(map[int]bool, len(todoIDs))
+	if len(todoIDs) == 0 {
+		return blockers, blocked, nil
+	}
+
+	query := fmt.Sprintf(`SELECT d.todo_id, d.blocked_by_id, b.completed FROM todo_dependencies d
+			  JOIN todos b ON b.id = d.blocked_by_id
+			  WHERE d.todo_id IN (%s) AND b.deleted_at IS NULL ORDER BY d.todo_id, d.blocked_by_id`,
+		placeholders(len(todoIDs)))
+
+	rows, err := r.db.Query(query, idArgs(todoIDs)...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query todo blockers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID, blockerID int
+		var completed bool
+		if err := rows.Scan(&todoID, &blockerID, &completed); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan todo blocker: %w", err)
+		}
+		blockers[todoID] = append(blockers[todoID], blockerID)
+		if !completed {
+			blocked[todoID] = true
+		}
+	}
+	return blockers, blocked, rows.Err()
+}
+
+// GetBlockingTodos retrieves the todos a todo blocks, leaving out those in the trash
+func (r *DependencyRepository) GetBlockingTodos(todoID int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todos WHERE deleted_at IS NULL
+			  AND id IN (SELECT todo_id FROM todo_dependencies WHERE blocked_by_id = ?) ORDER BY position, id`, todoColumns)
+	return r.queryTodos(query, todoID)
+}
+
+// GetBlockerTodos retrieves the todos blocking a todo, leaving out those in the trash
+func (r *DependencyRepository) GetBlockerTodos(todoID int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todos WHERE deleted_at IS NULL
+			  AND id IN (SELECT blocked_by_id FROM todo_dependencies WHERE todo_id = ?) ORDER BY position, id`, todoColumns)
+	return r.queryTodos(query, todoID)
+}
+
+func (r *DependencyRepository) queryTodos(query string, args ...interface{}) ([]model.Todo, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+
+	todos := []model.Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *todo)
+	}
+	return todos, rows.Err()
+}
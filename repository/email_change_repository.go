@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type EmailChangeRepository struct {
+	db *sql.DB
+}
+
+func NewEmailChangeRepository(db *sql.DB) *EmailChangeRepository {
+	return &EmailChangeRepository{db: db}
+}
+
+const emailChangeColumns = `id, user_id, old_email, new_email, confirm_token_hash, cancel_token_hash, status,
+	expires_at, cancel_expires_at, created_at, resolved_at`
+
+// CreateEmailChange stores a new pending email change, superseding any earlier pending one
+func (r *EmailChangeRepository) CreateEmailChange(change *model.EmailChange) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE email_changes SET status = ?, resolved_at = NOW() WHERE user_id = ? AND status = ?`,
+		model.EmailChangeSuperseded, change.UserID, model.EmailChangePending)
+	if err != nil {
+		return fmt.Errorf("failed to supersede email changes: %w", err)
+	}
+
+	query := `INSERT INTO email_changes (user_id, old_email, new_email, confirm_token_hash, cancel_token_hash, status, expires_at, cancel_expires_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.Exec(query, change.UserID, change.OldEmail, change.NewEmail, change.ConfirmTokenHash,
+		change.CancelTokenHash, model.EmailChangePending, change.ExpiresAt, change.CancelExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create email change: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get email change ID: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit email change: %w", err)
+	}
+
+	change.ID = int(id)
+	change.Status = model.EmailChangePending
+	return nil
+}
+
+// GetByConfirmTokenHash retrieves an email change by the hash of its confirmation token
+func (r *EmailChangeRepository) GetByConfirmTokenHash(hash string) (*model.EmailChange, error) {
+	query := fmt.Sprintf(`SELECT %s FROM email_changes WHERE confirm_token_hash = ?`, emailChangeColumns)
+	return scanEmailChange(r.db.QueryRow(query, hash))
+}
+
+// GetByCancelTokenHash retrieves an email change by the hash of its cancellation token
+func (r *EmailChangeRepository) GetByCancelTokenHash(hash string) (*model.EmailChange, error) {
+	query := fmt.Sprintf(`SELECT %s FROM email_changes WHERE cancel_token_hash = ?`, emailChangeColumns)
+	return scanEmailChange(r.db.QueryRow(query, hash))
+}
+
+// GetPendingEmailChange retrieves a user's unexpired pending email change
+func (r *EmailChangeRepository) GetPendingEmailChange(userID int) (*model.EmailChange, error) {
+	query := fmt.Sprintf(`SELECT %s FROM email_changes WHERE user_id = ? AND status = ? AND expires_at > NOW()
+			  ORDER BY id DESC LIMIT 1`, emailChangeColumns)
+	return scanEmailChange(r.db.QueryRow(query, userID, model.EmailChangePending))
+}
+
+// CancelEmailChange cancels a pending email change
+func (r *EmailChangeRepository) CancelEmailChange(id int) error {
+	query := `UPDATE email_changes SET status = ?, resolved_at = NOW() WHERE id = ? AND status = ?`
+	return r.execSingle(r.db, query, "email change is no longer pending", model.EmailChangeCancelled, id, model.EmailChangePending)
+}
+
+// ApplyEmailChange marks an unexpired pending change confirmed and updates the user's email atomically
+func (r *EmailChangeRepository) ApplyEmailChange(change *model.EmailChange) error {
+	return r.transition(change, model.EmailChangePending, model.EmailChangeConfirmed, "expires_at", change.NewEmail)
+}
+
+// RevertEmailChange restores the old email of a confirmed change while its cancel link is still valid
+func (r *EmailChangeRepository) RevertEmailChange(change *model.EmailChange) error {
+	return r.transition(change, model.EmailChangeConfirmed, model.EmailChangeReverted, "cancel_expires_at", change.OldEmail)
+}
+
+// transition moves a change between statuses and sets the user's email in one transaction
+func (r *EmailChangeRepository) transition(change *model.EmailChange, from, to, expiryColumn, email string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`UPDATE email_changes SET status = ?, resolved_at = NOW()
+			  WHERE id = ? AND status = ? AND %s > NOW()`, expiryColumn)
+	if err := r.execSingle(tx, query, "email change link is invalid or expired", to, change.ID, from); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET email = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`, email, change.UserID); err != nil {
+		return fmt.Errorf("failed to update email: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit email change: %w", err)
+	}
+	change.Status = to
+	return nil
+}
+
+// execSingle runs an update that must affect exactly one row
+func (r *EmailChangeRepository) execSingle(db execer, query, notFound string, args ...interface{}) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update email change: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return errors.New(notFound)
+	}
+	return nil
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func scanEmailChange(row rowScanner) (*model.EmailChange, error) {
+	change := &model.EmailChange{}
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(&change.ID, &change.UserID, &change.OldEmail, &change.NewEmail, &change.ConfirmTokenHash,
+		&change.CancelTokenHash, &change.Status, &change.ExpiresAt, &change.CancelExpiresAt, &change.CreatedAt, &resolvedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email change: %w", err)
+	}
+
+	if resolvedAt.Valid {
+		change.ResolvedAt = &resolvedAt.Time
+	}
+	return change, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type ExportRepository struct {
+	db *sql.DB
+}
+
+func NewExportRepository(db *sql.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+const exportJobColumns = `id, user_id, status, file_path, error, created_at, completed_at, expires_at`
+
+// CreateJob creates a pending export job
+func (r *ExportRepository) CreateJob(job *model.ExportJob) error {
+	query := `INSERT INTO export_jobs (user_id, status) VALUES (?, ?)`
+	result, err := r.db.Exec(query, job.UserID, job.Status)
+	if err != nil {
+		return fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get export job ID: %w", err)
+	}
+
+	job.ID = int(id)
+	job.CreatedAt = time.Now()
+	return nil
+}
+
+// UpdateJob stores the status and result of an export job
+func (r *ExportRepository) UpdateJob(job *model.ExportJob) error {
+	query := `UPDATE export_jobs SET status = ?, file_path = ?, error = ?, completed_at = ?, expires_at = ? WHERE id = ?`
+	_, err := r.db.Exec(query, job.Status, job.FilePath, job.Error, job.CompletedAt, job.ExpiresAt, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+	return nil
+}
+
+// GetLatestJob retrieves the most recent export job of a user
+func (r *ExportRepository) GetLatestJob(userID int) (*model.ExportJob, error) {
+	query := fmt.Sprintf(`SELECT %s FROM export_jobs WHERE user_id = ? ORDER BY id DESC LIMIT 1`, exportJobColumns)
+	return scanExportJob(r.db.QueryRow(query, userID))
+}
+
+// GetJob retrieves an export job owned by a user
+func (r *ExportRepository) GetJob(id, userID int) (*model.ExportJob, error) {
+	query := fmt.Sprintf(`SELECT %s FROM export_jobs WHERE id = ? AND user_id = ?`, exportJobColumns)
+	return scanExportJob(r.db.QueryRow(query, id, userID))
+}
+
+// GetExpiredJobs retrieves finished export jobs whose archives have expired
+func (r *ExportRepository) GetExpiredJobs(now time.Time) ([]model.ExportJob, error) {
+	query := fmt.Sprintf(`SELECT %s FROM export_jobs WHERE expires_at IS NOT NULL AND expires_at <= ?`, exportJobColumns)
+	rows, err := r.db.Query(query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expired export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []model.ExportJob
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// GetUserJobs retrieves every export job of a user
+func (r *ExportRepository) GetUserJobs(userID int) ([]model.ExportJob, error) {
+	query := fmt.Sprintf(`SELECT %s FROM export_jobs WHERE user_id = ?`, exportJobColumns)
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []model.ExportJob
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// DeleteJob removes an export job record
+func (r *ExportRepository) DeleteJob(id int) error {
+	_, err := r.db.Exec(`DELETE FROM export_jobs WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete export job: %w", err)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanExportJob(row rowScanner) (*model.ExportJob, error) {
+	job := &model.ExportJob{}
+	var filePath, jobError sql.NullString
+	var completedAt, expiresAt sql.NullTime
+
+	err := row.Scan(&job.ID, &job.UserID, &job.Status, &filePath, &jobError, &job.CreatedAt, &completedAt, &expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+
+	job.FilePath = filePath.String
+	job.Error = jobError.String
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		job.ExpiresAt = &expiresAt.Time
+	}
+	return job, nil
+}
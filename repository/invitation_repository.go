@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type InvitationRepository struct {
+	db *sql.DB
+}
+
+func NewInvitationRepository(db *sql.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+const invitationColumns = `id, email, role_id, organization_id, created_by, expires_at, used_at, used_by, revoked_at, created_at`
+
+// CreateInvitation creates a new invitation
+func (r *InvitationRepository) CreateInvitation(inv *model.Invitation) error {
+	query := `INSERT INTO invitations (email, role_id, organization_id, created_by, expires_at) VALUES (?, ?, ?, ?, ?)`
+	var email interface{}
+	if inv.Email != "" {
+		email = inv.Email
+	}
+
+	result, err := r.db.Exec(query, email, inv.RoleID, inv.OrganizationID, inv.CreatedBy, inv.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get invitation ID: %w", err)
+	}
+
+	inv.ID = int(id)
+	return nil
+}
+
+// GetInvitationByID retrieves an invitation by ID
+func (r *InvitationRepository) GetInvitationByID(id int) (*model.Invitation, error) {
+	query := fmt.Sprintf(`SELECT %s FROM invitations WHERE id = ?`, invitationColumns)
+	return scanInvitation(r.db.QueryRow(query, id))
+}
+
+// ListInvitations retrieves invitations, newest first; unused and unrevoked ones only unless includeInactive
+func (r *InvitationRepository) ListInvitations(includeInactive bool) ([]model.Invitation, error) {
+	whereClause := "WHERE used_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()"
+	if includeInactive {
+		whereClause = ""
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM invitations %s ORDER BY id DESC`, invitationColumns, whereClause)
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query invitations: %w", err)
+	}
+	defer rows.Close()
+
+	var invitations []model.Invitation
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+	return invitations, rows.Err()
+}
+
+// RevokeInvitation revokes an unused invitation
+func (r *InvitationRepository) RevokeInvitation(id int) error {
+	query := `UPDATE invitations SET revoked_at = NOW() WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL`
+	return r.execSingle(query, "invitation not found or already used", id)
+}
+
+// execSingle runs an update that must affect exactly one row
+func (r *InvitationRepository) execSingle(query, notFound string, args ...interface{}) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update invitation: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return errors.New(notFound)
+	}
+	return nil
+}
+
+func scanInvitation(row rowScanner) (*model.Invitation, error) {
+	inv := &model.Invitation{}
+	var email sql.NullString
+	var orgID, createdBy, usedBy sql.NullInt64
+	var usedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&inv.ID, &email, &inv.RoleID, &orgID, &createdBy, &inv.ExpiresAt, &usedAt, &usedBy, &revokedAt, &inv.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	inv.Email = email.String
+	inv.OrganizationID = scanNullableID(orgID)
+	inv.CreatedBy = scanNullableID(createdBy)
+	inv.UsedBy = scanNullableID(usedBy)
+	if usedAt.Valid {
+		inv.UsedAt = &usedAt.Time
+	}
+	if revokedAt.Valid {
+		inv.RevokedAt = &revokedAt.Time
+	}
+	return inv, nil
+}
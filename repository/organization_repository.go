@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type OrganizationRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// CreateOrganization creates a new organization
+func (r *OrganizationRepository) CreateOrganization(org *model.Organization) error {
+	result, err := r.db.Exec(`INSERT INTO organizations (name) VALUES (?)`, org.Name)
+	if err != nil {
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get organization ID: %w", err)
+	}
+
+	org.ID = int(id)
+	return nil
+}
+
+// GetOrganizationByID retrieves an organization by ID
+func (r *OrganizationRepository) GetOrganizationByID(id int) (*model.Organization, error) {
+	org := &model.Organization{}
+	err := r.db.QueryRow(`SELECT id, name, created_at FROM organizations WHERE id = ?`, id).Scan(
+		&org.ID, &org.Name, &org.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// ListOrganizations retrieves all organizations
+func (r *OrganizationRepository) ListOrganizations() ([]model.Organization, error) {
+	rows, err := r.db.Query(`SELECT id, name, created_at FROM organizations ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query organizations: %w", err)
+	}
+	defer rows.Close()
+
+	var orgs []model.Organization
+	for rows.Next() {
+		var org model.Organization
+		if err := rows.Scan(&org.ID, &org.Name, &org.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
+// AddMember adds a user to an organization
+func (r *OrganizationRepository) AddMember(orgID, userID int) error {
+	query := `INSERT IGNORE INTO organization_members (organization_id, user_id) VALUES (?, ?)`
+	if _, err := r.db.Exec(query, orgID, userID); err != nil {
+		return fmt.Errorf("failed to add organization member: %w", err)
+	}
+	return nil
+}
+
+// IsMember reports whether a user belongs to an organization
+func (r *OrganizationRepository) IsMember(orgID, userID int) (bool, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM organization_members WHERE organization_id = ? AND user_id = ?`,
+		orgID, userID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check organization membership: %w", err)
+	}
+	return count > 0, nil
+}
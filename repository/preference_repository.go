@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type PreferenceRepository struct {
+	db *sql.DB
+}
+
+func NewPreferenceRepository(db *sql.DB) *PreferenceRepository {
+	return &PreferenceRepository{db: db}
+}
+
+// GetPreferences retrieves a user's stored preferences; sql.ErrNoRows is wrapped when none are stored
+func (r *PreferenceRepository) GetPreferences(userID int) (*model.UserPreferences, error) {
+	prefs := &model.UserPreferences{}
+	query := `SELECT user_id, display_name, timezone, locale, date_format, default_sort, default_order, default_filter,
+			  default_limit, email_notifications, reminder_notifications, assignment_notifications, mention_notifications,
+			  auto_archive_days, enforce_dependencies, updated_at
+			  FROM user_preferences WHERE user_id = ?`
+
+	err := r.db.QueryRow(query, userID).Scan(
+		&prefs.UserID, &prefs.DisplayName, &prefs.Timezone, &prefs.Locale, &prefs.DateFormat,
+		&prefs.DefaultSort, &prefs.DefaultOrder, &prefs.DefaultFilter, &prefs.DefaultLimit,
+		&prefs.EmailNotifications, &prefs.ReminderNotifications, &prefs.AssignmentNotifications,
+		&prefs.MentionNotifications, &prefs.AutoArchiveDays, &prefs.EnforceDependencies,
+		&prefs.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// SavePreferences creates or replaces a user's preferences
+func (r *PreferenceRepository) SavePreferences(prefs *model.UserPreferences) error {
+	query := `INSERT INTO user_preferences (user_id, display_name, timezone, locale, date_format, default_sort, default_order,
+			  default_filter, default_limit, email_notifications, reminder_notifications, assignment_notifications, mention_notifications,
+			  auto_archive_days, enforce_dependencies)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON DUPLICATE KEY UPDATE display_name = VALUES(display_name), timezone = VALUES(timezone), locale = VALUES(locale),
+			  date_format = VALUES(date_format), default_sort = VALUES(default_sort), default_order = VALUES(default_order),
+			  default_filter = VALUES(default_filter), default_limit = VALUES(default_limit),
+			  email_notifications = VALUES(email_notifications), reminder_notifications = VALUES(reminder_notifications),
+			  assignment_notifications = VALUES(assignment_notifications), mention_notifications = VALUES(mention_notifications),
+			  auto_archive_days = VALUES(auto_archive_days), enforce_dependencies = VALUES(enforce_dependencies)`
+
+	_, err := r.db.Exec(query, prefs.UserID, prefs.DisplayName, prefs.Timezone, prefs.Locale, prefs.DateFormat,
+		prefs.DefaultSort, prefs.DefaultOrder, prefs.DefaultFilter, prefs.DefaultLimit,
+		prefs.EmailNotifications, prefs.ReminderNotifications, prefs.AssignmentNotifications, prefs.MentionNotifications,
+		prefs.AutoArchiveDays, prefs.EnforceDependencies)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type ProjectRepository struct {
+	db *sql.DB
+}
+
+func NewProjectRepository(db *sql.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+// projectSelect selects projects with their pending and completed todo counts, leaving out
+// archived todos
+const projectSelect = `SELECT p.id, p.user_id, p.name, COALESCE(p.description, ''), p.color, p.archived, p.position,
+	p.created_at, p.updated_at,
+	COUNT(CASE WHEN t.completed = false THEN 1 END), COUNT(CASE WHEN t.completed = true THEN 1 END)
+	FROM projects p LEFT JOIN todos t ON t.project_id = p.id AND t.archived_at IS NULL
+	AND t.deleted_at IS NULL`
+
+// CreateProject creates a new project; a negative position appends it after the user's other projects
+func (r *ProjectRepository) CreateProject(project *model.Project) error {
+	if project.Position < 0 {
+		err := r.db.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM projects WHERE user_id = ?`, project.UserID).
+			Scan(&project.Position)
+		if err != nil {
+			return fmt.Errorf("failed to get project position: %w", err)
+		}
+	}
+
+	result, err := insertProject(r.db, project)
+	if err != nil {
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get project ID: %w", err)
+	}
+
+	project.ID = int(id)
+	return nil
+}
+
+// GetProjectByID retrieves a project with its todo counts
+func (r *ProjectRepository) GetProjectByID(id int) (*model.Project, error) {
+	return scanProject(r.db.QueryRow(projectSelect+` WHERE p.id = ? GROUP BY p.id`, id))
+}
+
+// ListProjects retrieves a user's projects in position order, optionally including archived ones
+func (r *ProjectRepository) ListProjects(userID int, includeArchived bool) ([]model.Project, error) {
+	query := projectSelect + ` WHERE p.user_id = ?`
+	if !includeArchived {
+		query += ` AND p.archived = false`
+	}
+	query += ` GROUP BY p.id ORDER BY p.position, p.id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []model.Project{}
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+	return projects, rows.Err()
+}
+
+// UpdateProject saves a project's editable fields
+func (r *ProjectRepository) UpdateProject(project *model.Project) error {
+	query := `UPDATE projects SET name = ?, description = ?, color = ?, archived = ?, position = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND user_id = ?`
+
+	result, err := r.db.Exec(query, project.Name, project.Description, project.Color, project.Archived, project.Position,
+		project.ID, project.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("project not found or access denied")
+	}
+
+	return nil
+}
+
+// DeleteProject deletes a project; its todos are kept without a project
+func (r *ProjectRepository) DeleteProject(id, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE todos SET project_id = NULL WHERE project_id = ? AND user_id = ?`, id, userID); err != nil {
+		return fmt.Errorf("failed to detach project todos: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM projects WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("project not found or access denied")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit project deletion: %w", err)
+	}
+	return nil
+}
+
+func scanProject(row rowScanner) (*model.Project, error) {
+	project := &model.Project{}
+	err := row.Scan(&project.ID, &project.UserID, &project.Name, &project.Description, &project.Color, &project.Archived,
+		&project.Position, &project.CreatedAt, &project.UpdatedAt, &project.PendingCount, &project.CompletedCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+	return project, nil
+}
+
+// insertProject inserts a new project row
+func insertProject(db execer, project *model.Project) (sql.Result, error) {
+	query := `INSERT INTO projects (user_id, name, description, color, archived, position) VALUES (?, ?, ?, ?, ?, ?)`
+	return db.Exec(query, project.UserID, project.Name, project.Description, project.Color, project.Archived, project.Position)
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type RevisionRepository struct {
+	db *sql.DB
+}
+
+func NewRevisionRepository(db *sql.DB) *RevisionRepository {
+	return &RevisionRepository{db: db}
+}
+
+const revisionSelect = `SELECT r.id, r.todo_id, r.version, r.actor_id, u.username, r.restored_from, r.changes, r.snapshot,
+			  r.created_at FROM todo_revisions r LEFT JOIN users u ON u.id = r.actor_id`
+
+// AddRevision records the next version of a todo. A todo's first revision is preceded by a
+// baseline version holding its state before the change, dated baselineAt. Versions beyond the
+// newest keep are pruned.
+func (r *RevisionRepository) AddRevision(revision *model.TodoRevision, baseline model.TodoSnapshot, baselineAt time.Time, keep int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Concurrent edits of the same todo take versions one after the other
+	var locked int
+	if err := tx.QueryRow(`SELECT id FROM todos WHERE id = ? FOR UPDATE`, revision.TodoID).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to lock todo: %w", err)
+	}
+	var latest int
+	err = tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM todo_revisions WHERE todo_id = ?`, revision.TodoID).Scan(&latest)
+	if err != nil {
+		return fmt.Errorf("failed to get latest revision: %w", err)
+	}
+
+	if latest == 0 {
+		first := &model.TodoRevision{TodoID: revision.TodoID, Version: 1, Changes: []model.FieldChange{},
+			Snapshot: baseline, CreatedAt: baselineAt}
+		if err := insertRevision(tx, first); err != nil {
+			return err
+		}
+		latest = 1
+	}
+
+	revision.Version = latest + 1
+	revision.CreatedAt = time.Now()
+	if err := insertRevision(tx, revision); err != nil {
+		return err
+	}
+
+	if keep > 0 {
+		if _, err := tx.Exec(`DELETE FROM todo_revisions WHERE todo_id = ? AND version <= ?`,
+			revision.TodoID, revision.Version-keep); err != nil {
+			return fmt.Errorf("failed to prune todo revisions: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit todo revision: %w", err)
+	}
+	return nil
+}
+
+// GetRevisions retrieves the kept versions of a todo, newest first
+func (r *RevisionRepository) GetRevisions(todoID int) ([]model.TodoRevision, error) {
+	rows, err := r.db.Query(revisionSelect+` WHERE r.todo_id = ? ORDER BY r.version DESC`, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todo revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []model.TodoRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+	return revisions, rows.Err()
+}
+
+// GetRevision retrieves one version of a todo
+func (r *RevisionRepository) GetRevision(todoID, version int) (*model.TodoRevision, error) {
+	return scanRevision(r.db.QueryRow(revisionSelect+` WHERE r.todo_id = ? AND r.version = ?`, todoID, version))
+}
+
+func insertRevision(tx *sql.Tx, revision *model.TodoRevision) error {
+	changes, err := json.Marshal(revision.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode revision changes: %w", err)
+	}
+	snapshot, err := json.Marshal(revision.Snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode revision snapshot: %w", err)
+	}
+
+	result, err := tx.Exec(`INSERT INTO todo_revisions (todo_id, version, actor_id, restored_from, changes, snapshot, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`, revision.TodoID, revision.Version, revision.ActorID, revision.RestoredFrom,
+		string(changes), string(snapshot), revision.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create todo revision: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get todo revision ID: %w", err)
+	}
+	revision.ID = int(id)
+	return nil
+}
+
+func scanRevision(row rowScanner) (*model.TodoRevision, error) {
+	revision := &model.TodoRevision{}
+	var actorID, restoredFrom sql.NullInt64
+	var actor sql.NullString
+	var changes, snapshot string
+	err := row.Scan(&revision.ID, &revision.TodoID, &revision.Version, &actorID, &actor, &restoredFrom, &changes,
+		&snapshot, &revision.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo revision: %w", err)
+	}
+	revision.ActorID = scanNullableID(actorID)
+	revision.Actor = actor.String
+	revision.RestoredFrom = scanNullableID(restoredFrom)
+
+	if err := json.Unmarshal([]byte(changes), &revision.Changes); err != nil {
+		return nil, fmt.Errorf("failed to decode revision changes: %w", err)
+	}
+	if err := json.Unmarshal([]byte(snapshot), &revision.Snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode revision snapshot: %w", err)
+	}
+	return revision, nil
+}
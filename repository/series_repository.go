@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type SeriesRepository struct {
+	db *sql.DB
+}
+
+func NewSeriesRepository(db *sql.DB) *SeriesRepository {
+	return &SeriesRepository{db: db}
+}
+
+const seriesColumns = `id, user_id, rule, starts_at, timezone, exceptions, title, content, remind_offset_seconds, created_at, updated_at`
+
+// CreateSeries creates a new recurring todo series
+func (r *SeriesRepository) CreateSeries(series *model.TodoSeries) error {
+	return insertSeries(r.db, series)
+}
+
+// insertSeries inserts a new series row and fills in its ID
+func insertSeries(db execer, series *model.TodoSeries) error {
+	query := `INSERT INTO todo_series (user_id, rule, starts_at, timezone, exceptions, title, content, remind_offset_seconds)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := db.Exec(query, series.UserID, series.Rule, series.StartsAt.UTC(), series.Timezone,
+		joinExceptions(series.Exceptions), series.Title, series.Content, series.RemindOffset)
+	if err != nil {
+		return fmt.Errorf("failed to create series: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get series ID: %w", err)
+	}
+
+	series.ID = int(id)
+	return nil
+}
+
+// GetSeries retrieves a series by ID
+func (r *SeriesRepository) GetSeries(id int) (*model.TodoSeries, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todo_series WHERE id = ?`, seriesColumns)
+	return scanSeries(r.db.QueryRow(query, id))
+}
+
+// UpdateSeries saves a series' rule, schedule and template
+func (r *SeriesRepository) UpdateSeries(series *model.TodoSeries) error {
+	query := `UPDATE todo_series SET rule = ?, starts_at = ?, timezone = ?, exceptions = ?, title = ?, content = ?,
+			  remind_offset_seconds = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND user_id = ?`
+
+	result, err := r.db.Exec(query, series.Rule, series.StartsAt.UTC(), series.Timezone, joinExceptions(series.Exceptions),
+		series.Title, series.Content, series.RemindOffset, series.ID, series.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update series: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("series not found or access denied")
+	}
+
+	return nil
+}
+
+// DeleteSeries deletes a series and moves its open occurrences to the trash; completed
+// occurrences are kept as plain todos
+func (r *SeriesRepository) DeleteSeries(id, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE todos SET deleted_at = ? WHERE series_id = ? AND user_id = ? AND completed = false
+			  AND deleted_at IS NULL`, time.Now().UTC(), id, userID); err != nil {
+		return fmt.Errorf("failed to delete occurrences: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE todos SET series_id = NULL WHERE series_id = ? AND user_id = ?`, id, userID); err != nil {
+		return fmt.Errorf("failed to detach occurrences: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM todo_series WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete series: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("series not found or access denied")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit series deletion: %w", err)
+	}
+	return nil
+}
+
+func scanSeries(row rowScanner) (*model.TodoSeries, error) {
+	series := &model.TodoSeries{}
+	var exceptions, content sql.NullString
+	var remindOffset sql.NullInt64
+
+	err := row.Scan(&series.ID, &series.UserID, &series.Rule, &series.StartsAt, &series.Timezone, &exceptions,
+		&series.Title, &content, &remindOffset, &series.CreatedAt, &series.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+
+	series.Exceptions = splitList(exceptions.String)
+	series.Content = content.String
+	if remindOffset.Valid {
+		offset := int(remindOffset.Int64)
+		series.RemindOffset = &offset
+	}
+	return series, nil
+}
+
+// joinExceptions stores exception dates as a comma-separated list
+func joinExceptions(dates []string) string {
+	return strings.Join(dates, ",")
+}
+
+// splitList parses a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	dates := []string{}
+	for _, date := range strings.Split(value, ",") {
+		if date = strings.TrimSpace(date); date != "" {
+			dates = append(dates, date)
+		}
+	}
+	return dates
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type SettingsRepository struct {
+	db *sql.DB
+}
+
+func NewSettingsRepository(db *sql.DB) *SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// GetSetting retrieves a setting value; sql.ErrNoRows is wrapped when it has never been set
+func (r *SettingsRepository) GetSetting(name string) (string, error) {
+	var value string
+	err := r.db.QueryRow(`SELECT value FROM app_settings WHERE name = ?`, name).Scan(&value)
+	if err != nil {
+		return "", fmt.Errorf("failed to get setting %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// SetSetting creates or replaces a setting value
+func (r *SettingsRepository) SetSetting(name, value string, updatedBy int) error {
+	query := `INSERT INTO app_settings (name, value, updated_by) VALUES (?, ?, ?)
+			  ON DUPLICATE KEY UPDATE value = VALUES(value), updated_by = VALUES(updated_by)`
+	_, err := r.db.Exec(query, name, value, nullableID(updatedBy))
+	if err != nil {
+		return fmt.Errorf("failed to set setting %s: %w", name, err)
+	}
+	return nil
+}
+
+// nullableID maps a zero ID to NULL
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// scanNullableID converts a nullable column into an optional ID
+func scanNullableID(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
+
+// scanNullableTime converts a nullable column into an optional time
+func scanNullableTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+// nullableString maps an empty string to NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type TagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+const tagColumns = `t.id, t.user_id, t.name, t.color, t.created_at, t.updated_at`
+
+// CreateTag creates a new tag
+func (r *TagRepository) CreateTag(tag *model.Tag) error {
+	result, err := r.db.Exec(`INSERT INTO tags (user_id, name, color) VALUES (?, ?, ?)`, tag.UserID, tag.Name, tag.Color)
+	if err != nil {
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get tag ID: %w", err)
+	}
+
+	tag.ID = int(id)
+	return nil
+}
+
+// GetTagByID retrieves a tag by ID
+func (r *TagRepository) GetTagByID(id int) (*model.Tag, error) {
+	query := fmt.Sprintf(`SELECT %s FROM tags t WHERE t.id = ?`, tagColumns)
+	return scanTag(r.db.QueryRow(query, id))
+}
+
+// GetTagByName retrieves a user's tag by name
+func (r *TagRepository) GetTagByName(userID int, name string) (*model.Tag, error) {
+	query := fmt.Sprintf(`SELECT %s FROM tags t WHERE t.user_id = ? AND t.name = ?`, tagColumns)
+	return scanTag(r.db.QueryRow(query, userID, name))
+}
+
+// ListTags retrieves a user's tags by name, with the number of todos using each
+func (r *TagRepository) ListTags(userID int) ([]model.Tag, error) {
+	query := fmt.Sprintf(`SELECT %s, COUNT(tt.todo_id) FROM tags t
+			  LEFT JOIN todo_tags tt ON tt.tag_id = t.id
+			  WHERE t.user_id = ? GROUP BY t.id ORDER BY t.name`, tagColumns)
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []model.Tag{}
+	for rows.Next() {
+		var tag model.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt, &tag.UsageCount); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// UpdateTag saves a tag's name and color
+func (r *TagRepository) UpdateTag(tag *model.Tag) error {
+	result, err := r.db.Exec(`UPDATE tags SET name = ?, color = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ?`,
+		tag.Name, tag.Color, tag.ID, tag.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("tag not found or access denied")
+	}
+
+	return nil
+}
+
+// DeleteTag deletes a tag; it is detached from its todos
+func (r *TagRepository) DeleteTag(id, userID int) error {
+	result, err := r.db.Exec(`DELETE FROM tags WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("tag not found or access denied")
+	}
+
+	return nil
+}
+
+// CountUserTags counts how many of the given tag IDs belong to a user
+func (r *TagRepository) CountUserTags(userID int, tagIDs []int) (int, error) {
+	if len(tagIDs) == 0 {
+		return 0, nil
+	}
+
+	args := []interface{}{userID}
+	for _, id := range tagIDs {
+		args = append(args, id)
+	}
+
+	var count int
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM tags WHERE user_id = ? AND id IN (%s)`, placeholders(len(tagIDs)))
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count tags: %w", err)
+	}
+	return count, nil
+}
+
+// setTodoTags replaces the tags attached to a todo within tx
+func setTodoTags(tx *sql.Tx, todoID int, tagIDs []int) error {
+	if _, err := tx.Exec(`DELETE FROM todo_tags WHERE todo_id = ?`, todoID); err != nil {
+		return fmt.Errorf("failed to clear todo tags: %w", err)
+	}
+
+	for _, tagID := range tagIDs {
+		if _, err := tx.Exec(`INSERT IGNORE INTO todo_tags (todo_id, tag_id) VALUES (?, ?)`, todoID, tagID); err != nil {
+			return fmt.Errorf("failed to attach tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetTagsForTodos retrieves the tags of several todos, keyed by todo ID
+func (r *TagRepository) GetTagsForTodos(todoIDs []int) (map[int][]model.Tag, error) {
+	tags := make(map[int][]model.Tag, len(todoIDs))
+	if len(todoIDs) == 0 {
+		return tags, nil
+	}
+
+	query := fmt.Sprintf(`SELECT tt.todo_id, %s FROM todo_tags tt
+			  JOIN tags t ON t.id = tt.tag_id
+			  WHERE tt.todo_id IN (%s) ORDER BY t.name`, tagColumns, placeholders(len(todoIDs)))
+
+	rows, err := r.db.Query(query, idArgs(todoIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todo tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var todoID int
+		var tag model.Tag
+		if err := rows.Scan(&todoID, &tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan todo tag: %w", err)
+		}
+		tags[todoID] = append(tags[todoID], tag)
+	}
+	return tags, rows.Err()
+}
+
+func scanTag(row rowScanner) (*model.Tag, error) {
+	tag := &model.Tag{}
+	if err := row.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+	return tag, nil
+}
+
+// placeholders returns n comma-separated query placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// idArgs converts IDs into query arguments
+func idArgs(ids []int) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+// stringArgs converts strings into query arguments
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type TemplateRepository struct {
+	db *sql.DB
+}
+
+func NewTemplateRepository(db *sql.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+const templateColumns = `id, user_id, organization_id, name, COALESCE(description, ''), project_name, items, created_at, updated_at`
+
+// CreateTemplate creates a new template
+func (r *TemplateRepository) CreateTemplate(template *model.Template) error {
+	items, err := json.Marshal(template.Items)
+	if err != nil {
+		return fmt.Errorf("failed to encode template items: %w", err)
+	}
+
+	result, err := r.db.Exec(`INSERT INTO todo_templates (user_id, organization_id, name, description, project_name, items)
+			  VALUES (?, ?, ?, ?, ?, ?)`, template.UserID, template.OrganizationID, template.Name, template.Description,
+		template.ProjectName, string(items))
+	if err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get template ID: %w", err)
+	}
+
+	template.ID = int(id)
+	return nil
+}
+
+// GetTemplate retrieves a template by ID
+func (r *TemplateRepository) GetTemplate(id int) (*model.Template, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todo_templates WHERE id = ?`, templateColumns)
+	return scanTemplate(r.db.QueryRow(query, id))
+}
+
+// ListTemplates retrieves the templates a user created or shares through an organization, by name
+func (r *TemplateRepository) ListTemplates(userID int) ([]model.Template, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todo_templates
+			  WHERE user_id = ? OR organization_id IN (SELECT organization_id FROM organization_members WHERE user_id = ?)
+			  ORDER BY name, id`, templateColumns)
+
+	rows, err := r.db.Query(query, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []model.Template{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+	return templates, rows.Err()
+}
+
+// UpdateTemplate updates a template owned by its creator
+func (r *TemplateRepository) UpdateTemplate(template *model.Template) error {
+	items, err := json.Marshal(template.Items)
+	if err != nil {
+		return fmt.Errorf("failed to encode template items: %w", err)
+	}
+
+	result, err := r.db.Exec(`UPDATE todo_templates SET name = ?, description = ?, project_name = ?, items = ?
+			  WHERE id = ? AND user_id = ?`, template.Name, template.Description, template.ProjectName, string(items),
+		template.ID, template.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("template not found or access denied")
+	}
+	return nil
+}
+
+// DeleteTemplate deletes a template owned by userID
+func (r *TemplateRepository) DeleteTemplate(id, userID int) error {
+	result, err := r.db.Exec(`DELETE FROM todo_templates WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("template not found or access denied")
+	}
+	return nil
+}
+
+// Instantiate saves a planned template instance in one transaction: its project, any of its
+// tags the user does not have yet, and its todos with their tags, ranked after the user's last
+// todo like any new todo. IDs are filled in on the plan's project and todos.
+func (r *TemplateRepository) Instantiate(plan *model.TemplatePlan) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(tx, plan.UserID); err != nil {
+		return err
+	}
+
+	if plan.Project != nil {
+		err := tx.QueryRow(`SELECT COALESCE(MAX(position) + 1, 0) FROM projects WHERE user_id = ?`, plan.UserID).
+			Scan(&plan.Project.Position)
+		if err != nil {
+			return fmt.Errorf("failed to get project position: %w", err)
+		}
+		result, err := insertProject(tx, plan.Project)
+		if err != nil {
+			return fmt.Errorf("failed to create project: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get project ID: %w", err)
+		}
+		plan.Project.ID = int(id)
+		for _, todo := range plan.Todos {
+			todo.ProjectID = &plan.Project.ID
+		}
+	}
+
+	tagIDs := map[string]int{}
+	for i, todo := range plan.Todos {
+		if parent := plan.Parents[i]; parent >= 0 {
+			todo.ParentID = &plan.Todos[parent].ID
+		}
+
+		write := &model.TodoWrite{Todo: todo}
+		if len(plan.Tags[i]) > 0 {
+			ids := make([]int, 0, len(plan.Tags[i]))
+			for _, name := range plan.Tags[i] {
+				tagID, ok := tagIDs[name]
+				if !ok {
+					if tagID, err = ensureTag(tx, plan.UserID, name); err != nil {
+						return err
+					}
+					tagIDs[name] = tagID
+				}
+				ids = append(ids, tagID)
+			}
+			write.Links = &model.TodoLinks{TagIDs: &ids}
+		}
+		if err := saveTodo(tx, write); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit template instance: %w", err)
+	}
+	return nil
+}
+
+// ensureTag returns the ID of a user's tag by name, creating the tag when it is missing
+func ensureTag(tx *sql.Tx, userID int, name string) (int, error) {
+	result, err := tx.Exec(`INSERT INTO tags (user_id, name, color) VALUES (?, ?, ?)
+			  ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`, userID, name, model.DefaultTagColor)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create tag: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get tag ID: %w", err)
+	}
+	return int(id), nil
+}
+
+func scanTemplate(row rowScanner) (*model.Template, error) {
+	template := &model.Template{}
+	var organizationID sql.NullInt64
+	var items string
+	err := row.Scan(&template.ID, &template.UserID, &organizationID, &template.Name, &template.Description,
+		&template.ProjectName, &items, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	template.OrganizationID = scanNullableID(organizationID)
+
+	if err := json.Unmarshal([]byte(items), &template.Items); err != nil {
+		return nil, fmt.Errorf("failed to decode template items: %w", err)
+	}
+	return template, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type TimeRepository struct {
+	db *sql.DB
+}
+
+func NewTimeRepository(db *sql.DB) *TimeRepository {
+	return &TimeRepository{db: db}
+}
+
+// timeEntrySelect selects time entries along with their todo, project and user
+const timeEntrySelect = `SELECT e.id, e.todo_id, t.title, t.project_id, COALESCE(p.name, ''), e.user_id, u.username,
+			  e.started_at, e.ended_at, e.note, e.created_at, e.updated_at
+			  FROM time_entries e
+			  JOIN todos t ON t.id = e.todo_id
+			  JOIN users u ON u.id = e.user_id
+			  LEFT JOIN projects p ON p.id = t.project_id`
+
+// StartTimer starts a timer for entry.UserID on entry.TodoID at entry.StartedAt, stopping any
+// other timer of the user at that time; timers of the same user are serialized on their user
+// row. When the user's timer already runs on the todo, entry.ID is set to it and false is
+// returned.
+func (r *TimeRepository) StartTimer(entry *model.TimeEntry) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(tx, entry.UserID); err != nil {
+		return false, err
+	}
+
+	var runningID, runningTodoID int
+	err = tx.QueryRow(`SELECT id, todo_id FROM time_entries WHERE user_id = ? AND ended_at IS NULL`, entry.UserID).
+		Scan(&runningID, &runningTodoID)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return false, fmt.Errorf("failed to get running timer: %w", err)
+	case runningTodoID == entry.TodoID:
+		entry.ID = runningID
+		return false, nil
+	default:
+		if _, err := tx.Exec(`UPDATE time_entries SET ended_at = ? WHERE id = ?`, entry.StartedAt, runningID); err != nil {
+			return false, fmt.Errorf("failed to stop timer: %w", err)
+		}
+	}
+
+	result, err := tx.Exec(`INSERT INTO time_entries (todo_id, user_id, started_at, note) VALUES (?, ?, ?, ?)`,
+		entry.TodoID, entry.UserID, entry.StartedAt, entry.Note)
+	if err != nil {
+		return false, fmt.Errorf("failed to start timer: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get time entry ID: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit timer: %w", err)
+	}
+
+	entry.ID = int(id)
+	return true, nil
+}
+
+// StopTimer stops the running timer of a user at endedAt and returns its ID; like starting a
+// timer, it is serialized on the user row
+func (r *TimeRepository) StopTimer(userID int, endedAt time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockUser(tx, userID); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRow(`SELECT id FROM time_entries WHERE user_id = ? AND ended_at IS NULL FOR UPDATE`, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("no timer is running")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get running timer: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE time_entries SET ended_at = ? WHERE id = ?`, endedAt, id); err != nil {
+		return 0, fmt.Errorf("failed to stop timer: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit timer: %w", err)
+	}
+	return id, nil
+}
+
+// GetRunningTimer retrieves the running timer of a user; sql.ErrNoRows is wrapped when none runs
+func (r *TimeRepository) GetRunningTimer(userID int) (*model.TimeEntry, error) {
+	query := timeEntrySelect + ` WHERE e.user_id = ? AND e.ended_at IS NULL`
+	return scanTimeEntry(r.db.QueryRow(query, userID))
+}
+
+// CreateEntry records time entered by hand
+func (r *TimeRepository) CreateEntry(entry *model.TimeEntry) error {
+	result, err := r.db.Exec(`INSERT INTO time_entries (todo_id, user_id, started_at, ended_at, note) VALUES (?, ?, ?, ?, ?)`,
+		entry.TodoID, entry.UserID, entry.StartedAt, entry.EndedAt, entry.Note)
+	if err != nil {
+		return fmt.Errorf("failed to create time entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get time entry ID: %w", err)
+	}
+	entry.ID = int(id)
+	return nil
+}
+
+// GetEntry retrieves a time entry of a todo
+func (r *TimeRepository) GetEntry(id, todoID int) (*model.TimeEntry, error) {
+	query := timeEntrySelect + ` WHERE e.id = ? AND e.todo_id = ?`
+	return scanTimeEntry(r.db.QueryRow(query, id, todoID))
+}
+
+// UpdateEntry updates the times and note of a time entry
+func (r *TimeRepository) UpdateEntry(entry *model.TimeEntry) error {
+	result, err := r.db.Exec(`UPDATE time_entries SET started_at = ?, ended_at = ?, note = ? WHERE id = ? AND todo_id = ?`,
+		entry.StartedAt, entry.EndedAt, entry.Note, entry.ID, entry.TodoID)
+	if err != nil {
+		return fmt.Errorf("failed to update time entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	// MySQL reports no affected rows for an update that changes nothing, too
+	var exists bool
+	err = r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM time_entries WHERE id = ? AND todo_id = ?)`, entry.ID, entry.TodoID).
+		Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to get time entry: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("time entry not found")
+	}
+	return nil
+}
+
+// DeleteEntry deletes a time entry of a todo
+func (r *TimeRepository) DeleteEntry(id, todoID int) error {
+	result, err := r.db.Exec(`DELETE FROM time_entries WHERE id = ? AND todo_id = ?`, id, todoID)
+	if err != nil {
+		return fmt.Errorf("failed to delete time entry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("time entry not found")
+	}
+	return nil
+}
+
+// GetTodoEntries retrieves the time entries of a todo, newest first
+func (r *TimeRepository) GetTodoEntries(todoID int) ([]model.TimeEntry, error) {
+	query := timeEntrySelect + ` WHERE e.todo_id = ? ORDER BY e.started_at DESC, e.id DESC`
+	return r.queryEntries(query, todoID)
+}
+
+// GetReportEntries retrieves the time entries a user tracked, or that were tracked on todos
+// they own, which started in [from, to), oldest first. Entries of todos in the trash are left
+// out. todoID, projectID and tagID narrow the entries down when set.
+func (r *TimeRepository) GetReportEntries(userID int, from, to time.Time, todoID, projectID, tagID *int) ([]model.TimeEntry, error) {
+	query := timeEntrySelect + ` WHERE (e.user_id = ? OR t.user_id = ?) AND t.deleted_at IS NULL
+			  AND e.started_at >= ? AND e.started_at < ?`
+	args := []interface{}{userID, userID, from, to}
+	if todoID != nil {
+		query += " AND e.todo_id = ?"
+		args = append(args, *todoID)
+	}
+	if projectID != nil {
+		query += " AND t.project_id = ?"
+		args = append(args, *projectID)
+	}
+	if tagID != nil {
+		query += " AND e.todo_id IN (SELECT todo_id FROM todo_tags WHERE tag_id = ?)"
+		args = append(args, *tagID)
+	}
+	query += " ORDER BY e.started_at, e.id"
+	return r.queryEntries(query, args...)
+}
+
+func (r *TimeRepository) queryEntries(query string, args ...interface{}) ([]model.TimeEntry, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.TimeEntry{}
+	for rows.Next() {
+		entry, err := scanTimeEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, rows.Err()
+}
+
+func scanTimeEntry(row rowScanner) (*model.TimeEntry, error) {
+	entry := &model.TimeEntry{}
+	var projectID sql.NullInt64
+	var endedAt sql.NullTime
+	err := row.Scan(&entry.ID, &entry.TodoID, &entry.TodoTitle, &projectID, &entry.ProjectName, &entry.UserID,
+		&entry.Username, &entry.StartedAt, &endedAt, &entry.Note, &entry.CreatedAt, &entry.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time entry: %w", err)
+	}
+	entry.ProjectID = scanNullableID(projectID)
+	entry.EndedAt = scanNullableTime(endedAt)
+	return entry, nil
+}
@@ -3,8 +3,10 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"time"
 
 	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/rank"
 )
 
 type TodoRepository struct {
@@ -15,45 +17,112 @@ func NewTodoRepository(db *sql.DB) *TodoRepository {
 	return &TodoRepository{db: db}
 }
 
-// CreateTodo creates a new todo
-func (r *TodoRepository) CreateTodo(todo *model.Todo) error {
-	query := `INSERT INTO todos (user_id, title, content, completed) VALUES (?, ?, ?, ?)`
-	result, err := r.db.Exec(query, todo.UserID, todo.Title, todo.Content, todo.Completed)
+// SaveTodos saves planned changes to todos in order, in one transaction: either every change
+// is kept or none is. Created todos get their ID, and changed todos their series and board
+// position, filled in.
+func (r *TodoRepository) SaveTodos(writes ...*model.TodoWrite) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// New todos are ranked after their owner's last todo, so creates of a user are serialized
+	locked := map[int]bool{}
+	for _, write := range writes {
+		if userID := write.Todo.UserID; write.Todo.ID == 0 && !locked[userID] {
+			if err := lockUser(tx, userID); err != nil {
+				return err
+			}
+			locked[userID] = true
+		}
+	}
+
+	for _, write := range writes {
+		if err := saveTodo(tx, write); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit todos: %w", err)
+	}
+	return nil
+}
+
+// saveTodo applies one planned change within tx. A created todo is ranked last, so its owner's
+// user row must be locked first.
+func saveTodo(tx *sql.Tx, write *model.TodoWrite) error {
+	todo := write.Todo
+	if write.Delete {
+		return trashTodo(tx, todo.ID, todo.UserID)
+	}
+
+	if write.Series != nil {
+		if err := insertSeries(tx, write.Series); err != nil {
+			return err
+		}
+		write.Series.Schedule(todo, write.FirstAt)
+	}
+	if todo.ID == 0 || write.Reposition {
+		err := tx.QueryRow(`SELECT COALESCE(MAX(board_position) + 1, 0) FROM todos
+				  WHERE user_id = ? AND project_id <=> ? AND status = ? AND archived_at IS NULL AND deleted_at IS NULL`,
+			todo.UserID, todo.ProjectID, todo.Status).Scan(&todo.BoardPosition)
+		if err != nil {
+			return fmt.Errorf("failed to get board position: %w", err)
+		}
+	}
+
+	if todo.ID != 0 {
+		if err := updateTodoRow(tx, todo); err != nil {
+			return err
+		}
+		return writeTodoLinks(tx, todo.ID, write.Links)
+	}
+
+	position, err := positionAfter(tx, todo.UserID, "")
+	if err != nil {
+		return err
+	}
+	todo.Position = position
+
+	result, err := insertTodo(tx, todo)
 	if err != nil {
 		return fmt.Errorf("failed to create todo: %w", err)
 	}
-	
 	id, err := result.LastInsertId()
 	if err != nil {
 		return fmt.Errorf("failed to get todo ID: %w", err)
 	}
-	
 	todo.ID = int(id)
-	return nil
+	return writeTodoLinks(tx, todo.ID, write.Links)
 }
 
-// GetTodoByID retrieves a todo by ID
+// GetTodoByID retrieves a todo by ID unless it is in the trash
 func (r *TodoRepository) GetTodoByID(id int) (*model.Todo, error) {
-	todo := &model.Todo{}
-	query := `SELECT id, user_id, title, content, completed, created_at, updated_at 
-			  FROM todos WHERE id = ?`
-	
-	err := r.db.QueryRow(query, id).Scan(
-		&todo.ID, &todo.UserID, &todo.Title, &todo.Content,
-		&todo.Completed, &todo.CreatedAt, &todo.UpdatedAt,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get todo: %w", err)
-	}
-	
-	return todo, nil
+	query := fmt.Sprintf(`SELECT %s FROM todos WHERE id = ? AND deleted_at IS NULL`, todoColumns)
+	return scanTodo(r.db.QueryRow(query, id))
 }
 
-// GetTodosByUser retrieves todos for a user with pagination and filtering
+// GetTodosByUser retrieves todos a user owns or is assigned to with pagination and filtering
 func (r *TodoRepository) GetTodosByUser(userID int, req model.PaginationRequest) ([]model.Todo, int64, error) {
 	// Build WHERE clause
-	whereClause := "WHERE user_id = ?"
-	args := []interface{}{userID}
+	assigned := "id IN (SELECT todo_id FROM todo_assignees WHERE user_id = ?)"
+	whereClause := "WHERE deleted_at IS NULL AND (user_id = ? OR " + assigned + ")"
+	args := []interface{}{userID, userID}
+	if req.CreatedByMe {
+		whereClause += " AND user_id = ?"
+		args = append(args, userID)
+	}
+	if req.AssignedToMe {
+		whereClause += " AND " + assigned
+		args = append(args, userID)
+	}
+	if req.ArchivedOnly {
+		whereClause += " AND archived_at IS NOT NULL"
+	} else if !req.IncludeArchived {
+		whereClause += " AND archived_at IS NULL"
+	}
 	
 	// Add search filter
 	if req.Search != "" {
@@ -68,6 +137,59 @@ func (r *TodoRepository) GetTodosByUser(userID int, req model.PaginationRequest)
 	} else if req.Filter == "pending" {
 		whereClause += " AND completed = false"
 	}
+
+	// Add project, subtask and status filters
+	if req.ProjectID != nil {
+		whereClause += " AND project_id = ?"
+		args = append(args, *req.ProjectID)
+	} else if req.NoProject {
+		whereClause += " AND project_id IS NULL"
+	}
+	if req.ParentID != nil {
+		whereClause += " AND parent_id = ?"
+		args = append(args, *req.ParentID)
+	} else if req.TopLevel {
+		whereClause += " AND parent_id IS NULL"
+	}
+	if req.Status != "" {
+		whereClause += " AND status = ?"
+		args = append(args, req.Status)
+	}
+
+	// Add priority filter
+	if len(req.Priorities) > 0 {
+		whereClause += fmt.Sprintf(" AND priority IN (%s)", placeholders(len(req.Priorities)))
+		for _, priority := range req.Priorities {
+			args = append(args, int(priority))
+		}
+	}
+
+	// Add due date filters
+	if req.DueAfter != nil {
+		whereClause += " AND due_at >= ?"
+		args = append(args, req.DueAfter.UTC())
+	}
+	if req.DueBefore != nil {
+		whereClause += " AND due_at < ?"
+		args = append(args, req.DueBefore.UTC())
+	}
+	if req.Overdue {
+		whereClause += " AND completed = false AND due_at < ?"
+		args = append(args, time.Now().UTC())
+	}
+
+	// Add tag filters
+	if len(req.TagIDs) > 0 {
+		tagFilter := fmt.Sprintf("SELECT todo_id FROM todo_tags WHERE tag_id IN (%s)", placeholders(len(req.TagIDs)))
+		for _, tagID := range req.TagIDs {
+			args = append(args, tagID)
+		}
+		if req.TagMode != model.TagModeAny {
+			tagFilter += " GROUP BY todo_id HAVING COUNT(*) = ?"
+			args = append(args, len(req.TagIDs))
+		}
+		whereClause += " AND id IN (" + tagFilter + ")"
+	}
 	
 	// Count total records
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM todos %s", whereClause)
@@ -77,22 +199,14 @@ func (r *TodoRepository) GetTodosByUser(userID int, req model.PaginationRequest)
 		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
 	}
 	
-	// Build ORDER BY clause
-	orderBy := "ORDER BY created_at DESC"
-	if req.Sort != "" {
-		order := "ASC"
-		if req.Order == "desc" {
-			order = "DESC"
-		}
-		orderBy = fmt.Sprintf("ORDER BY %s %s", req.Sort, order)
-	}
+	orderBy := todoOrderBy(req)
 	
 	// Build main query with pagination
 	offset := (req.Page - 1) * req.Limit
 	query := fmt.Sprintf(`
-		SELECT id, user_id, title, content, completed, created_at, updated_at 
+		SELECT %s
 		FROM todos %s %s LIMIT ? OFFSET ?
-	`, whereClause, orderBy)
+	`, todoColumns, whereClause, orderBy)
 	
 	args = append(args, req.Limit, offset)
 	
@@ -104,119 +218,1144 @@ func (r *TodoRepository) GetTodosByUser(userID int, req model.PaginationRequest)
 	
 	var todos []model.Todo
 	for rows.Next() {
-		var todo model.Todo
-		err := rows.Scan(
-			&todo.ID, &todo.UserID, &todo.Title, &todo.Content,
-			&todo.Completed, &todo.CreatedAt, &todo.UpdatedAt,
-		)
+		todo, err := scanTodo(rows)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan todo: %w", err)
+			return nil, 0, err
 		}
-		todos = append(todos, todo)
+		todos = append(todos, *todo)
 	}
 	
 	return todos, total, nil
 }
 
-// UpdateTodo updates a todo
-func (r *TodoRepository) UpdateTodo(todo *model.Todo) error {
-	query := `UPDATE todos SET title = ?, content = ?, completed = ?, updated_at = CURRENT_TIMESTAMP 
-			  WHERE id = ? AND user_id = ?`
-	
-	result, err := r.db.Exec(query, todo.Title, todo.Content, todo.Completed, todo.ID, todo.UserID)
+// GetAllTodosByUser retrieves every todo owned by a user, oldest first
+func (r *TodoRepository) GetAllTodosByUser(userID int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todos WHERE user_id = ? AND deleted_at IS NULL ORDER BY id`, todoColumns)
+
+	rows, err := r.db.Query(query, userID)
 	if err != nil {
-		return fmt.Errorf("failed to update todo: %w", err)
+		return nil, fmt.Errorf("failed to query todos: %w", err)
 	}
-	
-	rowsAffected, err := result.RowsAffected()
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *todo)
+	}
+
+	return todos, rows.Err()
+}
+
+// UpdateTodo updates a todo along with its tags, checklist and assignees in one transaction;
+// links may be nil
+func (r *TodoRepository) UpdateTodo(todo *model.Todo, links *model.TodoLinks) error {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to get affected rows: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	
-	if rowsAffected == 0 {
-		return fmt.Errorf("todo not found or access denied")
+	defer tx.Rollback()
+
+	if err := updateTodoRow(tx, todo); err != nil {
+		return err
+	}
+	if err := writeTodoLinks(tx, todo.ID, links); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit todo: %w", err)
+	}
+	return nil
+}
+
+// SetTodoLinks replaces the tags and assignees of a todo, and extends its checklist, in one
+// transaction
+func (r *TodoRepository) SetTodoLinks(todoID int, links *model.TodoLinks) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := writeTodoLinks(tx, todoID, links); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit todo: %w", err)
 	}
-	
 	return nil
 }
 
-// DeleteTodo deletes a todo
+// DeleteTodo moves a todo and its subtasks to the trash. They share one deletion time so that
+// restoring the todo brings back exactly the subtasks trashed with it.
 func (r *TodoRepository) DeleteTodo(id, userID int) error {
-	query := `DELETE FROM todos WHERE id = ? AND user_id = ?`
-	result, err := r.db.Exec(query, id, userID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := trashTodo(tx, id, userID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deletion: %w", err)
+	}
+	return nil
+}
+
+// GetTrash retrieves a page of the todos a user deleted, most recently deleted first, and their
+// total count. Subtasks trashed along with their parent are left out.
+func (r *TodoRepository) GetTrash(userID, page, limit int) ([]model.Todo, int64, error) {
+	whereClause := `WHERE user_id = ? AND deleted_at IS NOT NULL AND NOT EXISTS (
+			SELECT 1 FROM todos p WHERE p.id = todos.parent_id AND p.deleted_at = todos.deleted_at)`
+
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM todos "+whereClause, userID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count deleted todos: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM todos %s ORDER BY deleted_at DESC, id DESC LIMIT ? OFFSET ?`, todoColumns, whereClause)
+	rows, err := r.db.Query(query, userID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query deleted todos: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		todos = append(todos, *todo)
+	}
+	return todos, total, rows.Err()
+}
+
+// trashedSubtreeQuery selects a trashed todo and its trashed descendants; with the deletion time
+// as a second argument only those trashed along with it
+const trashedSubtreeQuery = `WITH RECURSIVE trashed (id) AS (
+		SELECT id FROM todos WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT t.id FROM todos t JOIN trashed d ON t.parent_id = d.id WHERE t.deleted_at %s
+	) SELECT id FROM trashed`
+
+// RestoreTodo takes a todo out of the trash together with the subtasks trashed along with it and
+// returns the restored todo. A todo whose parent is still trashed, or gone, becomes top-level; an
+// open occurrence whose series has moved on to another open occurrence becomes a one-off todo.
+func (r *TodoRepository) RestoreTodo(id, userID int) (*model.Todo, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`SELECT %s FROM todos WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL FOR UPDATE`, todoColumns)
+	todo, err := scanTodo(tx.QueryRow(query, id, userID))
+	if err != nil {
+		return nil, fmt.Errorf("todo not found in trash")
+	}
+
+	ids, err := queryIDs(tx, fmt.Sprintf(trashedSubtreeQuery, "= ?"), id, userID, *todo.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf(`UPDATE todos SET deleted_at = NULL WHERE id IN (%s)`, placeholders(len(ids)))
+	if _, err := tx.Exec(query, idArgs(ids)...); err != nil {
+		return nil, fmt.Errorf("failed to restore todo: %w", err)
+	}
+
+	if todo.ParentID != nil {
+		var live int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM todos WHERE id = ? AND deleted_at IS NULL`, *todo.ParentID).Scan(&live)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check parent todo: %w", err)
+		}
+		if live == 0 {
+			if _, err := tx.Exec(`UPDATE todos SET parent_id = NULL WHERE id = ?`, id); err != nil {
+				return nil, fmt.Errorf("failed to detach restored todo: %w", err)
+			}
+			todo.ParentID = nil
+		}
+	}
+
+	query = fmt.Sprintf(`SELECT id, series_id FROM todos WHERE id IN (%s) AND series_id IS NOT NULL AND completed = false`,
+		placeholders(len(ids)))
+	rows, err := tx.Query(query, idArgs(ids)...)
 	if err != nil {
+		return nil, fmt.Errorf("failed to query restored occurrences: %w", err)
+	}
+	occurrences := map[int]int{}
+	for rows.Next() {
+		var occurrenceID, seriesID int
+		if err := rows.Scan(&occurrenceID, &seriesID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan restored occurrence: %w", err)
+		}
+		occurrences[occurrenceID] = seriesID
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query restored occurrences: %w", err)
+	}
+	for occurrenceID, seriesID := range occurrences {
+		var open int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM todos WHERE series_id = ? AND id <> ? AND completed = false
+				  AND deleted_at IS NULL`, seriesID, occurrenceID).Scan(&open)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count open occurrences: %w", err)
+		}
+		if open == 0 {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE todos SET series_id = NULL WHERE id = ?`, occurrenceID); err != nil {
+			return nil, fmt.Errorf("failed to detach restored occurrence: %w", err)
+		}
+		if occurrenceID == id {
+			todo.SeriesID = nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit todo restore: %w", err)
+	}
+	todo.DeletedAt = nil
+	return todo, nil
+}
+
+// DeleteTrashedTodo permanently deletes a trashed todo and its trashed subtasks
+func (r *TodoRepository) DeleteTrashedTodo(id, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids, err := queryIDs(tx, fmt.Sprintf(trashedSubtreeQuery, "IS NOT NULL"), id, userID)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("todo not found in trash")
+	}
+
+	query := fmt.Sprintf(`DELETE FROM todos WHERE id IN (%s)`, placeholders(len(ids)))
+	if _, err := tx.Exec(query, idArgs(ids)...); err != nil {
 		return fmt.Errorf("failed to delete todo: %w", err)
 	}
-	
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit todo deletion: %w", err)
+	}
+	return nil
+}
+
+// EmptyTrash permanently deletes all of a user's trashed todos and returns how many there were
+func (r *TodoRepository) EmptyTrash(userID int) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM todos WHERE user_id = ? AND deleted_at IS NOT NULL`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to empty trash: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// PurgeTrash permanently deletes up to limit todos trashed before cutoff and returns how many
+// were deleted
+func (r *TodoRepository) PurgeTrash(cutoff time.Time, limit int) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM todos WHERE deleted_at IS NOT NULL AND deleted_at < ? ORDER BY deleted_at LIMIT ?`,
+		cutoff.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// ArchiveTodo archives a todo and its subtasks. They share one archive time so that
+// unarchiving the todo brings back exactly the subtasks archived with it.
+func (r *TodoRepository) ArchiveTodo(id, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ids, err := lockSubtree(tx, id)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("todo not found or access denied")
+	}
+
+	query := fmt.Sprintf(`UPDATE todos SET archived_at = ? WHERE user_id = ? AND archived_at IS NULL AND deleted_at IS NULL
+			  AND id IN (%s)`, placeholders(len(ids)))
+	args := append([]interface{}{time.Now().UTC(), userID}, idArgs(ids)...)
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to archive todo: %w", err)
+	}
+
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get affected rows: %w", err)
 	}
-	
+
 	if rowsAffected == 0 {
 		return fmt.Errorf("todo not found or access denied")
 	}
-	
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit archive: %w", err)
+	}
 	return nil
 }
 
-// GetAllTodos retrieves all todos (admin only) with pagination
-func (r *TodoRepository) GetAllTodos(req model.PaginationRequest) ([]model.Todo, int64, error) {
-	// Build WHERE clause for search
-	whereClause := ""
-	args := []interface{}{}
-	
-	if req.Search != "" {
-		whereClause = "WHERE title LIKE ? OR content LIKE ?"
-		searchTerm := "%" + req.Search + "%"
-		args = append(args, searchTerm, searchTerm)
+// UnarchiveTodo takes an archived todo out of the archive together with the subtasks archived
+// along with it
+func (r *TodoRepository) UnarchiveTodo(id, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	
-	// Count total records
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM todos %s", whereClause)
-	var total int64
-	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	defer tx.Rollback()
+
+	ids, err := lockSubtree(tx, id)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+		return err
 	}
-	
-	// Build ORDER BY clause
-	orderBy := "ORDER BY created_at DESC"
-	if req.Sort != "" {
-		order := "ASC"
-		if req.Order == "desc" {
-			order = "DESC"
+
+	var archivedAt time.Time
+	err = tx.QueryRow(`SELECT archived_at FROM todos WHERE id = ? AND user_id = ? AND archived_at IS NOT NULL
+			  AND deleted_at IS NULL`, id, userID).Scan(&archivedAt)
+	if err != nil {
+		return fmt.Errorf("todo not found in archive")
+	}
+
+	query := fmt.Sprintf(`UPDATE todos SET archived_at = NULL WHERE archived_at = ? AND id IN (%s)`, placeholders(len(ids)))
+	args := append([]interface{}{archivedAt}, idArgs(ids)...)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to unarchive todo: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit unarchive: %w", err)
+	}
+	return nil
+}
+
+// GetArchivable retrieves up to limit completed top-level todos whose owners auto-archive todos
+// and that were completed more than the owner's auto_archive_days before now
+func (r *TodoRepository) GetArchivable(now time.Time, limit int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todos
+			  WHERE parent_id IS NULL AND completed = true AND archived_at IS NULL AND deleted_at IS NULL
+			  AND COALESCE(completed_at, updated_at) < (SELECT ? - INTERVAL p.auto_archive_days DAY FROM user_preferences p
+			  WHERE p.user_id = todos.user_id AND p.auto_archive_days > 0)
+			  ORDER BY id LIMIT ?`, todoColumns)
+
+	rows, err := r.db.Query(query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query archivable todos: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
 		}
-		orderBy = fmt.Sprintf("ORDER BY %s %s", req.Sort, order)
+		todos = append(todos, *todo)
 	}
-	
-	// Build main query with pagination
-	offset := (req.Page - 1) * req.Limit
-	query := fmt.Sprintf(`
-		SELECT id, user_id, title, content, completed, created_at, updated_at 
-		FROM todos %s %s LIMIT ? OFFSET ?
-	`, whereClause, orderBy)
-	
-	args = append(args, req.Limit, offset)
-	
-	rows, err := r.db.Query(query, args...)
+	return todos, rows.Err()
+}
+
+// subtreeQuery selects the IDs and depths of a todo and all of its descendants outside the trash
+const subtreeQuery = `WITH RECURSIVE subtree (id, depth) AS (
+		SELECT id, 0 FROM todos WHERE id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT t.id, s.depth + 1 FROM todos t JOIN subtree s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+	)`
+
+// lockSubtree locks a todo and its descendants within tx, so that none is moved while they
+// change together, and returns their IDs, shallowest first
+func lockSubtree(tx *sql.Tx, id int) ([]int, error) {
+	return queryIDs(tx, subtreeQuery+` SELECT t.id FROM todos t JOIN subtree s ON s.id = t.id
+			  ORDER BY s.depth, t.id FOR UPDATE OF t`, id)
+}
+
+// GetSubtreeIDs returns the IDs of a todo and its descendants, shallowest first
+func (r *TodoRepository) GetSubtreeIDs(id int) ([]int, error) {
+	rows, err := r.db.Query(subtreeQuery+` SELECT id FROM subtree ORDER BY depth, id`, id)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to query todos: %w", err)
+		return nil, fmt.Errorf("failed to query subtasks: %w", err)
 	}
 	defer rows.Close()
-	
+
+	var ids []int
+	for rows.Next() {
+		var subtaskID int
+		if err := rows.Scan(&subtaskID); err != nil {
+			return nil, fmt.Errorf("failed to scan subtask: %w", err)
+		}
+		ids = append(ids, subtaskID)
+	}
+	return ids, rows.Err()
+}
+
+// GetSubtree retrieves a todo and all of its descendants in one query, shallowest first
+func (r *TodoRepository) GetSubtree(id int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`%s SELECT %s FROM todos WHERE id IN (SELECT id FROM subtree) ORDER BY id`, subtreeQuery, todoColumns)
+
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subtasks: %w", err)
+	}
+	defer rows.Close()
+
 	var todos []model.Todo
 	for rows.Next() {
-		var todo model.Todo
-		err := rows.Scan(
-			&todo.ID, &todo.UserID, &todo.Title, &todo.Content,
-			&todo.Completed, &todo.CreatedAt, &todo.UpdatedAt,
-		)
+		todo, err := scanTodo(rows)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan todo: %w", err)
+			return nil, err
 		}
-		todos = append(todos, todo)
+		todos = append(todos, *todo)
 	}
-	
-	return todos, total, nil
-}
\ No newline at end of file
+	return todos, rows.Err()
+}
+
+// GetSubtreeHeight returns how many levels of subtasks sit below a todo
+func (r *TodoRepository) GetSubtreeHeight(id int) (int, error) {
+	var height int
+	if err := r.db.QueryRow(subtreeQuery+` SELECT MAX(depth) FROM subtree`, id).Scan(&height); err != nil {
+		return 0, fmt.Errorf("failed to get subtask depth: %w", err)
+	}
+	return height, nil
+}
+
+// GetAncestorIDs returns the IDs of a todo's parent, grandparent and so on, nearest first
+func (r *TodoRepository) GetAncestorIDs(id int) ([]int, error) {
+	query := `WITH RECURSIVE ancestors (id, parent_id, depth) AS (
+			SELECT id, parent_id, 0 FROM todos WHERE id = ?
+			UNION ALL
+			SELECT t.id, t.parent_id, a.depth + 1 FROM todos t JOIN ancestors a ON t.id = a.parent_id
+			WHERE t.deleted_at IS NULL
+		) SELECT id FROM ancestors WHERE depth > 0 ORDER BY depth`
+
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query parent todos: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var ancestorID int
+		if err := rows.Scan(&ancestorID); err != nil {
+			return nil, fmt.Errorf("failed to scan parent todo: %w", err)
+		}
+		ids = append(ids, ancestorID)
+	}
+	return ids, rows.Err()
+}
+
+// CountSubtasks counts the direct subtasks of each given todo and how many of them are completed
+func (r *TodoRepository) CountSubtasks(parentIDs []int) (map[int][2]int, error) {
+	counts := make(map[int][2]int, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+
+	query := fmt.Sprintf(`SELECT parent_id, COUNT(CASE WHEN completed = true THEN 1 END), COUNT(*) FROM todos
+			  WHERE parent_id IN (%s) AND deleted_at IS NULL GROUP BY parent_id`, placeholders(len(parentIDs)))
+	rows, err := r.db.Query(query, idArgs(parentIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count subtasks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var parentID, done, total int
+		if err := rows.Scan(&parentID, &done, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan subtask count: %w", err)
+		}
+		counts[parentID] = [2]int{done, total}
+	}
+	return counts, rows.Err()
+}
+
+// MoveTodos sets the project of a user's todos; a nil projectID removes them from their project.
+// It returns how many todos were moved.
+func (r *TodoRepository) MoveTodos(userID int, todoIDs []int, projectID *int) (int64, error) {
+	args := []interface{}{projectID, userID}
+	for _, id := range todoIDs {
+		args = append(args, id)
+	}
+
+	query := fmt.Sprintf(`UPDATE todos SET project_id = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE user_id = ? AND deleted_at IS NULL AND id IN (%s)`, placeholders(len(todoIDs)))
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move todos: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// FitStatuses moves a user's todos in a project, or outside any project when projectID is nil,
+// whose status is not part of the workflow or disagrees with their completion to the first open
+// or done status. It returns how many todos changed status.
+func (r *TodoRepository) FitStatuses(userID int, projectID *int, workflow model.Workflow) (int64, error) {
+	open, done := workflow.Keys(false), workflow.Keys(true)
+	args := []interface{}{workflow.FirstDone().Key, workflow.Initial().Key, userID, projectID}
+	args = append(args, stringArgs(done)...)
+	args = append(args, stringArgs(open)...)
+
+	query := fmt.Sprintf(`UPDATE todos SET status = CASE WHEN completed = true THEN ? ELSE ? END, updated_at = CURRENT_TIMESTAMP
+			  WHERE user_id = ? AND project_id <=> ? AND deleted_at IS NULL
+			  AND ((completed = true AND status NOT IN (%s)) OR (completed = false AND status NOT IN (%s)))`,
+		placeholders(len(done)), placeholders(len(open)))
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update todo statuses: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+// NextBoardPosition returns the position after the last todo in a board column
+func (r *TodoRepository) NextBoardPosition(userID int, projectID *int, status string) (int, error) {
+	var position int
+	err := r.db.QueryRow(`SELECT COALESCE(MAX(board_position) + 1, 0) FROM todos
+			  WHERE user_id = ? AND project_id <=> ? AND status = ? AND archived_at IS NULL AND deleted_at IS NULL`,
+		userID, projectID, status).Scan(&position)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get board position: %w", err)
+	}
+	return position, nil
+}
+
+// GetBoardTodos retrieves the todos of a project in board order within each status
+func (r *TodoRepository) GetBoardTodos(userID, projectID int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todos WHERE user_id = ? AND project_id = ? AND archived_at IS NULL
+			  AND deleted_at IS NULL ORDER BY status, board_position, id`, todoColumns)
+
+	rows, err := r.db.Query(query, userID, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board todos: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *todo)
+	}
+	return todos, rows.Err()
+}
+
+// MoveOnBoard saves a todo's status and completion and puts it at index within its board
+// column, renumbering the column in one transaction
+func (r *TodoRepository) MoveOnBoard(todo *model.Todo, index int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id FROM todos WHERE user_id = ? AND project_id <=> ? AND status = ? AND id <> ?
+			  AND archived_at IS NULL AND deleted_at IS NULL ORDER BY board_position, id FOR UPDATE`, todo.UserID, todo.ProjectID, todo.Status, todo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to lock board column: %w", err)
+	}
+	var column []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan board todo: %w", err)
+		}
+		column = append(column, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read board column: %w", err)
+	}
+
+	if index > len(column) {
+		index = len(column)
+	}
+	column = append(column[:index], append([]int{todo.ID}, column[index:]...)...)
+
+	result, err := tx.Exec(`UPDATE todos SET status = ?, completed = ?, completed_at = ?, board_position = ?,
+			  updated_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND deleted_at IS NULL`,
+		todo.Status, todo.Completed, todo.CompletedAt, index, todo.ID, todo.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to move todo: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return fmt.Errorf("todo not found or access denied")
+	}
+
+	for position, id := range column {
+		if id == todo.ID {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE todos SET board_position = ? WHERE id = ?`, position, id); err != nil {
+			return fmt.Errorf("failed to reorder board column: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit board move: %w", err)
+	}
+	todo.BoardPosition = index
+	return nil
+}
+
+// GetPendingTodos retrieves up to limit of a user's open todos in their manual order
+func (r *TodoRepository) GetPendingTodos(userID, limit int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todos WHERE user_id = ? AND completed = false AND archived_at IS NULL
+			  AND deleted_at IS NULL ORDER BY position, id LIMIT ?`, todoColumns)
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending todos: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *todo)
+	}
+	return todos, rows.Err()
+}
+
+// NeighborPosition returns the closest position key below (or, with above, after) position in a
+// user's manual order, ignoring the todo with excludeID; "" means there is none
+func (r *TodoRepository) NeighborPosition(userID, excludeID int, position string, above bool) (string, error) {
+	query := `SELECT COALESCE(MAX(position), '') FROM todos WHERE user_id = ? AND id <> ? AND deleted_at IS NULL AND position < ?`
+	if above {
+		query = `SELECT COALESCE(MIN(position), '') FROM todos WHERE user_id = ? AND id <> ? AND deleted_at IS NULL AND position > ?`
+	}
+
+	var neighbor string
+	if err := r.db.QueryRow(query, userID, excludeID, position).Scan(&neighbor); err != nil {
+		return "", fmt.Errorf("failed to get neighboring position: %w", err)
+	}
+	return neighbor, nil
+}
+
+// SetPosition moves a todo in its owner's manual order by changing its position key only
+func (r *TodoRepository) SetPosition(id, userID int, position string) error {
+	result, err := r.db.Exec(`UPDATE todos SET position = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND user_id = ? AND deleted_at IS NULL`, position, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to move todo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("todo not found or access denied")
+	}
+	return nil
+}
+
+// Rebalance replaces the position keys of all of a user's todos outside the trash with short,
+// evenly spaced keys in the same order
+func (r *TodoRepository) Rebalance(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id FROM todos WHERE user_id = ? AND deleted_at IS NULL ORDER BY position, id FOR UPDATE`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to lock todos: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan todo: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read todos: %w", err)
+	}
+
+	for i, position := range rank.Spread(len(ids)) {
+		if _, err := tx.Exec(`UPDATE todos SET position = ? WHERE id = ?`, position, ids[i]); err != nil {
+			return fmt.Errorf("failed to rebalance todo positions: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rebalance: %w", err)
+	}
+	return nil
+}
+
+// GetUsersWithLongPositions returns users whose longest position key exceeds maxLength
+func (r *TodoRepository) GetUsersWithLongPositions(maxLength, limit int) ([]int, error) {
+	rows, err := r.db.Query(`SELECT user_id FROM todos WHERE deleted_at IS NULL
+			  GROUP BY user_id HAVING MAX(LENGTH(position)) > ? LIMIT ?`, maxLength, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todo positions: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// positionAfter returns a position key right after position in a user's manual order, or at the
+// end when position is empty or no key fits
+func positionAfter(tx *sql.Tx, userID int, position string) (string, error) {
+	var next, last string
+	err := tx.QueryRow(`SELECT COALESCE(MIN(CASE WHEN position > ? THEN position END), ''), COALESCE(MAX(position), '')
+			  FROM todos WHERE user_id = ? AND deleted_at IS NULL`, position, userID).Scan(&next, &last)
+	if err != nil {
+		return "", fmt.Errorf("failed to get position: %w", err)
+	}
+
+	if position != "" {
+		if key, err := rank.Between(position, next); err == nil {
+			return key, nil
+		}
+	}
+	key, err := rank.Between(last, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to rank todo: %w", err)
+	}
+	return key, nil
+}
+
+// GetAllTodos retrieves all todos (admin only) with pagination
+func (r *TodoRepository) GetAllTodos(req model.PaginationRequest) ([]model.Todo, int64, error) {
+	// Build WHERE clause for search
+	whereClause := "WHERE deleted_at IS NULL"
+	args := []interface{}{}
+	
+	if req.Search != "" {
+		whereClause += " AND (title LIKE ? OR content LIKE ?)"
+		searchTerm := "%" + req.Search + "%"
+		args = append(args, searchTerm, searchTerm)
+	}
+	
+	// Count total records
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM todos %s", whereClause)
+	var total int64
+	err := r.db.QueryRow(countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+	
+	orderBy := todoOrderBy(req)
+	
+	// Build main query with pagination
+	offset := (req.Page - 1) * req.Limit
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM todos %s %s LIMIT ? OFFSET ?
+	`, todoColumns, whereClause, orderBy)
+	
+	args = append(args, req.Limit, offset)
+	
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+	
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		todos = append(todos, *todo)
+	}
+	
+	return todos, total, nil
+}
+// GetDueReminders retrieves open todos whose reminder is due and has not fired yet, oldest first
+func (r *TodoRepository) GetDueReminders(now time.Time, limit int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todos
+			  WHERE remind_at <= ? AND reminded_at IS NULL AND completed = false AND archived_at IS NULL
+			  AND deleted_at IS NULL
+			  ORDER BY remind_at LIMIT ?`, todoColumns)
+
+	rows, err := r.db.Query(query, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *todo)
+	}
+	return todos, rows.Err()
+}
+
+// MarkReminded claims a due reminder so that only one worker sends it; false means it was already claimed
+func (r *TodoRepository) MarkReminded(id int, at time.Time) (bool, error) {
+	result, err := r.db.Exec(`UPDATE todos SET reminded_at = ? WHERE id = ? AND reminded_at IS NULL`, at.UTC(), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark reminder: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// ReleaseReminder un-claims a reminder whose delivery failed so the next run retries it
+func (r *TodoRepository) ReleaseReminder(id int) error {
+	if _, err := r.db.Exec(`UPDATE todos SET reminded_at = NULL WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to release reminder: %w", err)
+	}
+	return nil
+}
+
+// CreateOccurrence inserts the next occurrence of a recurring series. Nothing is created, and false is
+// returned, when the series is gone, still has an open occurrence or already has one at the same time,
+// so concurrent completions generate a single successor.
+func (r *TodoRepository) CreateOccurrence(todo *model.Todo) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seriesID int
+	err = tx.QueryRow(`SELECT id FROM todo_series WHERE id = ? FOR UPDATE`, *todo.SeriesID).Scan(&seriesID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock series: %w", err)
+	}
+
+	var existing int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM todos WHERE series_id = ? AND deleted_at IS NULL
+			  AND (completed = false OR occurrence_at = ?)`,
+		seriesID, todo.OccurrenceAt).Scan(&existing)
+	if err != nil {
+		return false, fmt.Errorf("failed to count occurrences: %w", err)
+	}
+	if existing > 0 {
+		return false, nil
+	}
+
+	// The new occurrence stays in the project and under the parent of the previous one, follows
+	// it in the manual order, carries its tags, assignees and priority over and starts in the first open
+	// status of the project's workflow
+	var previousID int
+	var projectID, parentID sql.NullInt64
+	var previousPosition string
+	var previousPriority int
+	err = tx.QueryRow(`SELECT id, project_id, parent_id, position, priority FROM todos WHERE series_id = ?
+			  AND deleted_at IS NULL ORDER BY occurrence_at DESC, id DESC LIMIT 1`,
+		seriesID).Scan(&previousID, &projectID, &parentID, &previousPosition, &previousPriority)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to get previous occurrence: %w", err)
+	}
+	if todo.Position, err = positionAfter(tx, todo.UserID, previousPosition); err != nil {
+		return false, err
+	}
+	if todo.ProjectID == nil {
+		todo.ProjectID = scanNullableID(projectID)
+	}
+	if todo.ParentID == nil {
+		todo.ParentID = scanNullableID(parentID)
+	}
+	if todo.Priority == model.PriorityNone {
+		todo.Priority = model.Priority(previousPriority)
+	}
+	err = tx.QueryRow(`SELECT COALESCE((SELECT status_key FROM project_statuses WHERE project_id = ? AND done = false
+			  ORDER BY position, id LIMIT 1), ?)`, todo.ProjectID, model.StatusTodo).Scan(&todo.Status)
+	if err != nil {
+		return false, fmt.Errorf("failed to get initial status: %w", err)
+	}
+	err = tx.QueryRow(`SELECT COALESCE(MAX(board_position) + 1, 0) FROM todos WHERE user_id = ? AND project_id <=> ? AND status = ?
+			  AND deleted_at IS NULL`,
+		todo.UserID, todo.ProjectID, todo.Status).Scan(&todo.BoardPosition)
+	if err != nil {
+		return false, fmt.Errorf("failed to get board position: %w", err)
+	}
+
+	result, err := insertTodo(tx, todo)
+	if err != nil {
+		return false, fmt.Errorf("failed to create occurrence: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get todo ID: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO todo_tags (todo_id, tag_id) SELECT ?, tag_id FROM todo_tags WHERE todo_id = ?`, id, previousID)
+	if err != nil {
+		return false, fmt.Errorf("failed to copy occurrence tags: %w", err)
+	}
+	_, err = tx.Exec(`INSERT INTO todo_assignees (todo_id, user_id, assigned_by)
+			  SELECT ?, user_id, assigned_by FROM todo_assignees WHERE todo_id = ?`, id, previousID)
+	if err != nil {
+		return false, fmt.Errorf("failed to copy occurrence assignees: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit occurrence: %w", err)
+	}
+	todo.ID = int(id)
+	return true, nil
+}
+
+// LatestOccurrence returns the latest occurrence time in a series, optionally only among completed
+// occurrences; nil means there is none
+func (r *TodoRepository) LatestOccurrence(seriesID int, completedOnly bool) (*time.Time, error) {
+	query := `SELECT MAX(occurrence_at) FROM todos WHERE series_id = ? AND deleted_at IS NULL`
+	if completedOnly {
+		query += ` AND completed = true`
+	}
+
+	var latest sql.NullTime
+	if err := r.db.QueryRow(query, seriesID).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to get latest occurrence: %w", err)
+	}
+	return scanNullableTime(latest), nil
+}
+
+// GetOpenOccurrences retrieves the open occurrences of a series, earliest first
+func (r *TodoRepository) GetOpenOccurrences(seriesID int) ([]model.Todo, error) {
+	query := fmt.Sprintf(`SELECT %s FROM todos WHERE series_id = ? AND completed = false AND deleted_at IS NULL
+			  ORDER BY occurrence_at, id`, todoColumns)
+
+	rows, err := r.db.Query(query, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	var todos []model.Todo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, *todo)
+	}
+	return todos, rows.Err()
+}
+
+// insertTodo inserts a new todo row
+func insertTodo(db execer, todo *model.Todo) (sql.Result, error) {
+	query := `INSERT INTO todos (user_id, title, content, completed, due_at, remind_at, series_id, occurrence_at, project_id,
+			  parent_id, auto_complete, status, board_position, position, priority)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	return db.Exec(query, todo.UserID, todo.Title, todo.Content, todo.Completed, todo.DueAt, todo.RemindAt,
+		todo.SeriesID, todo.OccurrenceAt, todo.ProjectID, todo.ParentID, todo.AutoComplete, todo.Status, todo.BoardPosition,
+		todo.Position, int(todo.Priority))
+}
+
+// updateTodoRow saves the fields of an existing todo
+func updateTodoRow(db execer, todo *model.Todo) error {
+	query := `UPDATE todos SET title = ?, content = ?, completed = ?, due_at = ?, remind_at = ?, reminded_at = ?,
+			  completed_at = ?, series_id = ?, occurrence_at = ?, project_id = ?, parent_id = ?, auto_complete = ?,
+			  status = ?, board_position = ?, priority = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND user_id = ? AND deleted_at IS NULL`
+
+	result, err := db.Exec(query, todo.Title, todo.Content, todo.Completed, todo.DueAt, todo.RemindAt, todo.RemindedAt,
+		todo.CompletedAt, todo.SeriesID, todo.OccurrenceAt, todo.ProjectID, todo.ParentID, todo.AutoComplete,
+		todo.Status, todo.BoardPosition, int(todo.Priority), todo.ID, todo.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to update todo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("todo not found or access denied")
+	}
+	return nil
+}
+
+// trashTodo moves a todo and its subtasks to the trash within tx, under one deletion time
+func trashTodo(tx *sql.Tx, id, userID int) error {
+	ids, err := lockSubtree(tx, id)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("todo not found or access denied")
+	}
+
+	query := fmt.Sprintf(`UPDATE todos SET deleted_at = ? WHERE user_id = ? AND deleted_at IS NULL AND id IN (%s)`,
+		placeholders(len(ids)))
+	args := append([]interface{}{time.Now().UTC(), userID}, idArgs(ids)...)
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete todo: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("todo not found or access denied")
+	}
+	return nil
+}
+
+// writeTodoLinks saves the tags, checklist items and assignees of a todo within tx and fills
+// in the users who were assigned and unassigned
+func writeTodoLinks(tx *sql.Tx, todoID int, links *model.TodoLinks) error {
+	if links == nil {
+		return nil
+	}
+	if links.TagIDs != nil {
+		if err := setTodoTags(tx, todoID, *links.TagIDs); err != nil {
+			return err
+		}
+	}
+	for i, content := range links.Checklist {
+		item := &model.ChecklistItem{TodoID: todoID, Content: content, Position: i}
+		if err := insertChecklistItem(tx, item); err != nil {
+			return err
+		}
+	}
+	if links.AssigneeIDs != nil {
+		added, removed, err := setTodoAssignees(tx, todoID, links.AssignedBy, *links.AssigneeIDs)
+		if err != nil {
+			return err
+		}
+		links.Assigned, links.Unassigned = added, removed
+	}
+	return nil
+}
+
+const todoColumns = `id, user_id, title, content, completed, created_at, updated_at, due_at, remind_at, reminded_at, completed_at,
+	series_id, occurrence_at, project_id, parent_id, auto_complete, status, board_position, position, priority, archived_at,
+	deleted_at`
+
+// todoOrderBy builds the ORDER BY clause for a listing; todos without a due date sort last
+func todoOrderBy(req model.PaginationRequest) string {
+	if req.Sort == "" {
+		return "ORDER BY created_at DESC"
+	}
+
+	order := "ASC"
+	if req.Order == "desc" {
+		order = "DESC"
+	}
+	if req.Sort == "due_at" {
+		return fmt.Sprintf("ORDER BY due_at IS NULL, due_at %s, id", order)
+	}
+	if req.Sort == "position" {
+		return fmt.Sprintf("ORDER BY position %s, id %s", order, order)
+	}
+	if req.Sort == "priority" {
+		return fmt.Sprintf("ORDER BY priority %s, due_at IS NULL, due_at, id", order)
+	}
+	return fmt.Sprintf("ORDER BY %s %s", req.Sort, order)
+}
+
+func scanTodo(row rowScanner) (*model.Todo, error) {
+	todo := &model.Todo{}
+	var dueAt, remindAt, remindedAt, completedAt, occurrenceAt, archivedAt, deletedAt sql.NullTime
+	var seriesID, projectID, parentID sql.NullInt64
+
+	err := row.Scan(
+		&todo.ID, &todo.UserID, &todo.Title, &todo.Content, &todo.Completed, &todo.CreatedAt, &todo.UpdatedAt,
+		&dueAt, &remindAt, &remindedAt, &completedAt, &seriesID, &occurrenceAt, &projectID,
+		&parentID, &todo.AutoComplete, &todo.Status, &todo.BoardPosition, &todo.Position, &todo.Priority, &archivedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get todo: %w", err)
+	}
+
+	todo.DueAt = scanNullableTime(dueAt)
+	todo.RemindAt = scanNullableTime(remindAt)
+	todo.RemindedAt = scanNullableTime(remindedAt)
+	todo.CompletedAt = scanNullableTime(completedAt)
+	todo.SeriesID = scanNullableID(seriesID)
+	todo.OccurrenceAt = scanNullableTime(occurrenceAt)
+	todo.ProjectID = scanNullableID(projectID)
+	todo.ParentID = scanNullableID(parentID)
+	todo.ArchivedAt = scanNullableTime(archivedAt)
+	todo.DeletedAt = scanNullableTime(deletedAt)
+	return todo, nil
+}
+
+// queryIDs runs a query selecting a single ID column within a transaction
+func queryIDs(tx *sql.Tx, query string, args ...interface{}) ([]int, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan todo ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
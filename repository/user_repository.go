@@ -2,8 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"jmrashed/apps/userApp/model"
 )
@@ -33,15 +35,75 @@ func (r *UserRepository) CreateUser(user *model.User) error {
 	return nil
 }
 
+// RegisterUser creates a user with a role in one transaction. With an invitation, the
+// invitation is redeemed in the same transaction: it must still be usable, it is marked as
+// used by the new user, and the user joins the invitation's organization.
+func (r *UserRepository) RegisterUser(user *model.User, roleID int, invitation *model.Invitation) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Claiming the invitation first locks it, so it cannot be redeemed twice
+	if invitation != nil {
+		result, err := tx.Exec(`UPDATE invitations SET used_at = NOW()
+				  WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`, invitation.ID)
+		if err != nil {
+			return fmt.Errorf("failed to claim invitation: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		if rowsAffected == 0 {
+			return errors.New("invitation is no longer valid")
+		}
+	}
+
+	result, err := tx.Exec(`INSERT INTO users (username, email, password_hash, is_active) VALUES (?, ?, ?, ?)`,
+		user.Username, user.Email, user.PasswordHash, user.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get user ID: %w", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)`, id, roleID); err != nil {
+		return fmt.Errorf("failed to assign role to user: %w", err)
+	}
+
+	if invitation != nil {
+		if invitation.OrganizationID != nil {
+			if _, err := tx.Exec(`INSERT IGNORE INTO organization_members (organization_id, user_id) VALUES (?, ?)`,
+				*invitation.OrganizationID, id); err != nil {
+				return fmt.Errorf("failed to add organization member: %w", err)
+			}
+		}
+		if _, err := tx.Exec(`UPDATE invitations SET used_by = ? WHERE id = ?`, id, invitation.ID); err != nil {
+			return fmt.Errorf("failed to complete invitation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit registration: %w", err)
+	}
+
+	user.ID = int(id)
+	return nil
+}
+
 // GetUserByID retrieves a user by ID with roles and permissions
 func (r *UserRepository) GetUserByID(id int) (*model.User, error) {
 	user := &model.User{}
-	query := `SELECT id, username, email, password_hash, is_active, created_at, updated_at 
+	query := `SELECT id, username, email, password_hash, is_active, COALESCE(avatar_url, ''), created_at, updated_at 
 			  FROM users WHERE id = ? AND is_active = true`
 	
 	err := r.db.QueryRow(query, id).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsActive, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -58,12 +120,12 @@ func (r *UserRepository) GetUserByID(id int) (*model.User, error) {
 // GetUserByUsername retrieves a user by username
 func (r *UserRepository) GetUserByUsername(username string) (*model.User, error) {
 	user := &model.User{}
-	query := `SELECT id, username, email, password_hash, is_active, created_at, updated_at 
+	query := `SELECT id, username, email, password_hash, is_active, COALESCE(avatar_url, ''), created_at, updated_at 
 			  FROM users WHERE username = ? AND is_active = true`
 	
 	err := r.db.QueryRow(query, username).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsActive, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -80,12 +142,12 @@ func (r *UserRepository) GetUserByUsername(username string) (*model.User, error)
 // GetUserByEmail retrieves a user by email
 func (r *UserRepository) GetUserByEmail(email string) (*model.User, error) {
 	user := &model.User{}
-	query := `SELECT id, username, email, password_hash, is_active, created_at, updated_at 
+	query := `SELECT id, username, email, password_hash, is_active, COALESCE(avatar_url, ''), created_at, updated_at 
 			  FROM users WHERE email = ? AND is_active = true`
 	
 	err := r.db.QueryRow(query, email).Scan(
 		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
-		&user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+		&user.IsActive, &user.AvatarURL, &user.CreatedAt, &user.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -122,6 +184,174 @@ func (r *UserRepository) DeleteUser(id int) error {
 	return nil
 }
 
+// ScheduleDeletion deactivates a user and schedules the account for purging
+func (r *UserRepository) ScheduleDeletion(userID int, at time.Time) error {
+	query := `UPDATE users SET is_active = false, deletion_scheduled_at = ?, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND is_active = true`
+	result, err := r.db.Exec(query, at, userID)
+	if err != nil {
+		return fmt.Errorf("failed to schedule user deletion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+// GetUserPendingDeletion retrieves a deactivated user still inside the deletion grace period
+func (r *UserRepository) GetUserPendingDeletion(username string) (*model.User, error) {
+	user := &model.User{}
+	query := `SELECT id, username, email, password_hash, is_active, deletion_scheduled_at, created_at, updated_at
+			  FROM users WHERE username = ? AND is_active = false AND deletion_scheduled_at > NOW()`
+
+	var scheduledAt sql.NullTime
+	err := r.db.QueryRow(query, username).Scan(
+		&user.ID, &user.Username, &user.Email, &user.PasswordHash,
+		&user.IsActive, &scheduledAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if scheduledAt.Valid {
+		user.DeletionScheduledAt = &scheduledAt.Time
+	}
+
+	return user, nil
+}
+
+// CancelDeletion reactivates a user scheduled for deletion
+func (r *UserRepository) CancelDeletion(userID int) error {
+	query := `UPDATE users SET is_active = true, deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND deletion_scheduled_at IS NOT NULL`
+	_, err := r.db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel user deletion: %w", err)
+	}
+	return nil
+}
+
+// GetUsersDueForPurge returns IDs of users whose deletion grace period ended before the cutoff
+func (r *UserRepository) GetUsersDueForPurge(cutoff time.Time) ([]int, error) {
+	query := `SELECT id FROM users WHERE is_active = false AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?`
+	rows, err := r.db.Query(query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users due for purge: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// HardDeleteUser permanently removes a user scheduled for deletion; related rows cascade
+func (r *UserRepository) HardDeleteUser(userID int) error {
+	query := `DELETE FROM users WHERE id = ? AND deletion_scheduled_at IS NOT NULL`
+	_, err := r.db.Exec(query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to hard delete user: %w", err)
+	}
+	return nil
+}
+
+// AnonymizeUser strips personal data from a user scheduled for deletion, keeping the row for referential integrity
+func (r *UserRepository) AnonymizeUser(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	cleanup := []string{
+		`DELETE FROM todos WHERE user_id = ?`,
+		`DELETE FROM todo_series WHERE user_id = ?`,
+		`DELETE FROM tags WHERE user_id = ?`,
+		`DELETE FROM projects WHERE user_id = ?`,
+		`DELETE FROM refresh_tokens WHERE user_id = ?`,
+		`DELETE FROM user_roles WHERE user_id = ?`,
+		`DELETE FROM export_jobs WHERE user_id = ?`,
+		`DELETE FROM email_changes WHERE user_id = ?`,
+		`DELETE FROM user_preferences WHERE user_id = ?`,
+		// Comments on other users' todos keep their place in threads, without their text
+		`DELETE FROM todo_comment_revisions WHERE comment_id IN (SELECT id FROM todo_comments WHERE user_id = ?)`,
+		`UPDATE todo_comments SET content = '', edited_at = NULL WHERE user_id = ?`,
+		// Uploads to other users' todos become orphans, which the attachment sweep removes
+		`UPDATE todo_attachments SET user_id = NULL WHERE user_id = ?`,
+		`DELETE FROM todo_templates WHERE user_id = ?`,
+		// Time logged on other users' todos keeps counting towards them, without notes
+		`UPDATE time_entries SET note = '', ended_at = COALESCE(ended_at, NOW()) WHERE user_id = ?`,
+	}
+	for _, query := range cleanup {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return fmt.Errorf("failed to remove user data: %w", err)
+		}
+	}
+
+	query := `UPDATE users SET username = CONCAT('deleted-', id), email = CONCAT('deleted-', id, '@deleted.invalid'),
+			  password_hash = '', avatar_key = NULL, avatar_url = NULL, deletion_scheduled_at = NULL, updated_at = CURRENT_TIMESTAMP
+			  WHERE id = ? AND deletion_scheduled_at IS NOT NULL`
+	if _, err := tx.Exec(query, userID); err != nil {
+		return fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit anonymization: %w", err)
+	}
+	return nil
+}
+
+// GetAvatarKey returns the storage key prefix of a user's current avatar, or "" when none is set
+func (r *UserRepository) GetAvatarKey(userID int) (string, error) {
+	var key sql.NullString
+	err := r.db.QueryRow(`SELECT avatar_key FROM users WHERE id = ?`, userID).Scan(&key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get avatar: %w", err)
+	}
+	return key.String, nil
+}
+
+// SetAvatar stores a user's avatar key prefix and public URL; empty values clear the avatar
+func (r *UserRepository) SetAvatar(userID int, key, url string) error {
+	query := `UPDATE users SET avatar_key = ?, avatar_url = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := r.db.Exec(query, nullableString(key), nullableString(url), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update avatar: %w", err)
+	}
+	return nil
+}
+
+// GetUserRefreshTokens retrieves the active sessions of a user
+func (r *UserRepository) GetUserRefreshTokens(userID int) ([]model.RefreshToken, error) {
+	query := `SELECT id, user_id, token_hash, expires_at, created_at
+			  FROM refresh_tokens WHERE user_id = ? AND expires_at > NOW() ORDER BY created_at DESC`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []model.RefreshToken
+	for rows.Next() {
+		var token model.RefreshToken
+		if err := rows.Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan refresh token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
 // AssignRoleToUser assigns a role to a user
 func (r *UserRepository) AssignRoleToUser(userID, roleID int) error {
 	query := `INSERT INTO user_roles (user_id, role_id) VALUES (?, ?) 
@@ -134,6 +364,20 @@ func (r *UserRepository) AssignRoleToUser(userID, roleID int) error {
 	return nil
 }
 
+// GetRoleByID retrieves a role by ID
+func (r *UserRepository) GetRoleByID(id int) (*model.Role, error) {
+	role := &model.Role{}
+	var description sql.NullString
+	err := r.db.QueryRow(`SELECT id, name, description, created_at FROM roles WHERE id = ?`, id).Scan(
+		&role.ID, &role.Name, &description, &role.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	role.Description = description.String
+	return role, nil
+}
+
 // RemoveRoleFromUser removes a role from a user
 func (r *UserRepository) RemoveRoleFromUser(userID, roleID int) error {
 	query := `DELETE FROM user_roles WHERE user_id = ? AND role_id = ?`
@@ -277,4 +521,14 @@ func (r *UserRepository) loadUserRoles(user *model.User) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// lockUser locks a user's row within tx, serializing the writes of that user that must not
+// interleave
+func lockUser(tx *sql.Tx, userID int) error {
+	var locked int
+	if err := tx.QueryRow(`SELECT id FROM users WHERE id = ? FOR UPDATE`, userID).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"jmrashed/apps/userApp/model"
+)
+
+type WorkflowRepository struct {
+	db *sql.DB
+}
+
+func NewWorkflowRepository(db *sql.DB) *WorkflowRepository {
+	return &WorkflowRepository{db: db}
+}
+
+// GetWorkflow retrieves a project's statuses in board order; it is empty when the project
+// uses the default workflow
+func (r *WorkflowRepository) GetWorkflow(projectID int) (model.Workflow, error) {
+	query := `SELECT status_key, name, done, COALESCE(transitions, '') FROM project_statuses
+			  WHERE project_id = ? ORDER BY position, id`
+
+	rows, err := r.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workflow: %w", err)
+	}
+	defer rows.Close()
+
+	workflow := model.Workflow{}
+	for rows.Next() {
+		var status model.WorkflowStatus
+		var transitions string
+		if err := rows.Scan(&status.Key, &status.Name, &status.Done, &transitions); err != nil {
+			return nil, fmt.Errorf("failed to scan workflow status: %w", err)
+		}
+		status.Transitions = splitList(transitions)
+		workflow = append(workflow, status)
+	}
+	return workflow, rows.Err()
+}
+
+// ReplaceWorkflow replaces a project's statuses
+func (r *WorkflowRepository) ReplaceWorkflow(projectID int, workflow model.Workflow) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM project_statuses WHERE project_id = ?`, projectID); err != nil {
+		return fmt.Errorf("failed to clear workflow: %w", err)
+	}
+
+	for i, status := range workflow {
+		_, err := tx.Exec(`INSERT INTO project_statuses (project_id, status_key, name, done, transitions, position)
+				  VALUES (?, ?, ?, ?, ?, ?)`, projectID, status.Key, status.Name, status.Done, strings.Join(status.Transitions, ","), i)
+		if err != nil {
+			return fmt.Errorf("failed to save workflow status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit workflow: %w", err)
+	}
+	return nil
+}
@@ -9,9 +9,11 @@ import (
 	"jmrashed/apps/userApp/database"
 	"jmrashed/apps/userApp/handlers"
 	"jmrashed/apps/userApp/middleware"
+	"jmrashed/apps/userApp/notify"
 	"jmrashed/apps/userApp/repository"
 	"jmrashed/apps/userApp/seeder"
 	"jmrashed/apps/userApp/service"
+	"jmrashed/apps/userApp/storage"
 
 	"github.com/gorilla/mux"
 	"github.com/swaggo/http-swagger"
@@ -42,15 +44,104 @@ func SetupRoutes() {
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.DB)
 	todoRepo := repository.NewTodoRepository(db.DB)
+	auditRepo := repository.NewAuditRepository(db.DB)
+	exportRepo := repository.NewExportRepository(db.DB)
+	settingsRepo := repository.NewSettingsRepository(db.DB)
+	invitationRepo := repository.NewInvitationRepository(db.DB)
+	orgRepo := repository.NewOrganizationRepository(db.DB)
+	prefRepo := repository.NewPreferenceRepository(db.DB)
+	emailChangeRepo := repository.NewEmailChangeRepository(db.DB)
+	seriesRepo := repository.NewSeriesRepository(db.DB)
+	tagRepo := repository.NewTagRepository(db.DB)
+	projectRepo := repository.NewProjectRepository(db.DB)
+	checklistRepo := repository.NewChecklistRepository(db.DB)
+	workflowRepo := repository.NewWorkflowRepository(db.DB)
+	assigneeRepo := repository.NewAssigneeRepository(db.DB)
+	commentRepo := repository.NewCommentRepository(db.DB)
+	attachmentRepo := repository.NewAttachmentRepository(db.DB)
+	revisionRepo := repository.NewRevisionRepository(db.DB)
+	dependencyRepo := repository.NewDependencyRepository(db.DB)
+	timeRepo := repository.NewTimeRepository(db.DB)
+	templateRepo := repository.NewTemplateRepository(db.DB)
+
+	// Initialize blob storage
+	blobStore, err := storage.New(service.DefaultStorageConfig())
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	// Initialize notifications
+	notifier, err := notify.New(service.DefaultNotifyConfig())
+	if err != nil {
+		log.Fatal("Failed to initialize notifications:", err)
+	}
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo)
-	todoService := service.NewTodoService(todoRepo)
+	auditService := service.NewAuditService(auditRepo, service.DefaultAuditConfig())
+	registrationService := service.NewRegistrationService(settingsRepo, invitationRepo, orgRepo, userRepo, auditService, service.DefaultRegistrationSettings())
+	emailChangeService := service.NewEmailChangeService(userRepo, emailChangeRepo, notifier, auditService, service.DefaultEmailChangeConfig())
+	authService := service.NewAuthService(userRepo, auditService, registrationService, emailChangeService)
+	prefService := service.NewPreferenceService(prefRepo)
+	recurrenceService := service.NewRecurrenceService(seriesRepo, todoRepo, prefService)
+	tagService := service.NewTagService(tagRepo)
+	workflowService := service.NewWorkflowService(workflowRepo, projectRepo, todoRepo, auditService)
+	projectService := service.NewProjectService(projectRepo, todoRepo, auditService, workflowService)
+	dependencyService := service.NewDependencyService(dependencyRepo, todoRepo, prefService, projectService)
+	subtaskService := service.NewSubtaskService(todoRepo, checklistRepo, workflowService, dependencyService,
+		service.DefaultSubtaskConfig())
+	positionService := service.NewPositionService(todoRepo, service.DefaultPositionConfig())
+	nextService := service.NewNextService(todoRepo, service.DefaultNextConfig())
+	assigneeService := service.NewAssigneeService(assigneeRepo, userRepo, todoRepo, prefService, notifier)
+	commentService := service.NewCommentService(commentRepo, userRepo, assigneeService, prefService, notifier)
+	historyService := service.NewHistoryService(revisionRepo, assigneeService, prefService, service.DefaultHistoryConfig())
+	todoService := service.NewTodoService(todoRepo, auditService, prefService, recurrenceService, tagService, projectService,
+		subtaskService, workflowService, positionService, nextService, assigneeService, commentService, historyService,
+		dependencyService)
+	trashService := service.NewTrashService(todoRepo, todoService, auditService, service.DefaultTrashConfig())
+	archiveService := service.NewArchiveService(todoRepo, todoService, prefService, auditService, service.DefaultArchiveConfig())
+	bulkService := service.NewBulkService(todoRepo, todoService, projectService, assigneeService, auditService,
+		service.DefaultBulkConfig())
+	timeService := service.NewTimeService(timeRepo, tagRepo, assigneeService, prefService, projectService, tagService,
+		service.DefaultTimeConfig())
+	templateService := service.NewTemplateService(templateRepo, orgRepo, todoRepo, todoService, projectService, workflowService,
+		tagService, subtaskService, auditService, service.DefaultTemplateConfig())
+	reminderService := service.NewReminderService(todoRepo, userRepo, prefService, notifier, service.DefaultReminderConfig())
+	attachmentService := service.NewAttachmentService(attachmentRepo, assigneeService, prefService, blobStore,
+		service.DefaultAttachmentConfig())
+	avatarService := service.NewAvatarService(userRepo, blobStore, auditService, service.DefaultAvatarConfig())
+	accountService := service.NewAccountService(userRepo, todoRepo, exportRepo, auditRepo, auditService, avatarService, service.DefaultAccountConfig())
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	todoHandler := handlers.NewTodoHandler(todoService)
+	todoHandler := handlers.NewTodoHandler(todoService, prefService, recurrenceService, projectService)
 	healthHandler := handlers.NewHealthHandler(db.DB)
+	adminHandler := handlers.NewAdminHandler(authService, auditService, registrationService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	prefHandler := handlers.NewPreferenceHandler(prefService)
+	avatarHandler := handlers.NewAvatarHandler(avatarService)
+	emailChangeHandler := handlers.NewEmailChangeHandler(emailChangeService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	projectHandler := handlers.NewProjectHandler(projectService)
+	checklistHandler := handlers.NewChecklistHandler(subtaskService)
+	workflowHandler := handlers.NewWorkflowHandler(workflowService, todoService)
+	commentHandler := handlers.NewCommentHandler(commentService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	historyHandler := handlers.NewHistoryHandler(historyService, todoService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	archiveHandler := handlers.NewArchiveHandler(archiveService)
+	bulkHandler := handlers.NewBulkHandler(bulkService)
+	templateHandler := handlers.NewTemplateHandler(templateService)
+	dependencyHandler := handlers.NewDependencyHandler(dependencyService, todoService)
+	timeHandler := handlers.NewTimeHandler(timeService)
+
+	// Start background workers
+	auditService.StartRetentionWorker()
+	accountService.StartPurgeWorker()
+	reminderService.StartReminderWorker()
+	positionService.StartRebalanceWorker()
+	attachmentService.StartCleanupWorker()
+	trashService.StartPurgeWorker()
+	archiveService.StartArchiveWorker()
 
 	// Initialize middleware
 	rateLimiter := middleware.NewRateLimiter(rate.Every(time.Minute), 60) // 60 requests per minute
@@ -60,6 +151,7 @@ func SetupRoutes() {
 	router := mux.NewRouter().StrictSlash(true)
 
 	// Apply global middleware
+	router.Use(middleware.RequestID)
 	router.Use(middleware.CORS)
 	router.Use(middleware.Logging)
 	router.Use(middleware.RateLimit(rateLimiter))
@@ -69,6 +161,11 @@ func SetupRoutes() {
 	staticRouter.Use(middleware.CacheMiddleware(cache))
 	staticRouter.PathPrefix("/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
 
+	// Locally stored uploads such as avatars
+	if localStore, ok := blobStore.(*storage.LocalStore); ok {
+		router.PathPrefix("/media/").Handler(http.StripPrefix("/media/", handlers.MediaFileServer(localStore.Root())))
+	}
+
 	// Health check
 	router.HandleFunc("/health", healthHandler.HealthCheck).Methods("GET")
 
@@ -92,39 +189,160 @@ func SetupRoutes() {
 	public.HandleFunc("/register", authHandler.Register).Methods("POST")
 	public.HandleFunc("/login", authHandler.Login).Methods("POST")
 	public.HandleFunc("/refresh", authHandler.RefreshToken).Methods("POST")
+	public.HandleFunc("/account/recover", accountHandler.RecoverAccount).Methods("POST")
+	public.HandleFunc("/email-change/confirm", emailChangeHandler.ConfirmEmailChange).Methods("POST")
+	public.HandleFunc("/email-change/cancel", emailChangeHandler.CancelEmailChange).Methods("POST")
+	public.HandleFunc("/attachments/{id:[0-9]+}/download", attachmentHandler.DownloadAttachment).Methods("GET")
 
 	// Protected routes (authentication required)
 	protected := api.PathPrefix("").Subrouter()
 	protected.Use(middleware.AuthMiddleware)
+	protected.Use(middleware.AuditImpersonation(auditService))
 
 	// User profile routes
 	protected.HandleFunc("/profile", authHandler.GetProfile).Methods("GET")
-	protected.HandleFunc("/profile", authHandler.UpdateProfile).Methods("PUT")
-	protected.HandleFunc("/change-password", authHandler.ChangePassword).Methods("POST")
+	protected.Handle("/profile", middleware.DenyImpersonation(http.HandlerFunc(authHandler.UpdateProfile))).Methods("PUT")
+	protected.Handle("/change-password", middleware.DenyImpersonation(http.HandlerFunc(authHandler.ChangePassword))).Methods("POST")
 	protected.HandleFunc("/logout", authHandler.Logout).Methods("POST")
-	protected.HandleFunc("/logout-all", authHandler.LogoutAll).Methods("POST")
+	protected.Handle("/logout-all", middleware.DenyImpersonation(http.HandlerFunc(authHandler.LogoutAll))).Methods("POST")
+
+	// Preference routes (impersonators may only read them)
+	protected.HandleFunc("/me/preferences", prefHandler.GetPreferences).Methods("GET")
+	protected.Handle("/me/preferences", middleware.DenyImpersonation(http.HandlerFunc(prefHandler.UpdatePreferences))).Methods("PATCH")
+
+	// Account data routes (not available to impersonators)
+	me := protected.PathPrefix("/me").Subrouter()
+	me.Use(middleware.DenyImpersonation)
+	me.HandleFunc("", accountHandler.DeleteAccount).Methods("DELETE")
+	me.HandleFunc("/export", accountHandler.RequestExport).Methods("POST")
+	me.HandleFunc("/export", accountHandler.GetExport).Methods("GET")
+	me.HandleFunc("/export/{id:[0-9]+}/download", accountHandler.DownloadExport).Methods("GET")
+	me.HandleFunc("/avatar", avatarHandler.UploadAvatar).Methods("PUT")
+	me.HandleFunc("/avatar", avatarHandler.DeleteAvatar).Methods("DELETE")
+	me.HandleFunc("/attachments/usage", attachmentHandler.GetAttachmentUsage).Methods("GET")
+	me.HandleFunc("/email-change", emailChangeHandler.CancelPendingEmailChange).Methods("DELETE")
 
 	// Todo routes with permission-based access
 	todos := protected.PathPrefix("/todos").Subrouter()
 	todos.Use(middleware.RequirePermission("read_todos"))
 	todos.HandleFunc("", todoHandler.GetUserTodos).Methods("GET")
+	todos.HandleFunc("/next", todoHandler.GetNextTodos).Methods("GET")
+	todos.HandleFunc("/trash", trashHandler.ListTrash).Methods("GET")
+	// Bulk operations check write_todos or delete_todos per operation
+	todos.HandleFunc("/bulk", bulkHandler.ExecuteBulk).Methods("POST")
 	todos.HandleFunc("/{id:[0-9]+}", todoHandler.GetTodo).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/occurrences", todoHandler.GetOccurrences).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/tree", todoHandler.GetTodoTree).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/comments", commentHandler.ListComments).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/comments/{commentId:[0-9]+}/history", commentHandler.GetCommentHistory).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/attachments", attachmentHandler.ListAttachments).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}", attachmentHandler.GetAttachment).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/history", historyHandler.GetTodoHistory).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/dependencies", dependencyHandler.GetDependencies).Methods("GET")
+	todos.HandleFunc("/{id:[0-9]+}/time-entries", timeHandler.ListTimeEntries).Methods("GET")
+	todos.HandleFunc("/recurrence/preview", todoHandler.PreviewRecurrence).Methods("POST")
 	
 	// Todo creation/modification requires write permission
 	todosWrite := todos.PathPrefix("").Subrouter()
 	todosWrite.Use(middleware.RequirePermission("write_todos"))
 	todosWrite.HandleFunc("", todoHandler.CreateTodo).Methods("POST")
 	todosWrite.HandleFunc("/{id:[0-9]+}", todoHandler.UpdateTodo).Methods("PUT")
+	todosWrite.HandleFunc("/move", projectHandler.MoveTodos).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/move", todoHandler.MoveTodo).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/checklist", checklistHandler.AddItem).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/checklist/{itemId:[0-9]+}", checklistHandler.UpdateItem).Methods("PUT")
+	todosWrite.HandleFunc("/{id:[0-9]+}/checklist/{itemId:[0-9]+}", checklistHandler.DeleteItem).Methods("DELETE")
+	todosWrite.HandleFunc("/{id:[0-9]+}/comments", commentHandler.CreateComment).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/comments/{commentId:[0-9]+}", commentHandler.UpdateComment).Methods("PUT")
+	todosWrite.HandleFunc("/{id:[0-9]+}/comments/{commentId:[0-9]+}", commentHandler.DeleteComment).Methods("DELETE")
+	todosWrite.HandleFunc("/{id:[0-9]+}/attachments", attachmentHandler.UploadAttachment).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/attachments/{attachmentId:[0-9]+}", attachmentHandler.DeleteAttachment).Methods("DELETE")
+	todosWrite.HandleFunc("/{id:[0-9]+}/restore/{version:[0-9]+}", historyHandler.RestoreTodo).Methods("POST")
+	todosWrite.HandleFunc("/trash/{id:[0-9]+}/restore", trashHandler.RestoreTodo).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/archive", archiveHandler.ArchiveTodo).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/unarchive", archiveHandler.UnarchiveTodo).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/dependencies", dependencyHandler.AddDependency).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/dependencies/{blockedById:[0-9]+}", dependencyHandler.RemoveDependency).Methods("DELETE")
+	todosWrite.HandleFunc("/{id:[0-9]+}/timer/start", timeHandler.StartTimer).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/time-entries", timeHandler.CreateTimeEntry).Methods("POST")
+	todosWrite.HandleFunc("/{id:[0-9]+}/time-entries/{entryId:[0-9]+}", timeHandler.UpdateTimeEntry).Methods("PUT")
+	todosWrite.HandleFunc("/{id:[0-9]+}/time-entries/{entryId:[0-9]+}", timeHandler.DeleteTimeEntry).Methods("DELETE")
 	
 	// Todo deletion requires delete permission
 	todosDelete := todos.PathPrefix("").Subrouter()
 	todosDelete.Use(middleware.RequirePermission("delete_todos"))
 	todosDelete.HandleFunc("/{id:[0-9]+}", todoHandler.DeleteTodo).Methods("DELETE")
+	todosDelete.HandleFunc("/trash", trashHandler.EmptyTrash).Methods("DELETE")
+	todosDelete.HandleFunc("/trash/{id:[0-9]+}", trashHandler.DeleteTodoForever).Methods("DELETE")
+
+	// Tag routes; managing tags requires write permission
+	tags := protected.PathPrefix("/tags").Subrouter()
+	tags.Use(middleware.RequirePermission("read_todos"))
+	tags.HandleFunc("", tagHandler.ListTags).Methods("GET")
+
+	tagsWrite := tags.PathPrefix("").Subrouter()
+	tagsWrite.Use(middleware.RequirePermission("write_todos"))
+	tagsWrite.HandleFunc("", tagHandler.CreateTag).Methods("POST")
+	tagsWrite.HandleFunc("/{id:[0-9]+}", tagHandler.UpdateTag).Methods("PUT")
+	tagsWrite.HandleFunc("/{id:[0-9]+}", tagHandler.DeleteTag).Methods("DELETE")
+
+	// Project routes; changing projects requires write permission
+	projects := protected.PathPrefix("/projects").Subrouter()
+	projects.Use(middleware.RequirePermission("read_todos"))
+	projects.HandleFunc("", projectHandler.ListProjects).Methods("GET")
+	projects.HandleFunc("/{id:[0-9]+}", projectHandler.GetProject).Methods("GET")
+	projects.HandleFunc("/{id:[0-9]+}/todos", todoHandler.GetProjectTodos).Methods("GET")
+	projects.HandleFunc("/{id:[0-9]+}/workflow", workflowHandler.GetWorkflow).Methods("GET")
+	projects.HandleFunc("/{id:[0-9]+}/board", workflowHandler.GetBoard).Methods("GET")
+	projects.HandleFunc("/{id:[0-9]+}/dependencies", dependencyHandler.GetGraph).Methods("GET")
+
+	projectsWrite := projects.PathPrefix("").Subrouter()
+	projectsWrite.Use(middleware.RequirePermission("write_todos"))
+	projectsWrite.HandleFunc("", projectHandler.CreateProject).Methods("POST")
+	projectsWrite.HandleFunc("/{id:[0-9]+}", projectHandler.UpdateProject).Methods("PUT")
+	projectsWrite.HandleFunc("/{id:[0-9]+}", projectHandler.DeleteProject).Methods("DELETE")
+	projectsWrite.HandleFunc("/{id:[0-9]+}/workflow", workflowHandler.UpdateWorkflow).Methods("PUT")
+	projectsWrite.HandleFunc("/{id:[0-9]+}/board/move", workflowHandler.MoveOnBoard).Methods("POST")
+
+	// Template routes; changing or instantiating templates requires write permission
+	templates := protected.PathPrefix("/templates").Subrouter()
+	templates.Use(middleware.RequirePermission("read_todos"))
+	templates.HandleFunc("", templateHandler.ListTemplates).Methods("GET")
+	templates.HandleFunc("/{id:[0-9]+}", templateHandler.GetTemplate).Methods("GET")
+
+	templatesWrite := templates.PathPrefix("").Subrouter()
+	templatesWrite.Use(middleware.RequirePermission("write_todos"))
+	templatesWrite.HandleFunc("", templateHandler.CreateTemplate).Methods("POST")
+	templatesWrite.HandleFunc("/from-project", templateHandler.CreateTemplateFromProject).Methods("POST")
+	templatesWrite.HandleFunc("/{id:[0-9]+}", templateHandler.UpdateTemplate).Methods("PUT")
+	templatesWrite.HandleFunc("/{id:[0-9]+}", templateHandler.DeleteTemplate).Methods("DELETE")
+	templatesWrite.HandleFunc("/{id:[0-9]+}/instantiate", templateHandler.InstantiateTemplate).Methods("POST")
+
+	// Time tracking routes; stopping the timer requires write permission
+	timeTracking := protected.PathPrefix("/time").Subrouter()
+	timeTracking.Use(middleware.RequirePermission("read_todos"))
+	timeTracking.HandleFunc("/timer", timeHandler.GetTimer).Methods("GET")
+	timeTracking.HandleFunc("/report", timeHandler.GetTimeReport).Methods("GET")
+
+	timeTrackingWrite := timeTracking.PathPrefix("").Subrouter()
+	timeTrackingWrite.Use(middleware.RequirePermission("write_todos"))
+	timeTrackingWrite.HandleFunc("/timer/stop", timeHandler.StopTimer).Methods("POST")
 
 	// Admin routes (admin role required)
 	admin := protected.PathPrefix("/admin").Subrouter()
 	admin.Use(middleware.RequireRole("admin"))
 	admin.HandleFunc("/todos", todoHandler.GetAllTodos).Methods("GET")
+	admin.HandleFunc("/audit-events", adminHandler.ListAuditEvents).Methods("GET")
+	admin.HandleFunc("/audit-events/export", adminHandler.ExportAuditEvents).Methods("GET")
+	admin.HandleFunc("/audit-events/verify", adminHandler.VerifyAuditChain).Methods("GET")
+	admin.HandleFunc("/settings/registration", adminHandler.GetRegistrationSettings).Methods("GET")
+	admin.HandleFunc("/settings/registration", adminHandler.UpdateRegistrationSettings).Methods("PUT")
+	admin.HandleFunc("/invitations", adminHandler.CreateInvitation).Methods("POST")
+	admin.HandleFunc("/invitations", adminHandler.ListInvitations).Methods("GET")
+	admin.HandleFunc("/invitations/{id:[0-9]+}", adminHandler.RevokeInvitation).Methods("DELETE")
+	admin.HandleFunc("/organizations", adminHandler.CreateOrganization).Methods("POST")
+	admin.HandleFunc("/organizations", adminHandler.ListOrganizations).Methods("GET")
+	admin.Handle("/users/{id:[0-9]+}/impersonate", middleware.DenyImpersonation(http.HandlerFunc(adminHandler.ImpersonateUser))).Methods("POST")
 
 	// Moderator routes (moderator or admin role required)
 	moderator := protected.PathPrefix("/moderator").Subrouter()
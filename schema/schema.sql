@@ -7,6 +7,9 @@ CREATE TABLE IF NOT EXISTS users (
     email VARCHAR(100) UNIQUE NOT NULL,
     password_hash VARCHAR(255) NOT NULL,
     is_active BOOLEAN DEFAULT TRUE,
+    deletion_scheduled_at TIMESTAMP NULL DEFAULT NULL,
+    avatar_key VARCHAR(255) NULL DEFAULT NULL,
+    avatar_url VARCHAR(512) NULL DEFAULT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
@@ -70,12 +73,360 @@ CREATE TABLE IF NOT EXISTS todos (
     completed BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    due_at TIMESTAMP NULL DEFAULT NULL,
+    remind_at TIMESTAMP NULL DEFAULT NULL,
+    reminded_at TIMESTAMP NULL DEFAULT NULL,
+    completed_at TIMESTAMP NULL DEFAULT NULL,
+    series_id INT NULL DEFAULT NULL,
+    occurrence_at TIMESTAMP NULL DEFAULT NULL,
+    project_id INT NULL DEFAULT NULL,
+    parent_id INT NULL DEFAULT NULL,
+    auto_complete BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(50) NOT NULL DEFAULT 'todo',
+    board_position INT NOT NULL DEFAULT 0,
+    position VARCHAR(255) CHARACTER SET ascii COLLATE ascii_bin NOT NULL DEFAULT '',
+    priority TINYINT NOT NULL DEFAULT 0,
+    archived_at TIMESTAMP NULL DEFAULT NULL,
+    deleted_at TIMESTAMP NULL DEFAULT NULL,
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
     INDEX idx_user_id (user_id),
     INDEX idx_completed (completed),
+    INDEX idx_todos_due_at (user_id, due_at),
+    INDEX idx_todos_remind_at (remind_at),
+    INDEX idx_todos_series (series_id, occurrence_at),
+    INDEX idx_todos_project (project_id, completed),
+    INDEX idx_todos_parent (parent_id, completed),
+    INDEX idx_todos_board (project_id, status, board_position),
+    INDEX idx_todos_position (user_id, position),
+    INDEX idx_todos_priority (user_id, completed, priority),
+    INDEX idx_todos_archived (user_id, archived_at),
+    INDEX idx_todos_deleted_at (deleted_at),
     FULLTEXT idx_search (title, content)
 );
 
+-- Recurring todo series; occurrences are todos with series_id set
+CREATE TABLE IF NOT EXISTS todo_series (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    rule VARCHAR(255) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    exceptions TEXT,
+    title VARCHAR(200) NOT NULL,
+    content TEXT,
+    remind_offset_seconds INT NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Checklist items embedded in a todo
+CREATE TABLE IF NOT EXISTS checklist_items (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    todo_id INT NOT NULL,
+    content VARCHAR(500) NOT NULL,
+    checked BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    INDEX idx_checklist_items_todo (todo_id, position)
+);
+
+-- Projects group a user's todos; todos.project_id is cleared when a project is deleted
+CREATE TABLE IF NOT EXISTS projects (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    color VARCHAR(9) NOT NULL DEFAULT '#3b82f6',
+    archived BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_projects_user (user_id, archived, position)
+);
+
+-- Workflow statuses of a project, in board order; projects without rows use the default workflow
+CREATE TABLE IF NOT EXISTS project_statuses (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    project_id INT NOT NULL,
+    status_key VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    transitions TEXT,
+    position INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+    UNIQUE KEY uniq_project_status (project_id, status_key)
+);
+
+-- Per-user todo tags
+CREATE TABLE IF NOT EXISTS tags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    color VARCHAR(9) NOT NULL DEFAULT '#6b7280',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE KEY uniq_tags_user_name (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS todo_assignees (
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    assigned_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (todo_id, user_id),
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (assigned_by) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_todo_assignees_user (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS todo_dependencies (
+    todo_id INT NOT NULL,
+    blocked_by_id INT NOT NULL,
+    created_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (todo_id, blocked_by_id),
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_by_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_todo_dependencies_blocker (blocked_by_id)
+);
+
+CREATE TABLE IF NOT EXISTS time_entries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    started_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NULL DEFAULT NULL,
+    note VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_time_entries_todo (todo_id, started_at),
+    INDEX idx_time_entries_user (user_id, started_at),
+    INDEX idx_time_entries_running (user_id, ended_at)
+);
+
+CREATE TABLE IF NOT EXISTS todo_comments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    todo_id INT NOT NULL,
+    user_id INT NOT NULL,
+    parent_id INT NULL,
+    content TEXT NOT NULL,
+    edited_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (parent_id) REFERENCES todo_comments(id) ON DELETE CASCADE,
+    INDEX idx_todo_comments_todo (todo_id, parent_id, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS todo_comment_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    comment_id INT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (comment_id) REFERENCES todo_comments(id) ON DELETE CASCADE,
+    INDEX idx_todo_comment_revisions_comment (comment_id, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS todo_comment_mentions (
+    comment_id INT NOT NULL,
+    user_id INT NOT NULL,
+    PRIMARY KEY (comment_id, user_id),
+    FOREIGN KEY (comment_id) REFERENCES todo_comments(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Rows whose todo or uploader is deleted lose that reference and are swept, blob first
+CREATE TABLE IF NOT EXISTS todo_attachments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    todo_id INT NULL,
+    user_id INT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE SET NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_todo_attachments_todo (todo_id, created_at),
+    INDEX idx_todo_attachments_user (user_id)
+);
+
+-- Versioned history of todo edits. Version 1 is the todo as it was before its first recorded
+-- change; only the newest versions of each todo are kept.
+CREATE TABLE IF NOT EXISTS todo_revisions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    todo_id INT NOT NULL,
+    version INT NOT NULL,
+    actor_id INT NULL,
+    restored_from INT NULL,
+    changes TEXT NOT NULL,
+    snapshot TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY uniq_todo_revisions_version (todo_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id INT NOT NULL,
+    tag_id INT NOT NULL,
+    PRIMARY KEY (todo_id, tag_id),
+    FOREIGN KEY (todo_id) REFERENCES todos(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE,
+    INDEX idx_todo_tags_tag (tag_id)
+);
+
+-- Application settings adjustable at runtime by admins, and the audit chain head
+CREATE TABLE IF NOT EXISTS app_settings (
+    name VARCHAR(100) PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_by INT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Seed the row audit log writers lock to append to the hash chain one at a time
+INSERT IGNORE INTO app_settings (name, value) VALUES ('audit_chain_head', '');
+
+-- Audit events table (append-only, each entry chained to the previous one by hash)
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_id INT NULL,
+    impersonator_id INT NULL,
+    action VARCHAR(100) NOT NULL,
+    target_type VARCHAR(50),
+    target_id VARCHAR(64),
+    before_state TEXT NULL,
+    after_state TEXT NULL,
+    ip_address VARCHAR(64),
+    user_agent VARCHAR(255),
+    request_id VARCHAR(64),
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    INDEX idx_actor_id (actor_id),
+    INDEX idx_impersonator_id (impersonator_id),
+    INDEX idx_action (action),
+    INDEX idx_target (target_type, target_id),
+    INDEX idx_request_id (request_id),
+    INDEX idx_created_at (created_at)
+);
+
+-- Organizations table
+CREATE TABLE IF NOT EXISTS organizations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Organization members junction table
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id INT,
+    user_id INT,
+    joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, user_id),
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_id (user_id)
+);
+
+-- Todo templates; items holds the template's todos as JSON
+CREATE TABLE IF NOT EXISTS todo_templates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    organization_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    project_name VARCHAR(100) NOT NULL DEFAULT '',
+    items MEDIUMTEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+    INDEX idx_todo_templates_user (user_id),
+    INDEX idx_todo_templates_organization (organization_id)
+);
+
+-- Invitations table
+CREATE TABLE IF NOT EXISTS invitations (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    email VARCHAR(100) NULL,
+    role_id INT NOT NULL,
+    organization_id INT NULL,
+    created_by INT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    used_by INT NULL,
+    revoked_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE SET NULL,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (used_by) REFERENCES users(id) ON DELETE SET NULL,
+    INDEX idx_expires_at (expires_at)
+);
+
+-- User preferences table
+CREATE TABLE IF NOT EXISTS user_preferences (
+    user_id INT PRIMARY KEY,
+    display_name VARCHAR(100) NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    locale VARCHAR(35) NOT NULL DEFAULT 'en-US',
+    date_format VARCHAR(20) NOT NULL DEFAULT 'YYYY-MM-DD',
+    default_sort VARCHAR(20) NOT NULL DEFAULT 'created_at',
+    default_order VARCHAR(4) NOT NULL DEFAULT 'desc',
+    default_filter VARCHAR(20) NOT NULL DEFAULT 'all',
+    default_limit INT NOT NULL DEFAULT 10,
+    email_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+    reminder_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+    assignment_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+    mention_notifications BOOLEAN NOT NULL DEFAULT TRUE,
+    auto_archive_days INT NOT NULL DEFAULT 0,
+    enforce_dependencies BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Pending and resolved email address changes
+CREATE TABLE IF NOT EXISTS email_changes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    old_email VARCHAR(100) NOT NULL,
+    new_email VARCHAR(100) NOT NULL,
+    confirm_token_hash CHAR(64) NOT NULL UNIQUE,
+    cancel_token_hash CHAR(64) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    expires_at TIMESTAMP NOT NULL,
+    cancel_expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_email_changes_user_status (user_id, status)
+);
+
+-- Personal data export jobs
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    file_path VARCHAR(255),
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP NULL DEFAULT NULL,
+    expires_at TIMESTAMP NULL DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    INDEX idx_user_id (user_id)
+);
+
 -- Insert default roles
 INSERT IGNORE INTO roles (name, description) VALUES 
 ('admin', 'Administrator with full access'),
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"jmrashed/apps/userApp/auth"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Account purge modes
+const (
+	PurgeModeDelete    = "delete"
+	PurgeModeAnonymize = "anonymize"
+)
+
+// AccountConfig holds account lifecycle configuration
+type AccountConfig struct {
+	DeletionGracePeriod time.Duration // How long a deleted account stays recoverable
+	PurgeMode           string        // "delete" removes the user row, "anonymize" strips personal data
+	PurgeInterval       time.Duration // How often the purge and export cleanup run
+	ExportDir           string        // Where export archives are written
+	ExportTTL           time.Duration // How long export archives remain downloadable
+}
+
+// DefaultAccountConfig returns account configuration from the environment
+func DefaultAccountConfig() AccountConfig {
+	return AccountConfig{
+		DeletionGracePeriod: getEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+		PurgeMode:           getEnv("ACCOUNT_PURGE_MODE", PurgeModeDelete),
+		PurgeInterval:       getEnvDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+		ExportDir:           getEnv("EXPORT_DIR", "./data/exports"),
+		ExportTTL:           getEnvDuration("EXPORT_TTL", 7*24*time.Hour),
+	}
+}
+
+type AccountService struct {
+	userRepo      *repository.UserRepository
+	todoRepo      *repository.TodoRepository
+	exportRepo    *repository.ExportRepository
+	auditRepo     *repository.AuditRepository
+	auditService  *AuditService
+	avatarService *AvatarService
+	config        AccountConfig
+	validator     *validator.Validate
+}
+
+func NewAccountService(userRepo *repository.UserRepository, todoRepo *repository.TodoRepository,
+	exportRepo *repository.ExportRepository, auditRepo *repository.AuditRepository,
+	auditService *AuditService, avatarService *AvatarService, config AccountConfig) *AccountService {
+	return &AccountService{
+		userRepo:      userRepo,
+		todoRepo:      todoRepo,
+		exportRepo:    exportRepo,
+		auditRepo:     auditRepo,
+		auditService:  auditService,
+		avatarService: avatarService,
+		config:        config,
+		validator:     validator.New(),
+	}
+}
+
+// RequestExport starts an asynchronous export of the user's data, reusing a job already in progress
+func (s *AccountService) RequestExport(userID int, meta model.RequestMeta) (*model.ExportJob, error) {
+	if latest, err := s.exportRepo.GetLatestJob(userID); err == nil {
+		if latest.Status == model.ExportStatusPending || latest.Status == model.ExportStatusRunning {
+			return latest, nil
+		}
+	}
+
+	job := &model.ExportJob{
+		UserID: userID,
+		Status: model.ExportStatusPending,
+	}
+	if err := s.exportRepo.CreateJob(job); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "account.export_requested",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		After:      map[string]interface{}{"job_id": job.ID},
+	})
+
+	go s.runExport(*job)
+	return job, nil
+}
+
+// GetLatestExport returns the status of the user's most recent export
+func (s *AccountService) GetLatestExport(userID int) (*model.ExportJob, error) {
+	job, err := s.exportRepo.GetLatestJob(userID)
+	if err != nil {
+		return nil, errors.New("no export found")
+	}
+	return job, nil
+}
+
+// OpenExport opens a finished export archive for download
+func (s *AccountService) OpenExport(jobID, userID int) (*os.File, *model.ExportJob, error) {
+	job, err := s.exportRepo.GetJob(jobID, userID)
+	if err != nil {
+		return nil, nil, errors.New("export not found")
+	}
+	if job.Status != model.ExportStatusReady {
+		return nil, nil, fmt.Errorf("export is %s", job.Status)
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		return nil, nil, errors.New("export has expired")
+	}
+
+	file, err := os.Open(job.FilePath)
+	if err != nil {
+		return nil, nil, errors.New("export archive is no longer available")
+	}
+	return file, job, nil
+}
+
+// runExport builds the export archive and records the outcome on the job
+func (s *AccountService) runExport(job model.ExportJob) {
+	job.Status = model.ExportStatusRunning
+	if err := s.exportRepo.UpdateJob(&job); err != nil {
+		log.Printf("Warning: Failed to mark export job %d running: %v", job.ID, err)
+	}
+
+	path, err := s.writeExportArchive(job)
+	now := time.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		log.Printf("ERROR: export job %d failed: %v", job.ID, err)
+		job.Status = model.ExportStatusFailed
+		job.Error = "export failed"
+	} else {
+		expiresAt := now.Add(s.config.ExportTTL)
+		job.Status = model.ExportStatusReady
+		job.FilePath = path
+		job.ExpiresAt = &expiresAt
+	}
+
+	if err := s.exportRepo.UpdateJob(&job); err != nil {
+		log.Printf("ERROR: failed to store export job %d result: %v", job.ID, err)
+	}
+}
+
+// writeExportArchive collects the user's data and writes it as a ZIP of JSON documents
+func (s *AccountService) writeExportArchive(job model.ExportJob) (string, error) {
+	data, err := s.collectUserData(job.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(s.config.ExportDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	token, err := auth.GenerateSecureToken(16)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.config.ExportDir, fmt.Sprintf("export-%d-%s.zip", job.UserID, token))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export archive: %w", err)
+	}
+	defer file.Close()
+
+	if err := data.WriteArchive(file); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// collectUserData gathers everything stored about a user
+func (s *AccountService) collectUserData(userID int) (*model.UserDataExport, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	user.PasswordHash = ""
+
+	todos, err := s.todoRepo.GetAllTodosByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.userRepo.GetUserRefreshTokens(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Events the user performed plus events performed on the user's account
+	seen := make(map[int64]bool)
+	var events []model.AuditEvent
+	collect := func(event *model.AuditEvent) error {
+		if !seen[event.ID] {
+			seen[event.ID] = true
+			events = append(events, *event)
+		}
+		return nil
+	}
+	if err := s.auditRepo.EachEvent(model.AuditEventFilter{ActorID: userID}, collect); err != nil {
+		return nil, err
+	}
+	if err := s.auditRepo.EachEvent(model.AuditEventFilter{TargetType: "user", TargetID: strconv.Itoa(userID)}, collect); err != nil {
+		return nil, err
+	}
+
+	return &model.UserDataExport{
+		ExportedAt:  time.Now(),
+		Profile:     *user,
+		Roles:       user.Roles,
+		Todos:       todos,
+		Sessions:    sessions,
+		AuditEvents: events,
+	}, nil
+}
+
+// DeleteAccount deactivates the account after password re-confirmation and schedules it for purging
+func (s *AccountService) DeleteAccount(userID int, req model.DeleteAccountRequest, meta model.RequestMeta) (*model.AccountDeletionResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return nil, errors.New("password is incorrect")
+	}
+
+	scheduledAt := time.Now().Add(s.config.DeletionGracePeriod)
+	if err := s.userRepo.ScheduleDeletion(userID, scheduledAt); err != nil {
+		return nil, err
+	}
+
+	// End every session; the account can only be recovered with its password
+	if err := s.userRepo.DeleteUserRefreshTokens(userID); err != nil {
+		return nil, err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "account.deletion_requested",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		After:      map[string]interface{}{"deletion_scheduled_at": scheduledAt},
+	})
+
+	return &model.AccountDeletionResponse{DeletionScheduledAt: scheduledAt}, nil
+}
+
+// RecoverAccount reactivates an account still inside its deletion grace period
+func (s *AccountService) RecoverAccount(req model.RecoverAccountRequest, meta model.RequestMeta) (*model.User, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	user, err := s.userRepo.GetUserPendingDeletion(req.Username)
+	if err != nil || !auth.CheckPassword(req.Password, user.PasswordHash) {
+		return nil, errors.New("invalid credentials or account not pending deletion")
+	}
+
+	if err := s.userRepo.CancelDeletion(user.ID); err != nil {
+		return nil, err
+	}
+
+	meta.ActorID = user.ID
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "account.recovered",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
+	user.IsActive = true
+	user.DeletionScheduledAt = nil
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// PurgeDeletedAccounts hard-deletes or anonymizes accounts whose grace period has ended
+func (s *AccountService) PurgeDeletedAccounts() (int, error) {
+	ids, err := s.userRepo.GetUsersDueForPurge(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		s.removeExportArchives(id)
+		if err := s.avatarService.RemoveAvatar(id, model.RequestMeta{}); err != nil {
+			log.Printf("Warning: Failed to remove avatar of user %d: %v", id, err)
+		}
+
+		if s.config.PurgeMode == PurgeModeAnonymize {
+			err = s.userRepo.AnonymizeUser(id)
+		} else {
+			err = s.userRepo.HardDeleteUser(id)
+		}
+		if err != nil {
+			log.Printf("ERROR: failed to purge user %d: %v", id, err)
+			continue
+		}
+
+		s.auditService.RecordBestEffort(model.RequestMeta{}, model.AuditEntry{
+			Action:     "account.purged",
+			TargetType: "user",
+			TargetID:   strconv.Itoa(id),
+			After:      map[string]interface{}{"mode": s.config.PurgeMode},
+		})
+		purged++
+	}
+	return purged, nil
+}
+
+// removeExportArchives deletes a user's export files from disk
+func (s *AccountService) removeExportArchives(userID int) {
+	jobs, err := s.exportRepo.GetUserJobs(userID)
+	if err != nil {
+		log.Printf("Warning: Failed to list export jobs for user %d: %v", userID, err)
+		return
+	}
+	for _, job := range jobs {
+		if job.FilePath != "" {
+			os.Remove(job.FilePath)
+		}
+	}
+}
+
+// CleanupExpiredExports deletes expired export archives and their job records
+func (s *AccountService) CleanupExpiredExports() error {
+	jobs, err := s.exportRepo.GetExpiredJobs(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.FilePath != "" {
+			if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("Warning: Failed to remove export archive %s: %v", job.FilePath, err)
+				continue
+			}
+		}
+		if err := s.exportRepo.DeleteJob(job.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartPurgeWorker periodically purges deleted accounts and expired exports
+func (s *AccountService) StartPurgeWorker() {
+	if s.config.PurgeInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.PurgeInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			purged, err := s.PurgeDeletedAccounts()
+			if err != nil {
+				log.Printf("Warning: Failed to purge deleted accounts: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d deleted accounts (%s)", purged, s.config.PurgeMode)
+			}
+
+			if err := s.CleanupExpiredExports(); err != nil {
+				log.Printf("Warning: Failed to clean up expired exports: %v", err)
+			}
+		}
+	}()
+}
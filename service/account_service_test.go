@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeDeletedAccountsAnonymize(t *testing.T) {
+	env := newTestEnv(t)
+	env.accountService.config.PurgeMode = PurgeModeAnonymize
+
+	user := env.createUser(t)
+	env.createTodo(t, user.ID, "Private", nil)
+	pending := env.createUser(t)
+	require.NoError(t, env.userRepo.ScheduleDeletion(user.ID, time.Now().Add(-time.Minute)))
+	require.NoError(t, env.userRepo.ScheduleDeletion(pending.ID, time.Now().Add(time.Hour)))
+
+	purged, err := env.accountService.PurgeDeletedAccounts()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, 1)
+
+	// The user row stays, without personal data or todos
+	var username, email, passwordHash string
+	err = env.db.QueryRow(`SELECT username, email, password_hash FROM users WHERE id = ?`, user.ID).
+		Scan(&username, &email, &passwordHash)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("deleted-%d", user.ID), username)
+	assert.Equal(t, fmt.Sprintf("deleted-%d@deleted.invalid", user.ID), email)
+	assert.Empty(t, passwordHash)
+
+	var todos int
+	require.NoError(t, env.db.QueryRow(`SELECT COUNT(*) FROM todos WHERE user_id = ?`, user.ID).Scan(&todos))
+	assert.Equal(t, 0, todos)
+
+	// Accounts still inside their grace period are left alone
+	due, err := env.userRepo.GetUsersDueForPurge(time.Now())
+	require.NoError(t, err)
+	assert.NotContains(t, due, pending.ID)
+	require.NoError(t, env.db.QueryRow(`SELECT username FROM users WHERE id = ?`, pending.ID).Scan(&username))
+	assert.Equal(t, pending.Username, username)
+}
+
+func TestPurgeDeletedAccountsDelete(t *testing.T) {
+	env := newTestEnv(t)
+	env.accountService.config.PurgeMode = PurgeModeDelete
+
+	user := env.createUser(t)
+	env.createTodo(t, user.ID, "Private", nil)
+	require.NoError(t, env.userRepo.ScheduleDeletion(user.ID, time.Now().Add(-time.Minute)))
+
+	purged, err := env.accountService.PurgeDeletedAccounts()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, 1)
+
+	var users, todos int
+	require.NoError(t, env.db.QueryRow(`SELECT COUNT(*) FROM users WHERE id = ?`, user.ID).Scan(&users))
+	require.NoError(t, env.db.QueryRow(`SELECT COUNT(*) FROM todos WHERE user_id = ?`, user.ID).Scan(&todos))
+	assert.Equal(t, 0, users)
+	assert.Equal(t, 0, todos)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+)
+
+// ErrTodoArchived is returned when changing a todo that is archived
+var ErrTodoArchived = errors.New("todo is archived; unarchive it first")
+
+// ArchiveConfig holds archive configuration
+type ArchiveConfig struct {
+	Interval time.Duration // How often completed todos are auto-archived
+	Batch    int           // Todos auto-archived per query
+}
+
+// DefaultArchiveConfig returns archive configuration from the environment
+func DefaultArchiveConfig() ArchiveConfig {
+	return ArchiveConfig{
+		Interval: getEnvDuration("ARCHIVE_INTERVAL", time.Hour),
+		Batch:    getEnvInt("ARCHIVE_BATCH_SIZE", 500),
+	}
+}
+
+type ArchiveService struct {
+	todoRepo     *repository.TodoRepository
+	todoService  *TodoService
+	prefService  *PreferenceService
+	auditService *AuditService
+	config       ArchiveConfig
+}
+
+func NewArchiveService(todoRepo *repository.TodoRepository, todoService *TodoService, prefService *PreferenceService,
+	auditService *AuditService, config ArchiveConfig) *ArchiveService {
+	return &ArchiveService{
+		todoRepo:     todoRepo,
+		todoService:  todoService,
+		prefService:  prefService,
+		auditService: auditService,
+		config:       config,
+	}
+}
+
+// Archive archives a completed top-level todo owned by userID along with its subtasks
+func (s *ArchiveService) Archive(id, userID int, meta model.RequestMeta) (*model.Todo, error) {
+	todo, err := s.todoRepo.GetTodoByID(id)
+	if err != nil || todo.UserID != userID {
+		return nil, fmt.Errorf("todo not found or access denied")
+	}
+	if err := todo.CheckArchive(); err != nil {
+		return nil, err
+	}
+
+	if err := s.todoRepo.ArchiveTodo(id, userID); err != nil {
+		return nil, err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "todo.archived",
+		TargetType: "todo",
+		TargetID:   strconv.Itoa(id),
+	})
+	return s.present(id, userID)
+}
+
+// Unarchive takes a todo owned by userID out of the archive along with the subtasks archived
+// with it
+func (s *ArchiveService) Unarchive(id, userID int, meta model.RequestMeta) (*model.Todo, error) {
+	if err := s.todoRepo.UnarchiveTodo(id, userID); err != nil {
+		return nil, err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "todo.unarchived",
+		TargetType: "todo",
+		TargetID:   strconv.Itoa(id),
+	})
+	return s.present(id, userID)
+}
+
+// ArchiveExpired archives the completed todos of users who turned on auto-archiving once they
+// are older than the user's auto_archive_days, and returns how many were archived
+func (s *ArchiveService) ArchiveExpired() (int, error) {
+	now := time.Now()
+	prefs := map[int]*model.UserPreferences{}
+	archived := 0
+	for {
+		todos, err := s.todoRepo.GetArchivable(now, s.config.Batch)
+		if err != nil {
+			return archived, err
+		}
+		batchArchived := 0
+		for i := range todos {
+			todo := &todos[i]
+			pref, ok := prefs[todo.UserID]
+			if !ok {
+				if pref, err = s.prefService.GetPreferences(todo.UserID); err != nil {
+					return archived, err
+				}
+				prefs[todo.UserID] = pref
+			}
+			if !pref.AutoArchives(todo, now) {
+				continue
+			}
+			if err := s.todoRepo.ArchiveTodo(todo.ID, todo.UserID); err != nil {
+				return archived, err
+			}
+			batchArchived++
+		}
+		archived += batchArchived
+		// Stop once a batch comes back short, or when nothing in it was left to archive
+		if len(todos) < s.config.Batch || batchArchived == 0 {
+			return archived, nil
+		}
+	}
+}
+
+// StartArchiveWorker periodically auto-archives completed todos
+func (s *ArchiveService) StartArchiveWorker() {
+	if s.config.Interval <= 0 || s.config.Batch <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			archived, err := s.ArchiveExpired()
+			if err != nil {
+				log.Printf("Warning: Failed to auto-archive todos: %v", err)
+				continue
+			}
+			if archived > 0 {
+				log.Printf("Auto-archived %d completed todos", archived)
+			}
+		}
+	}()
+}
+
+// present loads a todo for the response after it was archived or unarchived
+func (s *ArchiveService) present(id, userID int) (*model.Todo, error) {
+	todo, err := s.todoRepo.GetTodoByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("todo not found: %w", err)
+	}
+	s.todoService.PresentTodos(userID, todo)
+	return todo, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+
+	"jmrashed/apps/userApp/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveAndUnarchive(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t)
+	other := env.createUser(t)
+	parent := env.createTodo(t, user.ID, "Parent", nil)
+	child := env.createTodo(t, user.ID, "Child", &parent.ID)
+
+	_, err := env.archiveService.Archive(parent.ID, user.ID, model.RequestMeta{})
+	assert.EqualError(t, err, "only completed todos can be archived")
+
+	env.complete(t, user.ID, child.ID)
+	_, err = env.archiveService.Archive(child.ID, user.ID, model.RequestMeta{})
+	assert.EqualError(t, err, "subtasks are archived along with their top-level todo")
+
+	env.complete(t, user.ID, parent.ID)
+	_, err = env.archiveService.Archive(parent.ID, other.ID, model.RequestMeta{})
+	assert.EqualError(t, err, "todo not found or access denied")
+
+	archived, err := env.archiveService.Archive(parent.ID, user.ID, model.RequestMeta{})
+	require.NoError(t, err)
+	assert.NotNil(t, archived.ArchivedAt)
+	stored, err := env.todoRepo.GetTodoByID(child.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.ArchivedAt)
+
+	_, err = env.archiveService.Archive(parent.ID, user.ID, model.RequestMeta{})
+	assert.EqualError(t, err, "todo is already archived")
+
+	unarchived, err := env.archiveService.Unarchive(parent.ID, user.ID, model.RequestMeta{})
+	require.NoError(t, err)
+	assert.Nil(t, unarchived.ArchivedAt)
+	stored, err = env.todoRepo.GetTodoByID(child.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.ArchivedAt)
+}
+
+func TestArchiveExpired(t *testing.T) {
+	env := newTestEnv(t)
+	days := 7
+	user := env.createUser(t)
+	_, err := env.prefService.UpdatePreferences(user.ID, model.UpdatePreferencesRequest{AutoArchiveDays: &days})
+	require.NoError(t, err)
+	manual := env.createUser(t)
+
+	old := env.createTodo(t, user.ID, "Old", nil)
+	subtask := env.createTodo(t, user.ID, "Subtask", &old.ID)
+	recent := env.createTodo(t, user.ID, "Recent", nil)
+	pending := env.createTodo(t, user.ID, "Pending", nil)
+	unset := env.createTodo(t, manual.ID, "Auto-archiving off", nil)
+	for _, todo := range []*model.Todo{subtask, old, recent, unset} {
+		env.complete(t, todo.UserID, todo.ID)
+	}
+	_, err = env.db.Exec(`UPDATE todos SET completed_at = completed_at - INTERVAL 8 DAY, updated_at = updated_at - INTERVAL 8 DAY
+			  WHERE id IN (?, ?, ?, ?)`, old.ID, subtask.ID, pending.ID, unset.ID)
+	require.NoError(t, err)
+
+	archived, err := env.archiveService.ArchiveExpired()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, archived, 1)
+
+	// Only the old completed todo of the user who turned auto-archiving on is archived, with its
+	// subtask
+	for _, todo := range []*model.Todo{old, subtask, recent, pending, unset} {
+		stored, err := env.todoRepo.GetTodoByID(todo.ID)
+		require.NoError(t, err)
+		assert.Equal(t, todo.ID == old.ID || todo.ID == subtask.ID, stored.ArchivedAt != nil, todo.Title)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/notify"
+	"jmrashed/apps/userApp/repository"
+)
+
+type AssigneeService struct {
+	assigneeRepo *repository.AssigneeRepository
+	userRepo     *repository.UserRepository
+	todoRepo     *repository.TodoRepository
+	prefService  *PreferenceService
+	notifier     notify.Notifier
+}
+
+func NewAssigneeService(assigneeRepo *repository.AssigneeRepository, userRepo *repository.UserRepository,
+	todoRepo *repository.TodoRepository, prefService *PreferenceService, notifier notify.Notifier) *AssigneeService {
+	return &AssigneeService{
+		assigneeRepo: assigneeRepo,
+		userRepo:     userRepo,
+		todoRepo:     todoRepo,
+		prefService:  prefService,
+		notifier:     notifier,
+	}
+}
+
+// ResolveAssignees checks that every user can be assigned todos owned by ownerID and returns
+// the IDs without duplicates
+func (s *AssigneeService) ResolveAssignees(ownerID int, userIDs []int) ([]int, error) {
+	ids := uniqueIDs(userIDs)
+	count, err := s.assigneeRepo.CountAssignable(ownerID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if count != len(ids) {
+		return nil, errors.New("assignees must share an organization or a todo with the todo's owner")
+	}
+	return ids, nil
+}
+
+// NotifyAssignments notifies the users who were assigned to or unassigned from a todo by
+// someone else, once the change has been saved
+func (s *AssigneeService) NotifyAssignments(todo *model.Todo, actorID int, links *model.TodoLinks) {
+	if len(links.Assigned) == 0 && len(links.Unassigned) == 0 {
+		return
+	}
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		log.Printf("Warning: Failed to notify assignees of todo %d: %v", todo.ID, err)
+		return
+	}
+	for _, userID := range links.Assigned {
+		s.notify(userID, actor, todo, true)
+	}
+	for _, userID := range links.Unassigned {
+		s.notify(userID, actor, todo, false)
+	}
+}
+
+// IsAssignee reports whether a user is assigned to a todo
+func (s *AssigneeService) IsAssignee(todoID, userID int) bool {
+	assigned, err := s.assigneeRepo.IsAssignee(todoID, userID)
+	if err != nil {
+		log.Printf("Warning: Failed to check assignee of todo %d: %v", todoID, err)
+		return false
+	}
+	return assigned
+}
+
+// CheckAccess returns a todo the user owns or is assigned to
+func (s *AssigneeService) CheckAccess(todoID, userID int) (*model.Todo, error) {
+	todo, err := s.todoRepo.GetTodoByID(todoID)
+	if err != nil || (todo.UserID != userID && !s.IsAssignee(todoID, userID)) {
+		return nil, fmt.Errorf("todo not found or access denied")
+	}
+	return todo, nil
+}
+
+// LoadAssignees fills in the assignees of each todo
+func (s *AssigneeService) LoadAssignees(todos ...*model.Todo) {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+		todo.Assignees = []model.Assignee{}
+	}
+
+	assignees, err := s.assigneeRepo.GetAssigneesForTodos(ids)
+	if err != nil {
+		log.Printf("Warning: Failed to load todo assignees: %v", err)
+		return
+	}
+	for _, todo := range todos {
+		if todoAssignees, ok := assignees[todo.ID]; ok {
+			todo.Assignees = todoAssignees
+		}
+	}
+}
+
+// notify tells a user they were assigned to or unassigned from a todo, unless they made the
+// change themselves or opted out of assignment notifications
+func (s *AssigneeService) notify(userID int, actor *model.User, todo *model.Todo, assigned bool) {
+	if userID == actor.ID {
+		return
+	}
+	prefs, err := s.prefService.GetPreferences(userID)
+	if err != nil || !prefs.WantsNotification(model.NotificationAssignment) {
+		return
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+
+	if err := s.notifier.Send(assignmentMessage(user, actor, todo, assigned)); err != nil {
+		log.Printf("Warning: Failed to send assignment notification for todo %d: %v", todo.ID, err)
+	}
+}
+
+// assignmentMessage renders an assignment or unassignment notification
+func assignmentMessage(user, actor *model.User, todo *model.Todo, assigned bool) notify.Message {
+	if !assigned {
+		return notify.Message{
+			To:      user.Email,
+			Subject: "Unassigned: " + todo.Title,
+			Body:    fmt.Sprintf("Hi %s,\n\n%s removed you from: %s\n", user.Username, actor.Username, todo.Title),
+		}
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\n%s assigned you to: %s\n", user.Username, actor.Username, todo.Title)
+	if todo.Content != "" {
+		body += "\n" + todo.Content + "\n"
+	}
+	return notify.Message{
+		To:      user.Email,
+		Subject: "Assigned: " + todo.Title,
+		Body:    body,
+	}
+}
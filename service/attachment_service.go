@@ -0,0 +1,318 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"jmrashed/apps/userApp/auth"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+	"jmrashed/apps/userApp/storage"
+)
+
+var (
+	ErrAttachmentTooLarge = errors.New("attachment file is too large")
+	ErrAttachmentQuota    = errors.New("attachment quota exceeded")
+	ErrAttachmentType     = errors.New("attachment file type is not allowed")
+)
+
+// maxFilenameLength matches the filename column
+const maxFilenameLength = 255
+
+// AttachmentConfig holds todo attachment configuration
+type AttachmentConfig struct {
+	MaxUploadBytes  int64         // Largest accepted upload
+	UserQuotaBytes  int64         // Total bytes of attachments a user may store
+	AllowedTypes    []string      // Sniffed MIME types accepted for upload
+	URLTTL          time.Duration // How long signed download URLs stay valid
+	URLSecret       string        // HMAC key for signed download URLs
+	CleanupInterval time.Duration // How often orphaned attachment blobs are removed
+	CleanupBatch    int           // Orphaned attachments removed per cleanup run
+}
+
+// DefaultAttachmentConfig returns attachment configuration from the environment
+func DefaultAttachmentConfig() AttachmentConfig {
+	config := AttachmentConfig{
+		MaxUploadBytes:  int64(getEnvInt("ATTACHMENT_MAX_UPLOAD_BYTES", 10<<20)),
+		UserQuotaBytes:  int64(getEnvInt("ATTACHMENT_USER_QUOTA_BYTES", 100<<20)),
+		URLTTL:          getEnvDuration("ATTACHMENT_URL_TTL", 15*time.Minute),
+		URLSecret:       getEnv("ATTACHMENT_URL_SECRET", os.Getenv("JWT_SECRET")),
+		CleanupInterval: getEnvDuration("ATTACHMENT_CLEANUP_INTERVAL", 5*time.Minute),
+		CleanupBatch:    getEnvInt("ATTACHMENT_CLEANUP_BATCH_SIZE", 100),
+	}
+	allowed := getEnv("ATTACHMENT_ALLOWED_TYPES",
+		"image/png,image/jpeg,image/gif,image/webp,image/bmp,application/pdf,text/plain,application/zip")
+	for _, contentType := range strings.Split(allowed, ",") {
+		if contentType = strings.TrimSpace(strings.ToLower(contentType)); contentType != "" {
+			config.AllowedTypes = append(config.AllowedTypes, contentType)
+		}
+	}
+	return config
+}
+
+type AttachmentService struct {
+	attachmentRepo  *repository.AttachmentRepository
+	assigneeService *AssigneeService
+	prefService     *PreferenceService
+	store           storage.BlobStore
+	signer          *storage.URLSigner
+	config          AttachmentConfig
+}
+
+func NewAttachmentService(attachmentRepo *repository.AttachmentRepository, assigneeService *AssigneeService,
+	prefService *PreferenceService, store storage.BlobStore, config AttachmentConfig) *AttachmentService {
+	if config.URLSecret == "" {
+		// Links signed with a random secret stop working when the server restarts
+		secret, err := auth.GenerateSecureToken(32)
+		if err != nil {
+			log.Fatalf("Failed to generate attachment URL secret: %v", err)
+		}
+		log.Printf("Warning: ATTACHMENT_URL_SECRET is not set; download links will not survive a restart")
+		config.URLSecret = secret
+	}
+
+	return &AttachmentService{
+		attachmentRepo:  attachmentRepo,
+		assigneeService: assigneeService,
+		prefService:     prefService,
+		store:           store,
+		signer:          storage.NewURLSigner(config.URLSecret),
+		config:          config,
+	}
+}
+
+// MaxUploadBytes returns the largest accepted upload
+func (s *AttachmentService) MaxUploadBytes() int64 {
+	return s.config.MaxUploadBytes
+}
+
+// Upload stores a file on a todo the user owns or is assigned to. The content type is sniffed
+// from the data rather than trusted from the client, and the upload counts against the
+// uploader's quota.
+func (s *AttachmentService) Upload(todoID, userID int, filename string, data []byte) (*model.Attachment, error) {
+	if int64(len(data)) > s.config.MaxUploadBytes {
+		return nil, ErrAttachmentTooLarge
+	}
+	contentType, err := s.sniffType(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateSecureToken(16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate attachment key: %w", err)
+	}
+	attachment := &model.Attachment{
+		TodoID:      todoID,
+		UserID:      userID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  fmt.Sprintf("attachments/%d/%s", todoID, token),
+	}
+
+	if err := s.store.Put(attachment.StorageKey, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+	created, err := s.attachmentRepo.CreateAttachment(attachment, s.config.UserQuotaBytes)
+	if err != nil || !created {
+		s.deleteBlob(attachment.StorageKey)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrAttachmentQuota
+	}
+
+	attachment.CreatedAt = time.Now()
+	s.present(userID, attachment)
+	return attachment, nil
+}
+
+// ListAttachments returns a todo's attachments with fresh download URLs
+func (s *AttachmentService) ListAttachments(todoID, userID int) ([]model.Attachment, error) {
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	attachments, err := s.attachmentRepo.GetAttachments(todoID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		s.present(userID, &attachments[i])
+	}
+	return attachments, nil
+}
+
+// GetAttachment returns one of a todo's attachments with a fresh download URL
+func (s *AttachmentService) GetAttachment(todoID, attachmentID, userID int) (*model.Attachment, error) {
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	attachment, err := s.attachmentRepo.GetAttachment(attachmentID, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("attachment not found")
+	}
+	s.present(userID, attachment)
+	return attachment, nil
+}
+
+// DeleteAttachment removes an attachment and its blob. Uploaders can delete their own
+// attachments and todo owners any attachment on their todo.
+func (s *AttachmentService) DeleteAttachment(todoID, attachmentID, userID int) error {
+	todo, err := s.assigneeService.CheckAccess(todoID, userID)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := s.attachmentRepo.GetAttachment(attachmentID, todoID)
+	if err != nil || (attachment.UserID != userID && todo.UserID != userID) {
+		return fmt.Errorf("attachment not found or access denied")
+	}
+	if err := s.attachmentRepo.DeleteAttachment(attachmentID, todoID); err != nil {
+		return err
+	}
+	s.deleteBlob(attachment.StorageKey)
+	return nil
+}
+
+// Usage returns how much of their attachment quota a user has used
+func (s *AttachmentService) Usage(userID int) (*model.AttachmentUsage, error) {
+	used, err := s.attachmentRepo.GetUsage(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.AttachmentUsage{UsedBytes: used, QuotaBytes: s.config.UserQuotaBytes}, nil
+}
+
+// OpenDownload verifies a signed download link and opens the attachment's blob. The caller
+// closes the returned reader.
+func (s *AttachmentService) OpenDownload(attachmentID int, expires, signature string) (io.ReadCloser, *model.Attachment, error) {
+	if err := s.signer.Verify(downloadPath(attachmentID), expires, signature, time.Now()); err != nil {
+		return nil, nil, err
+	}
+
+	attachment, err := s.attachmentRepo.GetAttachmentByID(attachmentID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("attachment not found")
+	}
+	blob, err := s.store.Get(attachment.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return blob, attachment, nil
+}
+
+// CleanupOrphans removes the blobs and records of attachments whose todo or uploader has
+// been deleted, or whose uploader has been anonymized
+func (s *AttachmentService) CleanupOrphans() (int, error) {
+	orphans, err := s.attachmentRepo.GetOrphans(s.config.CleanupBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, orphan := range orphans {
+		// The record is kept until the blob is gone so a failed delete is retried next run
+		if err := s.store.Delete(orphan.StorageKey); err != nil {
+			log.Printf("Warning: Failed to delete attachment %s: %v", orphan.StorageKey, err)
+			continue
+		}
+		if err := s.attachmentRepo.DeleteOrphan(orphan.ID); err != nil {
+			log.Printf("Warning: Failed to delete attachment record %d: %v", orphan.ID, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// StartCleanupWorker periodically removes orphaned attachments
+func (s *AttachmentService) StartCleanupWorker() {
+	if s.config.CleanupInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.CleanupInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			removed, err := s.CleanupOrphans()
+			if err != nil {
+				log.Printf("Warning: Failed to clean up orphaned attachments: %v", err)
+			} else if removed > 0 {
+				log.Printf("Removed %d orphaned attachments", removed)
+			}
+		}
+	}()
+}
+
+// sniffType detects the content type of an upload and checks it against the allowed types
+func (s *AttachmentService) sniffType(data []byte) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "", ErrAttachmentType
+	}
+	for _, allowed := range s.config.AllowedTypes {
+		if mediaType == allowed {
+			return mediaType, nil
+		}
+	}
+	return "", ErrAttachmentType
+}
+
+// present signs a download URL for an attachment and converts its timestamp to the viewer's
+// preferred timezone
+func (s *AttachmentService) present(viewerID int, attachment *model.Attachment) {
+	loc := s.prefService.Location(viewerID)
+	expires := time.Now().Add(s.config.URLTTL).Truncate(time.Second).In(loc)
+	attachment.DownloadURL = s.signer.Sign(downloadPath(attachment.ID), expires)
+	attachment.URLExpiresAt = &expires
+	attachment.CreatedAt = attachment.CreatedAt.In(loc)
+}
+
+// deleteBlob removes a stored attachment, logging failures
+func (s *AttachmentService) deleteBlob(key string) {
+	if err := s.store.Delete(key); err != nil {
+		log.Printf("Warning: Failed to delete attachment %s: %v", key, err)
+	}
+}
+
+func downloadPath(attachmentID int) string {
+	return fmt.Sprintf("/api/v1/attachments/%d/download", attachmentID)
+}
+
+// cleanFilename reduces a client-supplied filename to a printable base name that fits the
+// filename column
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	if runes := []rune(name); len(runes) > maxFilenameLength {
+		name = string(runes[:maxFilenameLength])
+	}
+	return name
+}
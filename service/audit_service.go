@@ -0,0 +1,214 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// AuditConfig holds audit log configuration
+type AuditConfig struct {
+	RetentionDays int           // Events older than this are purged; 0 keeps events forever
+	PurgeInterval time.Duration // How often the retention purge runs
+}
+
+// DefaultAuditConfig returns audit configuration from the environment
+func DefaultAuditConfig() AuditConfig {
+	return AuditConfig{
+		RetentionDays: getEnvInt("AUDIT_RETENTION_DAYS", 365),
+		PurgeInterval: getEnvDuration("AUDIT_PURGE_INTERVAL", 24*time.Hour),
+	}
+}
+
+type AuditService struct {
+	auditRepo *repository.AuditRepository
+	config    AuditConfig
+	validator *validator.Validate
+}
+
+func NewAuditService(auditRepo *repository.AuditRepository, config AuditConfig) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+		config:    config,
+		validator: validator.New(),
+	}
+}
+
+// Record appends an audit event for an action performed by the requester described in meta
+func (s *AuditService) Record(meta model.RequestMeta, entry model.AuditEntry) error {
+	if err := s.validator.Struct(entry); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	before, err := marshalAuditState(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalAuditState(entry.After)
+	if err != nil {
+		return err
+	}
+
+	event := &model.AuditEvent{
+		ActorID:        optionalID(meta.ActorID),
+		ImpersonatorID: optionalID(meta.ImpersonatorID),
+		Action:         entry.Action,
+		TargetType:     entry.TargetType,
+		TargetID:       entry.TargetID,
+		Before:         before,
+		After:          after,
+		IPAddress:      truncate(meta.IPAddress, 64),
+		UserAgent:      truncate(meta.UserAgent, 255),
+		RequestID:      truncate(meta.RequestID, 64),
+	}
+
+	return s.auditRepo.CreateEvent(event)
+}
+
+// RecordBestEffort records an audit event, logging instead of failing the caller's action
+func (s *AuditService) RecordBestEffort(meta model.RequestMeta, entry model.AuditEntry) {
+	if err := s.Record(meta, entry); err != nil {
+		log.Printf("Warning: Failed to record audit event %s: %v", entry.Action, err)
+	}
+}
+
+// ListEvents retrieves audit events with filtering and pagination
+func (s *AuditService) ListEvents(filter model.AuditEventFilter) (*model.PaginatedResponse, error) {
+	// Set defaults
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+
+	if err := s.validator.Struct(filter); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	events, total, err := s.auditRepo.ListEvents(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit events: %w", err)
+	}
+
+	totalPages := int((total + int64(filter.Limit) - 1) / int64(filter.Limit))
+
+	pagination := model.Pagination{
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		TotalPages: totalPages,
+		HasNext:    filter.Page < totalPages,
+		HasPrev:    filter.Page > 1,
+	}
+
+	return &model.PaginatedResponse{
+		Data:       events,
+		Pagination: pagination,
+	}, nil
+}
+
+// ExportEvents streams every event matching the filter, oldest first
+func (s *AuditService) ExportEvents(filter model.AuditEventFilter, fn func(event *model.AuditEvent) error) error {
+	return s.auditRepo.EachEvent(filter, fn)
+}
+
+// VerifyChain recomputes the hash chain and reports the first entry that does not match.
+// The oldest remaining entry anchors the chain, since retention purges earlier entries.
+func (s *AuditService) VerifyChain() (*model.AuditVerification, error) {
+	result := &model.AuditVerification{Valid: true}
+	var prevHash string
+	first := true
+
+	err := s.auditRepo.EachEvent(model.AuditEventFilter{}, func(event *model.AuditEvent) error {
+		if !result.Valid {
+			return nil
+		}
+		result.Checked++
+
+		linked := first || event.PrevHash == prevHash
+		if !linked || event.Hash != event.ChainHash(event.PrevHash) {
+			id := event.ID
+			result.Valid = false
+			result.FirstInvalidID = &id
+			return nil
+		}
+
+		prevHash = event.Hash
+		first = false
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify audit chain: %w", err)
+	}
+
+	return result, nil
+}
+
+// PurgeExpired removes events older than the configured retention window
+func (s *AuditService) PurgeExpired() (int64, error) {
+	if s.config.RetentionDays <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().UTC().AddDate(0, 0, -s.config.RetentionDays)
+	return s.auditRepo.DeleteEventsBefore(cutoff)
+}
+
+// StartRetentionWorker periodically purges events outside the retention window
+func (s *AuditService) StartRetentionWorker() {
+	if s.config.RetentionDays <= 0 || s.config.PurgeInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.PurgeInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			purged, err := s.PurgeExpired()
+			if err != nil {
+				log.Printf("Warning: Failed to purge audit events: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d audit events older than %d days", purged, s.config.RetentionDays)
+			}
+		}
+	}()
+}
+
+// marshalAuditState encodes a before/after snapshot, leaving nil states empty
+func marshalAuditState(state interface{}) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit state: %w", err)
+	}
+	return data, nil
+}
+
+// optionalID maps a zero ID to NULL
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
+
+// truncate shortens s to at most max characters, on rune boundaries, so stored values match
+// what was hashed
+func truncate(s string, max int) string {
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max])
+	}
+	return s
+}
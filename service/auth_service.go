@@ -1,11 +1,10 @@
 package service
 
 import (
-	"crypto/sha256"
 	"database/sql"
-	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"jmrashed/apps/userApp/auth"
@@ -16,19 +15,26 @@ import (
 )
 
 type AuthService struct {
-	userRepo  *repository.UserRepository
-	validator *validator.Validate
+	userRepo            *repository.UserRepository
+	auditService        *AuditService
+	registrationService *RegistrationService
+	emailChangeService  *EmailChangeService
+	validator           *validator.Validate
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, auditService *AuditService, registrationService *RegistrationService,
+	emailChangeService *EmailChangeService) *AuthService {
 	return &AuthService{
-		userRepo:  userRepo,
-		validator: validator.New(),
+		userRepo:            userRepo,
+		auditService:        auditService,
+		registrationService: registrationService,
+		emailChangeService:  emailChangeService,
+		validator:           validator.New(),
 	}
 }
 
 // Register creates a new user account
-func (s *AuthService) Register(req model.RegisterRequest) (*model.AuthResponse, error) {
+func (s *AuthService) Register(req model.RegisterRequest, meta model.RequestMeta) (*model.AuthResponse, error) {
 	// Validate request
 	if err := s.validator.Struct(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
@@ -42,13 +48,25 @@ func (s *AuthService) Register(req model.RegisterRequest) (*model.AuthResponse,
 		return nil, errors.New("email already exists")
 	}
 
+	// Enforce the registration mode and validate any invite code
+	invitation, err := s.registrationService.CheckRegistration(req.Email, req.InviteCode)
+	if err != nil {
+		return nil, err
+	}
+
+	roleID := defaultUserRoleID
+	if invitation != nil {
+		roleID = invitation.RoleID
+	}
+
 	// Hash password
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	// Create user
+	// Create the user with the invitation's role, or the default role (user), redeeming any
+	// invitation in the same transaction
 	user := &model.User{
 		Username:     req.Username,
 		Email:        req.Email,
@@ -56,13 +74,25 @@ func (s *AuthService) Register(req model.RegisterRequest) (*model.AuthResponse,
 		IsActive:     true,
 	}
 
-	if err := s.userRepo.CreateUser(user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	if err := s.userRepo.RegisterUser(user, roleID, invitation); err != nil {
+		return nil, err
 	}
 
-	// Assign default role (user)
-	if err := s.userRepo.AssignRoleToUser(user.ID, 2); err != nil {
-		return nil, fmt.Errorf("failed to assign default role: %w", err)
+	meta.ActorID = user.ID
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "user.registered",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+		After:      map[string]interface{}{"username": user.Username, "email": user.Email},
+	})
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "user.role_assigned",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+		After:      map[string]interface{}{"role_id": roleID},
+	})
+	if invitation != nil {
+		s.registrationService.RecordRedemption(invitation, user.ID, meta)
 	}
 
 	// Load user with roles and permissions
@@ -95,7 +125,7 @@ func (s *AuthService) Register(req model.RegisterRequest) (*model.AuthResponse,
 }
 
 // Login authenticates a user and returns tokens
-func (s *AuthService) Login(req model.LoginRequest) (*model.AuthResponse, error) {
+func (s *AuthService) Login(req model.LoginRequest, meta model.RequestMeta) (*model.AuthResponse, error) {
 	// Validate request
 	if err := s.validator.Struct(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
@@ -104,7 +134,8 @@ func (s *AuthService) Login(req model.LoginRequest) (*model.AuthResponse, error)
 	// Get user by username
 	user, err := s.userRepo.GetUserByUsername(req.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.recordFailedLogin(meta, req.Username, "", "unknown user")
 			return nil, errors.New("invalid credentials")
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -112,6 +143,7 @@ func (s *AuthService) Login(req model.LoginRequest) (*model.AuthResponse, error)
 
 	// Check password
 	if !auth.CheckPassword(req.Password, user.PasswordHash) {
+		s.recordFailedLogin(meta, req.Username, strconv.Itoa(user.ID), "invalid password")
 		return nil, errors.New("invalid credentials")
 	}
 
@@ -133,11 +165,28 @@ func (s *AuthService) Login(req model.LoginRequest) (*model.AuthResponse, error)
 		return nil, fmt.Errorf("failed to store refresh token: %w", err)
 	}
 
+	meta.ActorID = user.ID
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "auth.login",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+	})
+
 	// Remove password hash from response
 	authResponse.User.PasswordHash = ""
 	return authResponse, nil
 }
 
+// recordFailedLogin audits a rejected login attempt; userID is empty when the username is unknown
+func (s *AuthService) recordFailedLogin(meta model.RequestMeta, username, userID, reason string) {
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "auth.login_failed",
+		TargetType: "user",
+		TargetID:   userID,
+		After:      map[string]interface{}{"username": username, "reason": reason},
+	})
+}
+
 // RefreshToken generates new access token using refresh token
 func (s *AuthService) RefreshToken(req model.RefreshTokenRequest) (*model.AuthResponse, error) {
 	// Validate request
@@ -198,8 +247,17 @@ func (s *AuthService) Logout(userID int, refreshToken string) error {
 }
 
 // LogoutAll invalidates all refresh tokens for a user
-func (s *AuthService) LogoutAll(userID int) error {
-	return s.userRepo.DeleteUserRefreshTokens(userID)
+func (s *AuthService) LogoutAll(userID int, meta model.RequestMeta) error {
+	if err := s.userRepo.DeleteUserRefreshTokens(userID); err != nil {
+		return err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "auth.logout_all",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+	})
+	return nil
 }
 
 // GetUserProfile returns user profile information
@@ -209,18 +267,31 @@ func (s *AuthService) GetUserProfile(userID int) (*model.User, error) {
 		return nil, fmt.Errorf("failed to get user profile: %w", err)
 	}
 
+	user.PendingEmail = s.emailChangeService.PendingEmail(userID)
+
 	// Remove password hash
 	user.PasswordHash = ""
 	return user, nil
 }
 
-// UpdateUserProfile updates user profile information
-func (s *AuthService) UpdateUserProfile(userID int, username, email string) (*model.User, error) {
+// UpdateUserProfile updates user profile information; empty fields are left unchanged.
+// A new email address only becomes pending until it is confirmed.
+func (s *AuthService) UpdateUserProfile(userID int, req model.UpdateProfileRequest, meta model.RequestMeta) (*model.User, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
 	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	username := user.Username
+	if req.Username != "" {
+		username = req.Username
+	}
+	emailChanged := req.Email != "" && req.Email != user.Email
+
 	// Check if username is taken by another user
 	if username != user.Username {
 		if existingUser, err := s.userRepo.GetUserByUsername(username); err == nil && existingUser.ID != userID {
@@ -228,18 +299,43 @@ func (s *AuthService) UpdateUserProfile(userID int, username, email string) (*mo
 		}
 	}
 
-	// Check if email is taken by another user
-	if email != user.Email {
-		if existingUser, err := s.userRepo.GetUserByEmail(email); err == nil && existingUser.ID != userID {
+	if emailChanged {
+		if meta.ImpersonatorID != 0 {
+			return nil, errors.New("email cannot be changed while impersonating")
+		}
+		if !auth.CheckPassword(req.CurrentPassword, user.PasswordHash) {
+			return nil, errors.New("current password is required to change email")
+		}
+		if existingUser, err := s.userRepo.GetUserByEmail(req.Email); err == nil && existingUser.ID != userID {
 			return nil, errors.New("email already exists")
 		}
 	}
 
-	user.Username = username
-	user.Email = email
+	if username != user.Username {
+		before := map[string]interface{}{"username": user.Username}
+		user.Username = username
 
-	if err := s.userRepo.UpdateUser(user); err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		if err := s.userRepo.UpdateUser(user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+
+		s.auditService.RecordBestEffort(meta, model.AuditEntry{
+			Action:     "user.profile_updated",
+			TargetType: "user",
+			TargetID:   strconv.Itoa(userID),
+			Before:     before,
+			After:      map[string]interface{}{"username": user.Username},
+		})
+	}
+
+	if emailChanged {
+		change, err := s.emailChangeService.RequestChange(user, req.Email, meta)
+		if err != nil {
+			return nil, err
+		}
+		user.PendingEmail = change.NewEmail
+	} else {
+		user.PendingEmail = s.emailChangeService.PendingEmail(userID)
 	}
 
 	// Remove password hash
@@ -248,7 +344,7 @@ func (s *AuthService) UpdateUserProfile(userID int, username, email string) (*mo
 }
 
 // ChangePassword changes user password
-func (s *AuthService) ChangePassword(userID int, currentPassword, newPassword string) error {
+func (s *AuthService) ChangePassword(userID int, currentPassword, newPassword string, meta model.RequestMeta) error {
 	user, err := s.userRepo.GetUserByID(userID)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
@@ -270,12 +366,58 @@ func (s *AuthService) ChangePassword(userID int, currentPassword, newPassword st
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "user.password_changed",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+	})
+
 	// Invalidate all refresh tokens to force re-login
 	return s.userRepo.DeleteUserRefreshTokens(userID)
 }
 
+// Impersonate issues a short-lived access token letting an admin act as another user
+func (s *AuthService) Impersonate(impersonator *auth.Claims, targetUserID int, meta model.RequestMeta) (*model.ImpersonationResponse, error) {
+	if impersonator.IsImpersonated() {
+		return nil, errors.New("cannot start impersonation from an impersonated session")
+	}
+	if impersonator.UserID == targetUserID {
+		return nil, errors.New("cannot impersonate yourself")
+	}
+
+	user, err := s.userRepo.GetUserByID(targetUserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	for _, role := range user.Roles {
+		if role.Name == "admin" {
+			return nil, errors.New("cannot impersonate an administrator")
+		}
+	}
+
+	response, err := auth.GenerateImpersonationToken(*user, impersonator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	// Refuse to hand out the token if the audit trail cannot be written
+	entry := model.AuditEntry{
+		Action:     "impersonation.start",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+		After:      map[string]interface{}{"expires_in": response.ExpiresIn},
+	}
+	if err := s.auditService.Record(meta, entry); err != nil {
+		return nil, fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	// Remove password hash from response
+	response.User.PasswordHash = ""
+	return response, nil
+}
+
 // hashToken creates a SHA256 hash of the token for storage
 func (s *AuthService) hashToken(token string) string {
-	hash := sha256.Sum256([]byte(token))
-	return hex.EncodeToString(hash[:])
+	return auth.HashToken(token)
 }
\ No newline at end of file
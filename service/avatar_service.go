@@ -0,0 +1,168 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"jmrashed/apps/userApp/auth"
+	"jmrashed/apps/userApp/media"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+	"jmrashed/apps/userApp/storage"
+)
+
+// Avatar renditions; DefaultAvatarSize is the one exposed as avatar_url
+var AvatarSizes = []int{512, 256, 64}
+
+const DefaultAvatarSize = 256
+
+var ErrAvatarTooLarge = errors.New("avatar file is too large")
+
+// AvatarConfig holds avatar upload configuration
+type AvatarConfig struct {
+	MaxUploadBytes int64 // Largest accepted upload
+}
+
+// DefaultAvatarConfig returns avatar configuration from the environment
+func DefaultAvatarConfig() AvatarConfig {
+	return AvatarConfig{
+		MaxUploadBytes: int64(getEnvInt("AVATAR_MAX_UPLOAD_BYTES", 5<<20)),
+	}
+}
+
+// DefaultStorageConfig returns blob storage configuration from the environment
+func DefaultStorageConfig() storage.Config {
+	return storage.Config{
+		Driver:         getEnv("STORAGE_DRIVER", storage.DriverLocal),
+		LocalDir:       getEnv("STORAGE_LOCAL_DIR", "./data/uploads"),
+		PublicBaseURL:  getEnv("STORAGE_PUBLIC_URL", "/media"),
+		S3Endpoint:     getEnv("S3_ENDPOINT", ""),
+		S3Region:       getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:       getEnv("S3_BUCKET", ""),
+		S3AccessKey:    getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretKey:    getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3UsePathStyle: getEnvBool("S3_USE_PATH_STYLE", false),
+	}
+}
+
+type AvatarService struct {
+	userRepo     *repository.UserRepository
+	store        storage.BlobStore
+	auditService *AuditService
+	config       AvatarConfig
+}
+
+func NewAvatarService(userRepo *repository.UserRepository, store storage.BlobStore, auditService *AuditService, config AvatarConfig) *AvatarService {
+	return &AvatarService{
+		userRepo:     userRepo,
+		store:        store,
+		auditService: auditService,
+		config:       config,
+	}
+}
+
+// MaxUploadBytes returns the largest accepted upload
+func (s *AvatarService) MaxUploadBytes() int64 {
+	return s.config.MaxUploadBytes
+}
+
+// UploadAvatar processes an uploaded image into the avatar renditions and replaces the user's avatar
+func (s *AvatarService) UploadAvatar(userID int, data []byte, meta model.RequestMeta) (*model.AvatarResponse, error) {
+	if int64(len(data)) > s.config.MaxUploadBytes {
+		return nil, ErrAvatarTooLarge
+	}
+
+	variants, err := media.ProcessAvatar(data, AvatarSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	// A fresh prefix per upload keeps avatar URLs cacheable forever
+	version, err := auth.GenerateSecureToken(8)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate avatar key: %w", err)
+	}
+	prefix := fmt.Sprintf("avatars/%d/%s", userID, version)
+
+	for i, variant := range variants {
+		key := avatarKey(prefix, variant.Size)
+		if err := s.store.Put(key, bytes.NewReader(variant.Data), int64(len(variant.Data)), media.AvatarContentType); err != nil {
+			s.deleteRenditions(prefix, AvatarSizes[:i])
+			return nil, fmt.Errorf("failed to store avatar: %w", err)
+		}
+	}
+
+	oldPrefix, err := s.userRepo.GetAvatarKey(userID)
+	if err != nil {
+		s.deleteRenditions(prefix, AvatarSizes)
+		return nil, err
+	}
+
+	response := s.avatarResponse(prefix)
+	if err := s.userRepo.SetAvatar(userID, prefix, response.AvatarURL); err != nil {
+		s.deleteRenditions(prefix, AvatarSizes)
+		return nil, err
+	}
+	if oldPrefix != "" {
+		s.deleteRenditions(oldPrefix, AvatarSizes)
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "user.avatar_updated",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		After:      map[string]interface{}{"avatar_url": response.AvatarURL},
+	})
+
+	return response, nil
+}
+
+// RemoveAvatar deletes the user's avatar and its stored renditions
+func (s *AvatarService) RemoveAvatar(userID int, meta model.RequestMeta) error {
+	prefix, err := s.userRepo.GetAvatarKey(userID)
+	if err != nil {
+		return err
+	}
+	if prefix == "" {
+		return nil
+	}
+
+	if err := s.userRepo.SetAvatar(userID, "", ""); err != nil {
+		return err
+	}
+	s.deleteRenditions(prefix, AvatarSizes)
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "user.avatar_removed",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+	})
+	return nil
+}
+
+func (s *AvatarService) avatarResponse(prefix string) *model.AvatarResponse {
+	response := &model.AvatarResponse{
+		AvatarURL: s.store.URL(avatarKey(prefix, DefaultAvatarSize)),
+		Sizes:     make(map[string]string, len(AvatarSizes)),
+	}
+	for _, size := range AvatarSizes {
+		response.Sizes[strconv.Itoa(size)] = s.store.URL(avatarKey(prefix, size))
+	}
+	return response
+}
+
+// deleteRenditions removes stored renditions, logging failures
+func (s *AvatarService) deleteRenditions(prefix string, sizes []int) {
+	for _, size := range sizes {
+		if err := s.store.Delete(avatarKey(prefix, size)); err != nil {
+			log.Printf("Warning: Failed to delete avatar %s: %v", avatarKey(prefix, size), err)
+		}
+	}
+}
+
+func avatarKey(prefix string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", prefix, size)
+}
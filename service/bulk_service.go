@@ -0,0 +1,301 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"jmrashed/apps/userApp/auth"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// BulkConfig holds bulk operation configuration
+type BulkConfig struct {
+	MaxOperations int // Operations per request, including those of a selection
+}
+
+// DefaultBulkConfig returns bulk operation configuration from the environment
+func DefaultBulkConfig() BulkConfig {
+	return BulkConfig{
+		MaxOperations: getEnvInt("BULK_MAX_OPERATIONS", 100),
+	}
+}
+
+type BulkService struct {
+	todoRepo        *repository.TodoRepository
+	todoService     *TodoService
+	projectService  *ProjectService
+	assigneeService *AssigneeService
+	auditService    *AuditService
+	config          BulkConfig
+	validator       *validator.Validate
+}
+
+func NewBulkService(todoRepo *repository.TodoRepository, todoService *TodoService, projectService *ProjectService,
+	assigneeService *AssigneeService, auditService *AuditService, config BulkConfig) *BulkService {
+	return &BulkService{
+		todoRepo:        todoRepo,
+		todoService:     todoService,
+		projectService:  projectService,
+		assigneeService: assigneeService,
+		auditService:    auditService,
+		config:          config,
+		validator:       validator.New(),
+	}
+}
+
+// Execute applies the operations of a bulk request for userID, who holds permissions. Every
+// operation is checked before any is applied. Atomic mode saves every operation in one
+// transaction, or none when any fails; best_effort mode applies every operation that passes.
+func (s *BulkService) Execute(userID int, permissions []string, req model.BulkRequest, meta model.RequestMeta) (*model.BulkResponse, error) {
+	if req.Mode == "" {
+		req.Mode = model.BulkAtomic
+	}
+	if req.Selection != nil {
+		req.Selection.Filter.Page = 1
+		req.Selection.Filter.Limit = 100
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	ops := req.Operations
+	if req.Selection != nil {
+		selected, err := s.selectOperations(userID, *req.Selection, s.config.MaxOperations-len(ops))
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, selected...)
+	}
+	if len(ops) == 0 {
+		return nil, errors.New("no operations to apply")
+	}
+	if len(ops) > s.config.MaxOperations {
+		return nil, fmt.Errorf("at most %d operations are allowed per request", s.config.MaxOperations)
+	}
+
+	response := &model.BulkResponse{Mode: req.Mode, Results: make([]model.BulkResult, len(ops))}
+	for i, op := range ops {
+		response.Results[i] = model.BulkResult{Index: i, Op: op.Op, ID: op.ID, Status: model.BulkSkipped}
+		if err := s.check(userID, permissions, op); err != nil {
+			response.Results[i].Status = model.BulkFailed
+			response.Results[i].Error = err.Error()
+			response.Failed++
+		}
+	}
+	if req.Mode == model.BulkAtomic {
+		if response.Failed > 0 {
+			return response, nil
+		}
+		if err := s.applyAtomic(userID, ops, response, meta); err != nil {
+			return nil, err
+		}
+	} else {
+		for i, op := range ops {
+			result := &response.Results[i]
+			if result.Status == model.BulkFailed {
+				continue
+			}
+
+			todo, err := s.apply(userID, op, meta)
+			if err != nil {
+				result.Status = model.BulkFailed
+				result.Error = err.Error()
+				response.Failed++
+				continue
+			}
+			result.Status = model.BulkApplied
+			result.Todo = todo
+			if todo != nil {
+				result.ID = todo.ID
+			}
+			response.Applied++
+		}
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "todo.bulk",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		After:      map[string]interface{}{"mode": response.Mode, "applied": response.Applied, "failed": response.Failed},
+	})
+	return response, nil
+}
+
+// selectOperations expands a selection into one operation per matching todo, failing when
+// more than max todos, or more than fit in one page of the filter, match
+func (s *BulkService) selectOperations(userID int, selection model.BulkSelection, max int) ([]model.BulkOperation, error) {
+	if selection.Op == model.BulkCreate {
+		return nil, errors.New("a selection cannot create todos")
+	}
+	if selection.ID != 0 {
+		return nil, errors.New("a selection cannot name a todo id")
+	}
+
+	page, err := s.todoService.GetUserTodos(userID, selection.Filter)
+	if err != nil {
+		return nil, err
+	}
+	// A selection is expanded from a single page of matches
+	if selection.Filter.Limit < max {
+		max = selection.Filter.Limit
+	}
+	if page.Pagination.Total > int64(max) {
+		return nil, fmt.Errorf("selection matches %d todos; at most %d can be selected in this request",
+			page.Pagination.Total, max)
+	}
+
+	todos := page.Data.([]model.Todo)
+	ops := make([]model.BulkOperation, len(todos))
+	for i, todo := range todos {
+		ops[i] = selection.BulkOperation
+		ops[i].ID = todo.ID
+	}
+	return ops, nil
+}
+
+// check verifies that userID may apply an operation before any operation is applied
+func (s *BulkService) check(userID int, permissions []string, op model.BulkOperation) error {
+	if !auth.HasPermission(permissions, op.Permission()) {
+		return errors.New("insufficient permissions")
+	}
+	if err := op.Check(); err != nil {
+		return err
+	}
+	if op.Op == model.BulkCreate {
+		return nil
+	}
+
+	// Assignees may complete or update the status of a todo they do not own
+	todo, err := s.todoRepo.GetTodoByID(op.ID)
+	if err != nil {
+		return fmt.Errorf("todo not found or access denied")
+	}
+	if todo.UserID != userID {
+		edit, _ := op.Edit()
+		if op.Op == model.BulkDelete || !edit.StatusOnly() || !s.assigneeService.IsAssignee(todo.ID, userID) {
+			return fmt.Errorf("todo not found or access denied")
+		}
+	}
+	if todo.ArchivedAt != nil && op.Op != model.BulkDelete {
+		return ErrTodoArchived
+	}
+	if op.Op == model.BulkMove && op.ProjectID.ID != nil {
+		return s.projectService.CheckAssignable(*op.ProjectID.ID, userID)
+	}
+	return nil
+}
+
+// applyAtomic plans every operation, saves them all in one transaction and then follows up on
+// each. When an operation cannot be planned nothing is saved, and it is reported as failed.
+func (s *BulkService) applyAtomic(userID int, ops []model.BulkOperation, response *model.BulkResponse, meta model.RequestMeta) error {
+	// Later operations on a todo build on the planned outcome of earlier ones; nil marks a
+	// todo planned for deletion
+	planned := map[int]*model.Todo{}
+	changes := make([]*todoChange, len(ops))
+	writes := make([]*model.TodoWrite, len(ops))
+	for i, op := range ops {
+		change, err := s.plan(userID, op, planned)
+		if err != nil {
+			response.Results[i].Status = model.BulkFailed
+			response.Results[i].Error = err.Error()
+			response.Failed++
+			return nil
+		}
+		changes[i], writes[i] = change, change.write
+	}
+
+	if err := s.todoRepo.SaveTodos(writes...); err != nil {
+		return fmt.Errorf("failed to apply bulk operations: %w", err)
+	}
+
+	for i, op := range ops {
+		result := &response.Results[i]
+		result.Status = model.BulkApplied
+		response.Applied++
+
+		switch op.Op {
+		case model.BulkCreate:
+			result.Todo = s.todoService.finishCreate(changes[i], userID)
+			result.ID = result.Todo.ID
+		case model.BulkDelete:
+			s.todoService.finishDelete(changes[i].write.Todo, meta)
+		default:
+			todo, err := s.todoService.finishUpdate(changes[i], userID, nil)
+			if err != nil {
+				log.Printf("Warning: Failed to load todo %d after bulk %s: %v", op.ID, op.Op, err)
+			}
+			result.Todo = todo
+		}
+	}
+	return nil
+}
+
+// plan plans one operation of an atomic request on top of the todos planned before it
+func (s *BulkService) plan(userID int, op model.BulkOperation, planned map[int]*model.Todo) (*todoChange, error) {
+	if op.Op == model.BulkCreate {
+		return s.todoService.planCreate(userID, *op.Todo)
+	}
+
+	todo, ok := planned[op.ID]
+	if !ok {
+		stored, err := s.todoRepo.GetTodoByID(op.ID)
+		if err != nil {
+			return nil, fmt.Errorf("todo not found or access denied")
+		}
+		todo = stored
+	}
+	if todo == nil {
+		return nil, fmt.Errorf("todo not found or access denied")
+	}
+
+	if op.Op == model.BulkDelete {
+		if todo.UserID != userID {
+			return nil, fmt.Errorf("todo not found or access denied")
+		}
+		// Subtasks go to the trash with the todo, so later operations cannot target them
+		ids, err := s.todoRepo.GetSubtreeIDs(op.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			planned[id] = nil
+		}
+		return &todoChange{write: &model.TodoWrite{Todo: todo, Delete: true}}, nil
+	}
+
+	edit, err := op.Edit()
+	if err != nil {
+		return nil, err
+	}
+	copied := *todo
+	change, err := s.todoService.planUpdate(&copied, userID, edit, model.EditScopeThis)
+	if err != nil {
+		return nil, err
+	}
+	planned[op.ID] = &copied
+	return change, nil
+}
+
+// apply applies one operation of a best_effort request and returns the todo it created or
+// changed
+func (s *BulkService) apply(userID int, op model.BulkOperation, meta model.RequestMeta) (*model.Todo, error) {
+	switch op.Op {
+	case model.BulkCreate:
+		return s.todoService.CreateTodo(userID, *op.Todo)
+
+	case model.BulkDelete:
+		return nil, s.todoService.DeleteTodo(op.ID, userID, meta, model.EditScopeThis)
+
+	default:
+		edit, err := op.Edit()
+		if err != nil {
+			return nil, err
+		}
+		return s.todoService.UpdateTodo(op.ID, userID, edit, model.EditScopeThis)
+	}
+}
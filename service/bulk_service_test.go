@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+
+	"jmrashed/apps/userApp/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkAtomicDeleteThenUpdateSubtask(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t)
+	parent := env.createTodo(t, user.ID, "Parent", nil)
+	child := env.createTodo(t, user.ID, "Child", &parent.ID)
+
+	title := "Renamed"
+	response, err := env.bulkService.Execute(user.ID, []string{"write_todos", "delete_todos"}, model.BulkRequest{
+		Mode: model.BulkAtomic,
+		Operations: []model.BulkOperation{
+			{Op: model.BulkDelete, ID: parent.ID},
+			{Op: model.BulkUpdate, ID: child.ID, Changes: &model.UpdateTodoRequest{Title: &title}},
+		},
+	}, model.RequestMeta{})
+	require.NoError(t, err)
+
+	// The update is reported as failed and nothing is applied
+	assert.Equal(t, 0, response.Applied)
+	assert.Equal(t, 1, response.Failed)
+	assert.Equal(t, model.BulkSkipped, response.Results[0].Status)
+	assert.Equal(t, model.BulkFailed, response.Results[1].Status)
+	assert.Equal(t, "todo not found or access denied", response.Results[1].Error)
+
+	stored, err := env.todoRepo.GetTodoByID(child.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Child", stored.Title)
+	_, err = env.todoRepo.GetTodoByID(parent.ID)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/notify"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// defaultCommentLimit is how many threads are listed per page when no limit is given
+const defaultCommentLimit = 20
+
+type CommentService struct {
+	commentRepo     *repository.CommentRepository
+	userRepo        *repository.UserRepository
+	assigneeService *AssigneeService
+	prefService     *PreferenceService
+	notifier        notify.Notifier
+	validator       *validator.Validate
+}
+
+func NewCommentService(commentRepo *repository.CommentRepository, userRepo *repository.UserRepository,
+	assigneeService *AssigneeService, prefService *PreferenceService, notifier notify.Notifier) *CommentService {
+	return &CommentService{
+		commentRepo:     commentRepo,
+		userRepo:        userRepo,
+		assigneeService: assigneeService,
+		prefService:     prefService,
+		notifier:        notifier,
+		validator:       validator.New(),
+	}
+}
+
+// ListComments returns a page of a todo's comment threads, each with all of its replies
+func (s *CommentService) ListComments(todoID, userID, page, limit int) (*model.PaginatedResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = defaultCommentLimit
+	}
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	threads, total, err := s.commentRepo.GetThreads(todoID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	comments := make([]*model.Comment, len(threads))
+	ids := make([]int, len(threads))
+	for i := range threads {
+		comments[i] = &threads[i]
+		ids[i] = threads[i].ID
+	}
+
+	replies, err := s.commentRepo.GetReplies(ids)
+	if err != nil {
+		return nil, err
+	}
+	all := append([]*model.Comment{}, comments...)
+	for _, thread := range comments {
+		thread.Replies = []*model.Comment{}
+		for i := range replies[thread.ID] {
+			thread.Replies = append(thread.Replies, &replies[thread.ID][i])
+		}
+		all = append(all, thread.Replies...)
+	}
+	s.loadMentions(all...)
+	s.present(userID, comments...)
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return &model.PaginatedResponse{
+		Data: comments,
+		Pagination: model.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
+	}, nil
+}
+
+// CreateComment adds a comment, or a reply to a top-level comment, to a todo the user owns or
+// is assigned to, and notifies the users it mentions
+func (s *CommentService) CreateComment(todoID, userID int, req model.CreateCommentRequest) (*model.Comment, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	todo, err := s.assigneeService.CheckAccess(todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		parent, err := s.commentRepo.GetComment(*req.ParentID, todoID)
+		if err != nil {
+			return nil, fmt.Errorf("parent comment not found")
+		}
+		if parent.ParentID != nil {
+			return nil, errors.New("replies cannot be answered; reply to the top-level comment instead")
+		}
+	}
+
+	comment := &model.Comment{TodoID: todoID, UserID: userID, ParentID: req.ParentID, Content: req.Content}
+	if err := s.commentRepo.CreateComment(comment); err != nil {
+		return nil, err
+	}
+	s.syncMentions(comment, todo)
+
+	return s.getComment(comment.ID, todoID, userID)
+}
+
+// UpdateComment replaces the content of the user's own comment, keeping the previous content in
+// its history. Only users newly mentioned by the edit are notified.
+func (s *CommentService) UpdateComment(todoID, commentID, userID int, req model.UpdateCommentRequest) (*model.Comment, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	todo, err := s.assigneeService.CheckAccess(todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err := s.commentRepo.GetComment(commentID, todoID)
+	if err != nil || comment.UserID != userID {
+		return nil, fmt.Errorf("comment not found or access denied")
+	}
+
+	if req.Content != comment.Content {
+		previous := comment.Content
+		comment.Content = req.Content
+		if err := s.commentRepo.UpdateComment(comment, previous); err != nil {
+			return nil, err
+		}
+		s.syncMentions(comment, todo)
+	}
+
+	return s.getComment(commentID, todoID, userID)
+}
+
+// DeleteComment deletes a comment and its replies. Authors can delete their own comments and
+// todo owners any comment on their todo.
+func (s *CommentService) DeleteComment(todoID, commentID, userID int) error {
+	todo, err := s.assigneeService.CheckAccess(todoID, userID)
+	if err != nil {
+		return err
+	}
+
+	comment, err := s.commentRepo.GetComment(commentID, todoID)
+	if err != nil || (comment.UserID != userID && todo.UserID != userID) {
+		return fmt.Errorf("comment not found or access denied")
+	}
+	return s.commentRepo.DeleteComment(commentID, todoID)
+}
+
+// GetHistory returns the earlier versions of a comment, newest first
+func (s *CommentService) GetHistory(todoID, commentID, userID int) ([]model.CommentRevision, error) {
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.commentRepo.GetComment(commentID, todoID); err != nil {
+		return nil, fmt.Errorf("comment not found")
+	}
+
+	revisions, err := s.commentRepo.GetRevisions(commentID)
+	if err != nil {
+		return nil, err
+	}
+	loc := s.prefService.Location(userID)
+	for i := range revisions {
+		revisions[i].CreatedAt = revisions[i].CreatedAt.In(loc)
+	}
+	return revisions, nil
+}
+
+// LoadCounts fills in the comment counts of todos
+func (s *CommentService) LoadCounts(todos ...*model.Todo) {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	counts, err := s.commentRepo.CountComments(ids)
+	if err != nil {
+		log.Printf("Warning: Failed to count comments: %v", err)
+		return
+	}
+	for _, todo := range todos {
+		todo.CommentCount = counts[todo.ID]
+	}
+}
+
+// syncMentions records the users a comment mentions who can see its todo and notifies the ones
+// it did not mention before
+func (s *CommentService) syncMentions(comment *model.Comment, todo *model.Todo) {
+	mentions, err := s.commentRepo.FindUsersWithAccess(todo.ID, model.ParseMentions(comment.Content))
+	if err != nil {
+		log.Printf("Warning: Failed to resolve mentions in comment %d: %v", comment.ID, err)
+		return
+	}
+	userIDs := make([]int, len(mentions))
+	for i, mention := range mentions {
+		userIDs[i] = mention.UserID
+	}
+
+	added, err := s.commentRepo.SetMentions(comment.ID, userIDs)
+	if err != nil {
+		log.Printf("Warning: Failed to save mentions in comment %d: %v", comment.ID, err)
+		return
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	author, err := s.userRepo.GetUserByID(comment.UserID)
+	if err != nil {
+		log.Printf("Warning: Failed to notify mentions in comment %d: %v", comment.ID, err)
+		return
+	}
+	for _, userID := range added {
+		s.notify(userID, author, todo, comment)
+	}
+}
+
+// notify tells a user they were mentioned in a comment, unless they wrote it or opted out of
+// mention notifications
+func (s *CommentService) notify(userID int, author *model.User, todo *model.Todo, comment *model.Comment) {
+	if userID == author.ID {
+		return
+	}
+	prefs, err := s.prefService.GetPreferences(userID)
+	if err != nil || !prefs.WantsNotification(model.NotificationMention) {
+		return
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+
+	if err := s.notifier.Send(mentionMessage(user, author, todo, comment)); err != nil {
+		log.Printf("Warning: Failed to send mention notification for comment %d: %v", comment.ID, err)
+	}
+}
+
+func (s *CommentService) getComment(commentID, todoID, userID int) (*model.Comment, error) {
+	comment, err := s.commentRepo.GetComment(commentID, todoID)
+	if err != nil {
+		return nil, err
+	}
+	s.loadMentions(comment)
+	s.present(userID, comment)
+	return comment, nil
+}
+
+func (s *CommentService) loadMentions(comments ...*model.Comment) {
+	ids := make([]int, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+		comment.Mentions = []model.Mention{}
+	}
+
+	mentions, err := s.commentRepo.GetMentions(ids)
+	if err != nil {
+		log.Printf("Warning: Failed to load comment mentions: %v", err)
+		return
+	}
+	for _, comment := range comments {
+		if commentMentions, ok := mentions[comment.ID]; ok {
+			comment.Mentions = commentMentions
+		}
+	}
+}
+
+// present converts comment timestamps to the viewer's preferred timezone
+func (s *CommentService) present(viewerID int, comments ...*model.Comment) {
+	loc := s.prefService.Location(viewerID)
+	for _, comment := range comments {
+		comment.In(loc)
+	}
+}
+
+// mentionMessage renders a mention notification with the comment's Markdown source
+func mentionMessage(user, author *model.User, todo *model.Todo, comment *model.Comment) notify.Message {
+	return notify.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("%s mentioned you on: %s", author.Username, todo.Title),
+		Body: fmt.Sprintf("Hi %s,\n\n%s mentioned you in a comment on: %s\n\n%s\n",
+			user.Username, author.Username, todo.Title, comment.Content),
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+	ErrTodoBlocked     = errors.New("todo is blocked by todos that are not completed yet")
+)
+
+type DependencyService struct {
+	dependencyRepo *repository.DependencyRepository
+	todoRepo       *repository.TodoRepository
+	prefService    *PreferenceService
+	projectService *ProjectService
+	validator      *validator.Validate
+}
+
+func NewDependencyService(dependencyRepo *repository.DependencyRepository, todoRepo *repository.TodoRepository,
+	prefService *PreferenceService, projectService *ProjectService) *DependencyService {
+	return &DependencyService{
+		dependencyRepo: dependencyRepo,
+		todoRepo:       todoRepo,
+		prefService:    prefService,
+		projectService: projectService,
+		validator:      validator.New(),
+	}
+}
+
+// AddDependency makes a todo owned by userID blocked by another of their todos, refusing
+// dependencies that would form a cycle
+func (s *DependencyService) AddDependency(todoID, userID int, req model.CreateDependencyRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+
+	todo, err := s.todoRepo.GetTodoByID(todoID)
+	if err != nil || todo.UserID != userID {
+		return fmt.Errorf("todo not found or access denied")
+	}
+	if todo.ArchivedAt != nil {
+		return ErrTodoArchived
+	}
+	blocker, err := s.todoRepo.GetTodoByID(req.BlockedByID)
+	if err != nil || blocker.UserID != userID {
+		return fmt.Errorf("blocking todo not found or access denied")
+	}
+
+	cycle, err := s.dependencyRepo.AddDependency(userID, &model.Dependency{TodoID: todoID, BlockedByID: req.BlockedByID})
+	if err != nil {
+		return err
+	}
+	if cycle != nil {
+		return fmt.Errorf("%w: %s", ErrDependencyCycle, model.FormatCycle(cycle))
+	}
+	return nil
+}
+
+// RemoveDependency stops a todo owned by userID from being blocked by another todo
+func (s *DependencyService) RemoveDependency(todoID, blockedByID, userID int) error {
+	return s.dependencyRepo.DeleteDependency(todoID, blockedByID, userID)
+}
+
+// GetDependencies returns the todos blocking and blocked by a todo owned by userID
+func (s *DependencyService) GetDependencies(todoID, userID int) (*model.TodoDependencies, error) {
+	todo, err := s.todoRepo.GetTodoByID(todoID)
+	if err != nil || todo.UserID != userID {
+		return nil, fmt.Errorf("todo not found or access denied")
+	}
+	blockedBy, err := s.dependencyRepo.GetBlockerTodos(todoID)
+	if err != nil {
+		return nil, err
+	}
+	blocking, err := s.dependencyRepo.GetBlockingTodos(todoID)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies := &model.TodoDependencies{
+		BlockedBy: make([]*model.Todo, len(blockedBy)),
+		Blocking:  make([]*model.Todo, len(blocking)),
+	}
+	for i := range blockedBy {
+		dependencies.BlockedBy[i] = &blockedBy[i]
+	}
+	for i := range blocking {
+		dependencies.Blocking[i] = &blocking[i]
+	}
+	return dependencies, nil
+}
+
+// LoadBlockers sets which todos block each todo and whether any of them is still open
+func (s *DependencyService) LoadBlockers(todos ...*model.Todo) {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+		todo.BlockedBy = []int{}
+		todo.Blocked = false
+	}
+
+	blockers, blocked, err := s.dependencyRepo.GetBlockers(ids)
+	if err != nil {
+		log.Printf("Warning: Failed to load todo blockers: %v", err)
+		return
+	}
+	for _, todo := range todos {
+		if ids, ok := blockers[todo.ID]; ok {
+			todo.BlockedBy = ids
+		}
+		todo.Blocked = blocked[todo.ID]
+	}
+}
+
+// CheckCompletion refuses completing a blocked todo when its owner enforces dependencies
+func (s *DependencyService) CheckCompletion(todo *model.Todo) error {
+	prefs, err := s.prefService.GetPreferences(todo.UserID)
+	if err != nil || !prefs.EnforceDependencies {
+		return nil
+	}
+
+	_, blocked, err := s.dependencyRepo.GetBlockers([]int{todo.ID})
+	if err != nil {
+		return err
+	}
+	if blocked[todo.ID] {
+		return ErrTodoBlocked
+	}
+	return nil
+}
+
+// Graph returns the dependencies between the todos of a project owned by userID. Todos come
+// in board order, or with format "sorted" after the todos blocking them.
+func (s *DependencyService) Graph(projectID, userID int, format string) (*model.DependencyGraph, error) {
+	if _, err := s.projectService.GetProject(projectID, userID); err != nil {
+		return nil, err
+	}
+
+	todos, err := s.todoRepo.GetBoardTodos(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+	blockers, blocked, err := s.dependencyRepo.GetBlockers(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &model.DependencyGraph{ProjectID: projectID, Edges: []model.DependencyEdge{}}
+	nodes := make(map[int]model.DependencyNode, len(todos))
+	for _, todo := range todos {
+		node := model.DependencyNode{
+			ID:        todo.ID,
+			Title:     todo.Title,
+			Status:    todo.Status,
+			Completed: todo.Completed,
+			Blocked:   blocked[todo.ID],
+			BlockedBy: []int{},
+		}
+		if ids, ok := blockers[todo.ID]; ok {
+			node.BlockedBy = ids
+		}
+		nodes[todo.ID] = node
+	}
+	for _, id := range ids {
+		for _, blockerID := range nodes[id].BlockedBy {
+			if _, ok := nodes[blockerID]; ok {
+				graph.Edges = append(graph.Edges, model.DependencyEdge{TodoID: id, BlockedByID: blockerID})
+			}
+		}
+	}
+
+	if format == model.DependencyFormatSorted {
+		if ids, err = model.SortDependencies(ids, graph.Edges); err != nil {
+			return nil, err
+		}
+	}
+	graph.Nodes = make([]model.DependencyNode, len(ids))
+	for i, id := range ids {
+		graph.Nodes[i] = nodes[id]
+	}
+	return graph, nil
+}
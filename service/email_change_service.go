@@ -0,0 +1,250 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"jmrashed/apps/userApp/auth"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/notify"
+	"jmrashed/apps/userApp/repository"
+)
+
+// EmailChangeConfig holds email change configuration
+type EmailChangeConfig struct {
+	TokenTTL       time.Duration // How long the new address has to confirm
+	RevertTTL      time.Duration // How long the old address can cancel or revert the change
+	RevokeSessions bool          // Revoke all sessions once the change is confirmed
+	AppBaseURL     string        // Base of the links sent by email
+}
+
+// DefaultEmailChangeConfig returns email change configuration from the environment
+func DefaultEmailChangeConfig() EmailChangeConfig {
+	return EmailChangeConfig{
+		TokenTTL:       getEnvDuration("EMAIL_CHANGE_TOKEN_TTL", 24*time.Hour),
+		RevertTTL:      getEnvDuration("EMAIL_CHANGE_REVERT_TTL", 7*24*time.Hour),
+		RevokeSessions: getEnvBool("EMAIL_CHANGE_REVOKE_SESSIONS", true),
+		AppBaseURL:     strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:8080"), "/"),
+	}
+}
+
+// DefaultNotifyConfig returns notification delivery configuration from the environment
+func DefaultNotifyConfig() notify.Config {
+	return notify.Config{
+		Driver:       getEnv("NOTIFY_DRIVER", notify.DriverLog),
+		From:         getEnv("MAIL_FROM", "noreply@localhost"),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+	}
+}
+
+type EmailChangeService struct {
+	userRepo        *repository.UserRepository
+	emailChangeRepo *repository.EmailChangeRepository
+	notifier        notify.Notifier
+	auditService    *AuditService
+	config          EmailChangeConfig
+}
+
+func NewEmailChangeService(userRepo *repository.UserRepository, emailChangeRepo *repository.EmailChangeRepository,
+	notifier notify.Notifier, auditService *AuditService, config EmailChangeConfig) *EmailChangeService {
+	return &EmailChangeService{
+		userRepo:        userRepo,
+		emailChangeRepo: emailChangeRepo,
+		notifier:        notifier,
+		auditService:    auditService,
+		config:          config,
+	}
+}
+
+// RequestChange records a pending change to newEmail, sending a confirmation link to the new
+// address and a notice with a cancel link to the current one
+func (s *EmailChangeService) RequestChange(user *model.User, newEmail string, meta model.RequestMeta) (*model.EmailChange, error) {
+	if existingUser, err := s.userRepo.GetUserByEmail(newEmail); err == nil && existingUser.ID != user.ID {
+		return nil, errors.New("email already exists")
+	}
+
+	confirmToken, err := auth.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	cancelToken, err := auth.GenerateSecureToken(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	now := time.Now()
+	change := &model.EmailChange{
+		UserID:           user.ID,
+		OldEmail:         user.Email,
+		NewEmail:         newEmail,
+		ConfirmTokenHash: auth.HashToken(confirmToken),
+		CancelTokenHash:  auth.HashToken(cancelToken),
+		ExpiresAt:        now.Add(s.config.TokenTTL),
+		CancelExpiresAt:  now.Add(s.config.RevertTTL),
+	}
+	if err := s.emailChangeRepo.CreateEmailChange(change); err != nil {
+		return nil, err
+	}
+
+	s.send(notify.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm that %s should become the email address of your account:\n\n%s\n\n"+
+			"The link expires at %s. If you did not request this, ignore this message.",
+			user.Username, newEmail, s.link("/email-change/confirm", confirmToken), change.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+	s.send(notify.Message{
+		To:      user.Email,
+		Subject: "Email address change requested",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to change the email address of your account to %s.\n"+
+			"If this was not you, cancel the change and change your password:\n\n%s\n\n"+
+			"The link also undoes the change after it has been confirmed, until %s.",
+			user.Username, newEmail, s.link("/email-change/cancel", cancelToken), change.CancelExpiresAt.UTC().Format(time.RFC1123)),
+	})
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "user.email_change_requested",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(user.ID),
+		Before:     map[string]interface{}{"email": user.Email},
+		After:      map[string]interface{}{"email": newEmail},
+	})
+
+	return change, nil
+}
+
+// ConfirmChange applies a pending change using the token sent to the new address
+func (s *EmailChangeService) ConfirmChange(token string, meta model.RequestMeta) error {
+	change, err := s.emailChangeRepo.GetByConfirmTokenHash(auth.HashToken(token))
+	if err != nil || change.Status != model.EmailChangePending || time.Now().After(change.ExpiresAt) {
+		return errors.New("email change link is invalid or expired")
+	}
+
+	if existingUser, err := s.userRepo.GetUserByEmail(change.NewEmail); err == nil && existingUser.ID != change.UserID {
+		return errors.New("email already exists")
+	}
+
+	if err := s.emailChangeRepo.ApplyEmailChange(change); err != nil {
+		return err
+	}
+
+	if s.config.RevokeSessions {
+		if err := s.userRepo.DeleteUserRefreshTokens(change.UserID); err != nil {
+			log.Printf("Warning: Failed to revoke sessions of user %d: %v", change.UserID, err)
+		}
+	}
+
+	s.send(notify.Message{
+		To:      change.OldEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("The email address of your account was changed to %s.\n"+
+			"If this was not you, use the cancel link from our previous message before %s to restore this address.",
+			change.NewEmail, change.CancelExpiresAt.UTC().Format(time.RFC1123)),
+	})
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "user.email_changed",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(change.UserID),
+		Before:     map[string]interface{}{"email": change.OldEmail},
+		After:      map[string]interface{}{"email": change.NewEmail, "sessions_revoked": s.config.RevokeSessions},
+	})
+	return nil
+}
+
+// CancelChange uses the token sent to the old address to cancel a pending change, or to revert
+// a confirmed one and revoke every session while the revert window is open
+func (s *EmailChangeService) CancelChange(token string, meta model.RequestMeta) error {
+	change, err := s.emailChangeRepo.GetByCancelTokenHash(auth.HashToken(token))
+	if err != nil || time.Now().After(change.CancelExpiresAt) {
+		return errors.New("email change link is invalid or expired")
+	}
+
+	switch change.Status {
+	case model.EmailChangePending:
+		if err := s.emailChangeRepo.CancelEmailChange(change.ID); err != nil {
+			return err
+		}
+		s.auditService.RecordBestEffort(meta, model.AuditEntry{
+			Action:     "user.email_change_cancelled",
+			TargetType: "user",
+			TargetID:   strconv.Itoa(change.UserID),
+			After:      map[string]interface{}{"email": change.NewEmail},
+		})
+		return nil
+
+	case model.EmailChangeConfirmed:
+		if existingUser, err := s.userRepo.GetUserByEmail(change.OldEmail); err == nil && existingUser.ID != change.UserID {
+			return errors.New("the previous email address is now used by another account")
+		}
+		if err := s.emailChangeRepo.RevertEmailChange(change); err != nil {
+			return err
+		}
+		if err := s.userRepo.DeleteUserRefreshTokens(change.UserID); err != nil {
+			log.Printf("Warning: Failed to revoke sessions of user %d: %v", change.UserID, err)
+		}
+
+		s.send(notify.Message{
+			To:      change.OldEmail,
+			Subject: "Your email address was restored",
+			Body:    "The email address change was undone and all sessions were signed out. Change your password now.",
+		})
+		s.auditService.RecordBestEffort(meta, model.AuditEntry{
+			Action:     "user.email_change_reverted",
+			TargetType: "user",
+			TargetID:   strconv.Itoa(change.UserID),
+			Before:     map[string]interface{}{"email": change.NewEmail},
+			After:      map[string]interface{}{"email": change.OldEmail},
+		})
+		return nil
+
+	default:
+		return errors.New("email change link is invalid or expired")
+	}
+}
+
+// CancelPending withdraws the user's own pending email change
+func (s *EmailChangeService) CancelPending(userID int, meta model.RequestMeta) error {
+	change, err := s.emailChangeRepo.GetPendingEmailChange(userID)
+	if err != nil {
+		return errors.New("no pending email change")
+	}
+	if err := s.emailChangeRepo.CancelEmailChange(change.ID); err != nil {
+		return err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "user.email_change_cancelled",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		After:      map[string]interface{}{"email": change.NewEmail},
+	})
+	return nil
+}
+
+// PendingEmail returns the address a pending change would switch to, or ""
+func (s *EmailChangeService) PendingEmail(userID int) string {
+	change, err := s.emailChangeRepo.GetPendingEmailChange(userID)
+	if err != nil {
+		return ""
+	}
+	return change.NewEmail
+}
+
+func (s *EmailChangeService) link(path, token string) string {
+	return s.config.AppBaseURL + path + "?token=" + token
+}
+
+// send delivers a notification, logging failures; the change is already recorded
+func (s *EmailChangeService) send(msg notify.Message) {
+	if err := s.notifier.Send(msg); err != nil {
+		log.Printf("ERROR: failed to send %q to %s: %v", msg.Subject, msg.To, err)
+	}
+}
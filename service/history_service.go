@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+)
+
+// HistoryConfig holds todo history configuration
+type HistoryConfig struct {
+	MaxVersions int // Versions kept per todo; older ones are pruned
+}
+
+// DefaultHistoryConfig returns todo history configuration from the environment
+func DefaultHistoryConfig() HistoryConfig {
+	return HistoryConfig{
+		MaxVersions: getEnvInt("TODO_HISTORY_MAX_VERSIONS", 50),
+	}
+}
+
+type HistoryService struct {
+	revisionRepo    *repository.RevisionRepository
+	assigneeService *AssigneeService
+	prefService     *PreferenceService
+	config          HistoryConfig
+}
+
+func NewHistoryService(revisionRepo *repository.RevisionRepository, assigneeService *AssigneeService,
+	prefService *PreferenceService, config HistoryConfig) *HistoryService {
+	return &HistoryService{
+		revisionRepo:    revisionRepo,
+		assigneeService: assigneeService,
+		prefService:     prefService,
+		config:          config,
+	}
+}
+
+// Record saves a revision of a todo edited by actorID when the edit changed it. before is the
+// todo's state before the edit, last changed at beforeAt. Failures are logged rather than
+// failing the edit.
+func (s *HistoryService) Record(todo *model.Todo, before model.TodoSnapshot, beforeAt time.Time, actorID int, restoredFrom *int) {
+	after := model.SnapshotTodo(todo)
+	changes := before.Diff(after)
+	if len(changes) == 0 {
+		return
+	}
+
+	revision := &model.TodoRevision{
+		TodoID:       todo.ID,
+		ActorID:      &actorID,
+		RestoredFrom: restoredFrom,
+		Changes:      changes,
+		Snapshot:     after,
+	}
+	if err := s.revisionRepo.AddRevision(revision, before, beforeAt, s.config.MaxVersions); err != nil {
+		log.Printf("Warning: Failed to record revision of todo %d: %v", todo.ID, err)
+	}
+}
+
+// GetHistory returns the kept versions of a todo the user owns or is assigned to, newest first
+func (s *HistoryService) GetHistory(todoID, userID int) ([]model.TodoRevision, error) {
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.revisionRepo.GetRevisions(todoID)
+	if err != nil {
+		return nil, err
+	}
+	loc := s.prefService.Location(userID)
+	for i := range revisions {
+		revisions[i].In(loc)
+	}
+	return revisions, nil
+}
+
+// GetRevision returns one kept version of a todo
+func (s *HistoryService) GetRevision(todoID, version int) (*model.TodoRevision, error) {
+	revision, err := s.revisionRepo.GetRevision(todoID, version)
+	if err != nil {
+		return nil, fmt.Errorf("version %d not found", version)
+	}
+	return revision, nil
+}
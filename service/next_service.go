@@ -0,0 +1,64 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+)
+
+// NextConfig holds configuration for ranking pending todos
+type NextConfig struct {
+	Weights        model.NextWeights
+	CandidateLimit int // Maximum pending todos considered per request
+}
+
+// DefaultNextConfig returns ranking configuration from the environment
+func DefaultNextConfig() NextConfig {
+	return NextConfig{
+		Weights: model.NextWeights{
+			Priority: getEnvFloat("NEXT_WEIGHT_PRIORITY", 3),
+			Due:      getEnvFloat("NEXT_WEIGHT_DUE", 4),
+			Age:      getEnvFloat("NEXT_WEIGHT_AGE", 1),
+			Position: getEnvFloat("NEXT_WEIGHT_POSITION", 2),
+		},
+		CandidateLimit: getEnvInt("NEXT_CANDIDATE_LIMIT", 500),
+	}
+}
+
+type NextService struct {
+	todoRepo *repository.TodoRepository
+	config   NextConfig
+}
+
+func NewNextService(todoRepo *repository.TodoRepository, config NextConfig) *NextService {
+	return &NextService{
+		todoRepo: todoRepo,
+		config:   config,
+	}
+}
+
+// Rank scores a user's pending todos and returns the limit highest, ties kept in manual order
+func (s *NextService) Rank(userID, limit int) ([]*model.Todo, error) {
+	todos, err := s.todoRepo.GetPendingTodos(userID, s.config.CandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ranked := make([]*model.Todo, len(todos))
+	for i := range todos {
+		score := s.config.Weights.Score(&todos[i], i, len(todos), now)
+		todos[i].Score = &score
+		ranked[i] = &todos[i]
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return *ranked[i].Score > *ranked[j].Score
+	})
+
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked, nil
+}
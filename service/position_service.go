@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/rank"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// PositionConfig holds manual todo ordering configuration
+type PositionConfig struct {
+	MaxKeyLength      int           // Position keys longer than this get the user's todos rebalanced
+	RebalanceInterval time.Duration // How often long position keys are checked
+	RebalanceBatch    int           // Maximum users rebalanced per run
+}
+
+// DefaultPositionConfig returns manual ordering configuration from the environment
+func DefaultPositionConfig() PositionConfig {
+	return PositionConfig{
+		MaxKeyLength:      getEnvInt("POSITION_MAX_KEY_LENGTH", 32),
+		RebalanceInterval: getEnvDuration("POSITION_REBALANCE_INTERVAL", time.Hour),
+		RebalanceBatch:    getEnvInt("POSITION_REBALANCE_BATCH_SIZE", 100),
+	}
+}
+
+// errNoRoom means two neighboring position keys leave no room for a key between them
+var errNoRoom = errors.New("no room between position keys")
+
+type PositionService struct {
+	todoRepo  *repository.TodoRepository
+	config    PositionConfig
+	validator *validator.Validate
+}
+
+func NewPositionService(todoRepo *repository.TodoRepository, config PositionConfig) *PositionService {
+	return &PositionService{
+		todoRepo:  todoRepo,
+		config:    config,
+		validator: validator.New(),
+	}
+}
+
+// Move places a user's todo before or after another todo in their manual order. Only the moved
+// todo is updated; if its neighbors leave no room between them, the user's todos are rebalanced
+// first.
+func (s *PositionService) Move(id, userID int, req model.MoveTodoRequest) error {
+	if err := s.validator.Struct(req); err != nil {
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	if req.BeforeID == nil && req.AfterID == nil {
+		return errors.New("before_id or after_id is required")
+	}
+	if err := s.checkTodo(id, userID); err != nil {
+		return err
+	}
+
+	key, err := s.keyFor(id, userID, req)
+	if err == errNoRoom {
+		if err := s.todoRepo.Rebalance(userID); err != nil {
+			return err
+		}
+		key, err = s.keyFor(id, userID, req)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.todoRepo.SetPosition(id, userID, key)
+}
+
+// RebalanceLongKeys rebalances the todos of users whose position keys grew too long
+func (s *PositionService) RebalanceLongKeys() (int, error) {
+	userIDs, err := s.todoRepo.GetUsersWithLongPositions(s.config.MaxKeyLength, s.config.RebalanceBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	rebalanced := 0
+	for _, userID := range userIDs {
+		if err := s.todoRepo.Rebalance(userID); err != nil {
+			log.Printf("Warning: Failed to rebalance todo positions of user %d: %v", userID, err)
+			continue
+		}
+		rebalanced++
+	}
+	return rebalanced, nil
+}
+
+// StartRebalanceWorker periodically rebalances long position keys in the background
+func (s *PositionService) StartRebalanceWorker() {
+	if s.config.RebalanceInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.RebalanceInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			rebalanced, err := s.RebalanceLongKeys()
+			if err != nil {
+				log.Printf("Warning: Failed to rebalance todo positions: %v", err)
+			} else if rebalanced > 0 {
+				log.Printf("Rebalanced todo positions of %d users", rebalanced)
+			}
+		}
+	}()
+}
+
+// keyFor computes a position key between the anchors of a move
+func (s *PositionService) keyFor(id, userID int, req model.MoveTodoRequest) (string, error) {
+	var lower, upper string
+	var err error
+
+	if req.AfterID != nil {
+		if lower, err = s.anchorPosition(*req.AfterID, id, userID); err != nil {
+			return "", err
+		}
+	}
+	if req.BeforeID != nil {
+		if upper, err = s.anchorPosition(*req.BeforeID, id, userID); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case req.BeforeID == nil:
+		upper, err = s.todoRepo.NeighborPosition(userID, id, lower, true)
+	case req.AfterID == nil:
+		lower, err = s.todoRepo.NeighborPosition(userID, id, upper, false)
+	case lower > upper:
+		return "", errors.New("after_id must come before before_id")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Equal or malformed neighboring keys need a rebalance
+	key, err := rank.Between(lower, upper)
+	if err != nil {
+		return "", errNoRoom
+	}
+	return key, nil
+}
+
+// anchorPosition returns the position of the todo a move is anchored to
+func (s *PositionService) anchorPosition(anchorID, id, userID int) (string, error) {
+	if anchorID == id {
+		return "", errors.New("a todo cannot be moved next to itself")
+	}
+	anchor, err := s.todoRepo.GetTodoByID(anchorID)
+	if err != nil || anchor.UserID != userID {
+		return "", fmt.Errorf("anchor todo not found or access denied")
+	}
+	return anchor.Position, nil
+}
+
+func (s *PositionService) checkTodo(id, userID int) error {
+	todo, err := s.todoRepo.GetTodoByID(id)
+	if err != nil || todo.UserID != userID {
+		return fmt.Errorf("todo not found or access denied")
+	}
+	return nil
+}
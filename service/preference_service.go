@@ -0,0 +1,126 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type PreferenceService struct {
+	prefRepo  *repository.PreferenceRepository
+	validator *validator.Validate
+}
+
+func NewPreferenceService(prefRepo *repository.PreferenceRepository) *PreferenceService {
+	return &PreferenceService{
+		prefRepo:  prefRepo,
+		validator: validator.New(),
+	}
+}
+
+// GetPreferences returns a user's preferences, or the defaults if none are stored
+func (s *PreferenceService) GetPreferences(userID int) (*model.UserPreferences, error) {
+	prefs, err := s.prefRepo.GetPreferences(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			defaults := model.DefaultUserPreferences(userID)
+			return &defaults, nil
+		}
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences applies the fields present in req
+func (s *PreferenceService) UpdatePreferences(userID int, req model.UpdatePreferencesRequest) (*model.UserPreferences, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	prefs, err := s.GetPreferences(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	if req.DisplayName != nil {
+		prefs.DisplayName = *req.DisplayName
+	}
+	if req.Timezone != nil {
+		prefs.Timezone = *req.Timezone
+	}
+	if req.Locale != nil {
+		prefs.Locale = *req.Locale
+	}
+	if req.DateFormat != nil {
+		prefs.DateFormat = *req.DateFormat
+	}
+	if req.DefaultSort != nil {
+		prefs.DefaultSort = *req.DefaultSort
+	}
+	if req.DefaultOrder != nil {
+		prefs.DefaultOrder = *req.DefaultOrder
+	}
+	if req.DefaultFilter != nil {
+		prefs.DefaultFilter = *req.DefaultFilter
+	}
+	if req.DefaultLimit != nil {
+		prefs.DefaultLimit = *req.DefaultLimit
+	}
+	if req.EmailNotifications != nil {
+		prefs.EmailNotifications = *req.EmailNotifications
+	}
+	if req.ReminderNotifications != nil {
+		prefs.ReminderNotifications = *req.ReminderNotifications
+	}
+	if req.AssignmentNotifications != nil {
+		prefs.AssignmentNotifications = *req.AssignmentNotifications
+	}
+	if req.MentionNotifications != nil {
+		prefs.MentionNotifications = *req.MentionNotifications
+	}
+	if req.AutoArchiveDays != nil {
+		prefs.AutoArchiveDays = *req.AutoArchiveDays
+	}
+	if req.EnforceDependencies != nil {
+		prefs.EnforceDependencies = *req.EnforceDependencies
+	}
+
+	if err := s.prefRepo.SavePreferences(prefs); err != nil {
+		return nil, err
+	}
+
+	prefs.UpdatedAt = time.Now().In(prefs.Location())
+	return prefs, nil
+}
+
+// Location returns the user's preferred timezone, falling back to UTC
+func (s *PreferenceService) Location(userID int) *time.Location {
+	prefs, err := s.GetPreferences(userID)
+	if err != nil {
+		return time.UTC
+	}
+	return prefs.Location()
+}
+
+// TodoListDefaults returns list query defaults taken from the user's preferences
+func (s *PreferenceService) TodoListDefaults(userID int) model.PaginationRequest {
+	prefs, err := s.GetPreferences(userID)
+	if err != nil {
+		defaults := model.DefaultUserPreferences(userID)
+		prefs = &defaults
+	}
+
+	return model.PaginationRequest{
+		Page:   1,
+		Limit:  prefs.DefaultLimit,
+		Sort:   prefs.DefaultSort,
+		Order:  prefs.DefaultOrder,
+		Filter: prefs.DefaultFilter,
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type ProjectService struct {
+	projectRepo     *repository.ProjectRepository
+	todoRepo        *repository.TodoRepository
+	auditService    *AuditService
+	workflowService *WorkflowService
+	validator       *validator.Validate
+}
+
+func NewProjectService(projectRepo *repository.ProjectRepository, todoRepo *repository.TodoRepository, auditService *AuditService,
+	workflowService *WorkflowService) *ProjectService {
+	return &ProjectService{
+		projectRepo:     projectRepo,
+		todoRepo:        todoRepo,
+		auditService:    auditService,
+		workflowService: workflowService,
+		validator:       validator.New(),
+	}
+}
+
+// ListProjects returns a user's projects with their todo counts
+func (s *ProjectService) ListProjects(userID int, includeArchived bool) ([]model.Project, error) {
+	return s.projectRepo.ListProjects(userID, includeArchived)
+}
+
+// GetProject returns a project owned by userID
+func (s *ProjectService) GetProject(id, userID int) (*model.Project, error) {
+	project, err := s.projectRepo.GetProjectByID(id)
+	if err != nil || project.UserID != userID {
+		return nil, fmt.Errorf("project not found or access denied")
+	}
+	return project, nil
+}
+
+// CreateProject creates a project, appended after the user's others unless a position is given
+func (s *ProjectService) CreateProject(userID int, req model.CreateProjectRequest) (*model.Project, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	project := &model.Project{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Color:       model.DefaultProjectColor,
+		Position:    -1,
+	}
+	if req.Color != "" {
+		project.Color = strings.ToLower(req.Color)
+	}
+	if req.Position != nil {
+		project.Position = *req.Position
+	}
+
+	if err := s.projectRepo.CreateProject(project); err != nil {
+		return nil, err
+	}
+	return s.projectRepo.GetProjectByID(project.ID)
+}
+
+// UpdateProject applies the fields present in req
+func (s *ProjectService) UpdateProject(id, userID int, req model.UpdateProjectRequest) (*model.Project, error) {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	project, err := s.GetProject(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		project.Name = *req.Name
+	}
+	if req.Description != nil {
+		project.Description = *req.Description
+	}
+	if req.Color != nil {
+		project.Color = strings.ToLower(*req.Color)
+	}
+	if req.Archived != nil {
+		project.Archived = *req.Archived
+	}
+	if req.Position != nil {
+		project.Position = *req.Position
+	}
+
+	if err := s.projectRepo.UpdateProject(project); err != nil {
+		return nil, err
+	}
+	return s.projectRepo.GetProjectByID(id)
+}
+
+// DeleteProject deletes a project; its todos are kept without a project and move into the
+// default workflow
+func (s *ProjectService) DeleteProject(id, userID int, meta model.RequestMeta) error {
+	project, err := s.GetProject(id, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.projectRepo.DeleteProject(id, userID); err != nil {
+		return err
+	}
+	if err := s.workflowService.FitTodos(userID, nil); err != nil {
+		log.Printf("Warning: Failed to update statuses of todos from project %d: %v", id, err)
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "project.deleted",
+		TargetType: "project",
+		TargetID:   strconv.Itoa(id),
+		Before:     project,
+	})
+	return nil
+}
+
+// CheckAssignable verifies that todos can be put in a project: it must be the user's own and not archived
+func (s *ProjectService) CheckAssignable(projectID, userID int) error {
+	project, err := s.GetProject(projectID, userID)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return errors.New("project is archived")
+	}
+	return nil
+}
+
+// MoveTodos moves a user's todos into a project, or out of any project when ProjectID is nil.
+// Moved todos whose status is not part of the target workflow take its first open or done status.
+func (s *ProjectService) MoveTodos(userID int, req model.MoveTodosRequest) (int64, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return 0, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if req.ProjectID != nil {
+		if err := s.CheckAssignable(*req.ProjectID, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	moved, err := s.todoRepo.MoveTodos(userID, uniqueIDs(req.TodoIDs), req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.workflowService.FitTodos(userID, req.ProjectID); err != nil {
+		return moved, err
+	}
+	return moved, nil
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/recurrence"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// defaultPreviewLimit is how many occurrences are listed when no limit is given
+const defaultPreviewLimit = 10
+
+type RecurrenceService struct {
+	seriesRepo  *repository.SeriesRepository
+	todoRepo    *repository.TodoRepository
+	prefService *PreferenceService
+	validator   *validator.Validate
+}
+
+func NewRecurrenceService(seriesRepo *repository.SeriesRepository, todoRepo *repository.TodoRepository, prefService *PreferenceService) *RecurrenceService {
+	return &RecurrenceService{
+		seriesRepo:  seriesRepo,
+		todoRepo:    todoRepo,
+		prefService: prefService,
+		validator:   validator.New(),
+	}
+}
+
+// PlanSeries plans a series starting from a todo that has not been saved yet, or from an
+// existing todo, and returns it along with its first occurrence: the first time the rule matches
+// on or after the todo's due date. The series is saved along with the todo, which becomes that
+// occurrence.
+func (s *RecurrenceService) PlanSeries(todo *model.Todo, req model.RecurrenceRequest) (*model.TodoSeries, time.Time, error) {
+	rule, err := s.parseRule(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if todo.DueAt == nil {
+		return nil, time.Time{}, errors.New("recurring todos need a due_at")
+	}
+
+	series := &model.TodoSeries{
+		UserID:       todo.UserID,
+		Rule:         rule.String(),
+		StartsAt:     *todo.DueAt,
+		Timezone:     s.prefService.Location(todo.UserID).String(),
+		Exceptions:   exceptionDates(req.Exceptions),
+		Title:        todo.Title,
+		Content:      todo.Content,
+		RemindOffset: remindOffset(todo.DueAt, todo.RemindAt),
+	}
+
+	first, ok := nextOccurrence(series, rule, nil)
+	if !ok {
+		return nil, time.Time{}, errors.New("recurrence rule has no occurrences on or after due_at")
+	}
+	return series, first, nil
+}
+
+// Advance creates the next occurrence of a series once it has no open occurrence left. It
+// returns nil when the series has ended or an open occurrence already exists.
+func (s *RecurrenceService) Advance(seriesID int) (*model.Todo, error) {
+	series, err := s.seriesRepo.GetSeries(seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := recurrence.Parse(series.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored rule for series %d: %w", seriesID, err)
+	}
+
+	latest, err := s.todoRepo.LatestOccurrence(seriesID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	next, ok := nextOccurrence(series, rule, latest)
+	if !ok {
+		return nil, nil
+	}
+
+	todo := series.Occurrence(next)
+	created, err := s.todoRepo.CreateOccurrence(todo)
+	if err != nil || !created {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// SkipOccurrence records a deleted occurrence as an exception so it is not generated again,
+// then creates the following occurrence
+func (s *RecurrenceService) SkipOccurrence(todo *model.Todo) error {
+	series, err := s.seriesRepo.GetSeries(*todo.SeriesID)
+	if err != nil {
+		return err
+	}
+
+	if todo.OccurrenceAt != nil && !series.HasException(*todo.OccurrenceAt) {
+		series.Exceptions = append(series.Exceptions, todo.OccurrenceAt.In(series.Location()).Format("2006-01-02"))
+		if err := s.seriesRepo.UpdateSeries(series); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.Advance(series.ID)
+	return err
+}
+
+// UpdateSeries applies an edit to a whole series: the template, the open occurrences and,
+// when the schedule changes, the date of the latest open occurrence. A new rule or due date
+// restarts the series from that point, so COUNT is counted from there.
+func (s *RecurrenceService) UpdateSeries(todo *model.Todo, req model.UpdateTodoRequest) error {
+	if req.Completed != nil {
+		return errors.New("completion applies to a single occurrence; use scope=this")
+	}
+
+	series, err := s.seriesRepo.GetSeries(*todo.SeriesID)
+	if err != nil || series.UserID != todo.UserID {
+		return fmt.Errorf("series not found or access denied")
+	}
+
+	if req.Title != nil {
+		series.Title = *req.Title
+	}
+	if req.Content != nil {
+		series.Content = *req.Content
+	}
+
+	rule, err := recurrence.Parse(series.Rule)
+	if err != nil {
+		return fmt.Errorf("invalid stored rule for series %d: %w", series.ID, err)
+	}
+
+	rescheduled := false
+	if req.Recurrence != nil {
+		if rule, err = s.parseRule(*req.Recurrence); err != nil {
+			return err
+		}
+		series.Rule = rule.String()
+		series.Exceptions = exceptionDates(req.Recurrence.Exceptions)
+		if todo.OccurrenceAt != nil {
+			series.StartsAt = *todo.OccurrenceAt
+		}
+		rescheduled = true
+	}
+	if req.DueAt.Set {
+		if req.DueAt.Time == nil {
+			return errors.New("recurring todos need a due_at; delete the series to stop repeating")
+		}
+		series.StartsAt = req.DueAt.Time.UTC()
+		rescheduled = true
+	}
+	if req.RemindAt.Set {
+		due := todo.DueAt
+		if req.DueAt.Set {
+			due = req.DueAt.Time
+		}
+		series.RemindOffset = remindOffset(due, req.RemindAt.Time)
+	}
+
+	var next time.Time
+	if rescheduled {
+		latestDone, err := s.todoRepo.LatestOccurrence(series.ID, true)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if next, ok = nextOccurrence(series, rule, latestDone); !ok {
+			return errors.New("recurrence rule has no upcoming occurrences")
+		}
+	}
+
+	if err := s.seriesRepo.UpdateSeries(series); err != nil {
+		return err
+	}
+
+	open, err := s.todoRepo.GetOpenOccurrences(series.ID)
+	if err != nil {
+		return err
+	}
+	for i := range open {
+		occurrence := &open[i]
+		occurrence.Title = series.Title
+		occurrence.Content = series.Content
+		switch {
+		case rescheduled && i == len(open)-1:
+			series.Schedule(occurrence, next)
+		case req.RemindAt.Set:
+			// Keep each occurrence's own due date and recompute its reminder
+			occurrence.RemindAt, occurrence.RemindedAt = nil, nil
+			if series.RemindOffset != nil && occurrence.DueAt != nil {
+				remind := occurrence.DueAt.Add(-time.Duration(*series.RemindOffset) * time.Second)
+				occurrence.RemindAt = &remind
+			}
+		}
+		if err := s.todoRepo.UpdateTodo(occurrence, nil); err != nil {
+			return err
+		}
+	}
+
+	if rescheduled && len(open) == 0 {
+		_, err = s.Advance(series.ID)
+	}
+	return err
+}
+
+// DeleteSeries deletes the series a todo belongs to along with its open occurrences
+func (s *RecurrenceService) DeleteSeries(todo *model.Todo) (*model.TodoSeries, error) {
+	series, err := s.seriesRepo.GetSeries(*todo.SeriesID)
+	if err != nil || series.UserID != todo.UserID {
+		return nil, fmt.Errorf("series not found or access denied")
+	}
+
+	if err := s.seriesRepo.DeleteSeries(series.ID, todo.UserID); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// Occurrences lists the upcoming occurrences of a recurring todo's series after the todo itself
+func (s *RecurrenceService) Occurrences(todo *model.Todo, limit int) (*model.OccurrencesResponse, error) {
+	if todo.SeriesID == nil {
+		return nil, errors.New("todo is not recurring")
+	}
+	if limit <= 0 || limit > 100 {
+		limit = defaultPreviewLimit
+	}
+
+	series, err := s.seriesRepo.GetSeries(*todo.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := recurrence.Parse(series.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stored rule for series %d: %w", series.ID, err)
+	}
+
+	after := todo.DueAt
+	if todo.OccurrenceAt != nil {
+		after = todo.OccurrenceAt
+	}
+	start := series.StartsAt.In(series.Location())
+	if after == nil {
+		before := start.Add(-time.Nanosecond)
+		after = &before
+	}
+
+	loc := s.prefService.Location(todo.UserID)
+	series.StartsAt = series.StartsAt.In(loc)
+	return &model.OccurrencesResponse{
+		Series:      series,
+		Occurrences: inLocation(rule.After(start, *after, limit, series.Exceptions), loc),
+	}, nil
+}
+
+// Preview lists the first occurrences of a rule without saving anything. Times are expanded
+// in the user's timezone, starting now unless a start is given.
+func (s *RecurrenceService) Preview(userID int, req model.RecurrencePreviewRequest) (*model.OccurrencesResponse, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	rule, err := recurrence.Parse(req.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+
+	loc := s.prefService.Location(userID)
+	start := time.Now().In(loc).Truncate(time.Minute)
+	if req.Start != nil {
+		start = req.Start.In(loc)
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultPreviewLimit
+	}
+
+	return &model.OccurrencesResponse{
+		Occurrences: inLocation(rule.After(start, start.Add(-time.Nanosecond), limit, req.Exceptions), loc),
+	}, nil
+}
+
+func (s *RecurrenceService) parseRule(req model.RecurrenceRequest) (*recurrence.Rule, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	rule, err := recurrence.Parse(req.Rule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+	}
+	return rule, nil
+}
+
+// nextOccurrence returns the first occurrence after the given time, or the first one at or
+// after the series start when after is nil
+func nextOccurrence(series *model.TodoSeries, rule *recurrence.Rule, after *time.Time) (time.Time, bool) {
+	start := series.StartsAt.In(series.Location())
+	from := start.Add(-time.Nanosecond)
+	if after != nil && after.After(from) {
+		from = *after
+	}
+
+	occurrences := rule.After(start, from, 1, series.Exceptions)
+	if len(occurrences) == 0 {
+		return time.Time{}, false
+	}
+	return occurrences[0], true
+}
+
+// remindOffset converts a reminder time into seconds before the due time
+func remindOffset(dueAt, remindAt *time.Time) *int {
+	if dueAt == nil || remindAt == nil {
+		return nil
+	}
+	offset := int(dueAt.Sub(*remindAt).Seconds())
+	return &offset
+}
+
+func exceptionDates(dates []string) []string {
+	if dates == nil {
+		return []string{}
+	}
+	return dates
+}
+
+func inLocation(times []time.Time, loc *time.Location) []time.Time {
+	out := make([]time.Time, len(times))
+	for i, t := range times {
+		out[i] = t.In(loc)
+	}
+	return out
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"jmrashed/apps/userApp/auth"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	registrationSettingsKey = "registration"
+	defaultUserRoleID       = 2
+	defaultInviteTTL        = 7 * 24 * time.Hour
+)
+
+// DefaultRegistrationSettings returns the registration settings used until an admin changes them
+func DefaultRegistrationSettings() model.RegistrationSettings {
+	settings := model.RegistrationSettings{
+		Mode: getEnv("REGISTRATION_MODE", model.RegistrationModeOpen),
+	}
+	for _, domain := range strings.Split(getEnv("REGISTRATION_ALLOWED_DOMAINS", ""), ",") {
+		if domain = strings.TrimSpace(strings.ToLower(domain)); domain != "" {
+			settings.AllowedDomains = append(settings.AllowedDomains, domain)
+		}
+	}
+	return settings
+}
+
+type RegistrationService struct {
+	settingsRepo   *repository.SettingsRepository
+	invitationRepo *repository.InvitationRepository
+	orgRepo        *repository.OrganizationRepository
+	userRepo       *repository.UserRepository
+	auditService   *AuditService
+	defaults       model.RegistrationSettings
+	validator      *validator.Validate
+}
+
+func NewRegistrationService(settingsRepo *repository.SettingsRepository, invitationRepo *repository.InvitationRepository,
+	orgRepo *repository.OrganizationRepository, userRepo *repository.UserRepository,
+	auditService *AuditService, defaults model.RegistrationSettings) *RegistrationService {
+	return &RegistrationService{
+		settingsRepo:   settingsRepo,
+		invitationRepo: invitationRepo,
+		orgRepo:        orgRepo,
+		userRepo:       userRepo,
+		auditService:   auditService,
+		defaults:       defaults,
+		validator:      validator.New(),
+	}
+}
+
+// GetSettings returns the current registration settings
+func (s *RegistrationService) GetSettings() (*model.RegistrationSettings, error) {
+	value, err := s.settingsRepo.GetSetting(registrationSettingsKey)
+	if err != nil {
+		defaults := s.defaults
+		return &defaults, nil
+	}
+
+	var settings model.RegistrationSettings
+	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+		return nil, fmt.Errorf("failed to decode registration settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// UpdateSettings replaces the registration settings
+func (s *RegistrationService) UpdateSettings(settings model.RegistrationSettings, meta model.RequestMeta) (*model.RegistrationSettings, error) {
+	for i, domain := range settings.AllowedDomains {
+		settings.AllowedDomains[i] = strings.ToLower(strings.TrimSpace(domain))
+	}
+
+	if err := s.validator.Struct(settings); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if settings.Mode == model.RegistrationModeDomainAllowlist && len(settings.AllowedDomains) == 0 {
+		return nil, errors.New("domain-allowlist mode requires at least one allowed domain")
+	}
+
+	before, _ := s.GetSettings()
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode registration settings: %w", err)
+	}
+	if err := s.settingsRepo.SetSetting(registrationSettingsKey, string(value), meta.ActorID); err != nil {
+		return nil, err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "settings.registration_updated",
+		TargetType: "setting",
+		TargetID:   registrationSettingsKey,
+		Before:     before,
+		After:      settings,
+	})
+	return &settings, nil
+}
+
+// CheckRegistration decides whether email may register, returning the invitation to redeem if a code was given
+func (s *RegistrationService) CheckRegistration(email, inviteCode string) (*model.Invitation, error) {
+	settings, err := s.GetSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.Mode == model.RegistrationModeClosed {
+		return nil, errors.New("registration is closed")
+	}
+
+	var invitation *model.Invitation
+	if inviteCode != "" {
+		invitation, err = s.validateInvitation(email, inviteCode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch settings.Mode {
+	case model.RegistrationModeInviteOnly:
+		if invitation == nil {
+			return nil, errors.New("registration requires an invitation")
+		}
+	case model.RegistrationModeDomainAllowlist:
+		if invitation == nil && !emailDomainAllowed(email, settings.AllowedDomains) {
+			return nil, errors.New("registration is restricted to approved email domains")
+		}
+	}
+
+	return invitation, nil
+}
+
+// validateInvitation checks an invite code against its stored invitation
+func (s *RegistrationService) validateInvitation(email, code string) (*model.Invitation, error) {
+	id, err := auth.ParseInviteCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.invitationRepo.GetInvitationByID(id)
+	if err != nil {
+		return nil, errors.New("invalid invite code")
+	}
+
+	switch {
+	case invitation.RevokedAt != nil:
+		return nil, errors.New("invitation has been revoked")
+	case invitation.UsedAt != nil:
+		return nil, errors.New("invitation has already been used")
+	case time.Now().After(invitation.ExpiresAt):
+		return nil, errors.New("invite code has expired")
+	case invitation.Email != "" && !strings.EqualFold(invitation.Email, email):
+		return nil, errors.New("invitation was issued for a different email address")
+	}
+
+	return invitation, nil
+}
+
+// RecordRedemption records in the audit log that a user registered with an invitation
+func (s *RegistrationService) RecordRedemption(invitation *model.Invitation, userID int, meta model.RequestMeta) {
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "invitation.used",
+		TargetType: "invitation",
+		TargetID:   strconv.Itoa(invitation.ID),
+		After:      map[string]interface{}{"user_id": userID, "role_id": invitation.RoleID, "organization_id": invitation.OrganizationID},
+	})
+}
+
+// CreateInvitation issues a new signed invite code
+func (s *RegistrationService) CreateInvitation(req model.CreateInvitationRequest, meta model.RequestMeta) (*model.Invitation, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if _, err := s.userRepo.GetRoleByID(req.RoleID); err != nil {
+		return nil, errors.New("role not found")
+	}
+	if req.OrganizationID != nil {
+		if _, err := s.orgRepo.GetOrganizationByID(*req.OrganizationID); err != nil {
+			return nil, errors.New("organization not found")
+		}
+	}
+
+	ttl := defaultInviteTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+
+	invitation := &model.Invitation{
+		Email:          strings.ToLower(req.Email),
+		RoleID:         req.RoleID,
+		OrganizationID: req.OrganizationID,
+		CreatedBy:      optionalID(meta.ActorID),
+		ExpiresAt:      time.Now().Add(ttl).Truncate(time.Second),
+		CreatedAt:      time.Now(),
+	}
+	if err := s.invitationRepo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+	invitation.Code = auth.GenerateInviteCode(invitation.ID, invitation.ExpiresAt)
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "invitation.created",
+		TargetType: "invitation",
+		TargetID:   strconv.Itoa(invitation.ID),
+		After:      map[string]interface{}{"email": invitation.Email, "role_id": invitation.RoleID, "organization_id": invitation.OrganizationID},
+	})
+	return invitation, nil
+}
+
+// ListInvitations returns invitations, optionally including used, revoked and expired ones
+func (s *RegistrationService) ListInvitations(includeInactive bool) ([]model.Invitation, error) {
+	return s.invitationRepo.ListInvitations(includeInactive)
+}
+
+// RevokeInvitation revokes an unused invitation
+func (s *RegistrationService) RevokeInvitation(id int, meta model.RequestMeta) error {
+	if err := s.invitationRepo.RevokeInvitation(id); err != nil {
+		return err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "invitation.revoked",
+		TargetType: "invitation",
+		TargetID:   strconv.Itoa(id),
+	})
+	return nil
+}
+
+// CreateOrganization creates a new organization
+func (s *RegistrationService) CreateOrganization(req model.CreateOrganizationRequest, meta model.RequestMeta) (*model.Organization, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	org := &model.Organization{Name: req.Name, CreatedAt: time.Now()}
+	if err := s.orgRepo.CreateOrganization(org); err != nil {
+		return nil, err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "organization.created",
+		TargetType: "organization",
+		TargetID:   strconv.Itoa(org.ID),
+		After:      org,
+	})
+	return org, nil
+}
+
+// ListOrganizations returns all organizations
+func (s *RegistrationService) ListOrganizations() ([]model.Organization, error) {
+	return s.orgRepo.ListOrganizations()
+}
+
+// emailDomainAllowed reports whether the email's domain is in the allowlist
+func emailDomainAllowed(email string, allowed []string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, d := range allowed {
+		if domain == d {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/notify"
+	"jmrashed/apps/userApp/repository"
+)
+
+// ReminderConfig holds reminder scheduler configuration
+type ReminderConfig struct {
+	Interval  time.Duration // How often due reminders are checked
+	BatchSize int           // Maximum reminders sent per run
+}
+
+// DefaultReminderConfig returns reminder configuration from the environment
+func DefaultReminderConfig() ReminderConfig {
+	return ReminderConfig{
+		Interval:  getEnvDuration("REMINDER_INTERVAL", time.Minute),
+		BatchSize: getEnvInt("REMINDER_BATCH_SIZE", 100),
+	}
+}
+
+type ReminderService struct {
+	todoRepo    *repository.TodoRepository
+	userRepo    *repository.UserRepository
+	prefService *PreferenceService
+	notifier    notify.Notifier
+	config      ReminderConfig
+}
+
+func NewReminderService(todoRepo *repository.TodoRepository, userRepo *repository.UserRepository,
+	prefService *PreferenceService, notifier notify.Notifier, config ReminderConfig) *ReminderService {
+	return &ReminderService{
+		todoRepo:    todoRepo,
+		userRepo:    userRepo,
+		prefService: prefService,
+		notifier:    notifier,
+		config:      config,
+	}
+}
+
+// SendDueReminders notifies owners of open todos whose reminder time has passed. Each reminder
+// is claimed before sending so concurrent workers never send it twice.
+func (s *ReminderService) SendDueReminders() (int, error) {
+	now := time.Now()
+	todos, err := s.todoRepo.GetDueReminders(now, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range todos {
+		todo := &todos[i]
+		claimed, err := s.todoRepo.MarkReminded(todo.ID, now)
+		if err != nil {
+			log.Printf("Warning: Failed to claim reminder for todo %d: %v", todo.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		prefs, err := s.prefService.GetPreferences(todo.UserID)
+		if err != nil || !prefs.WantsNotification(model.NotificationReminder) {
+			continue
+		}
+		user, err := s.userRepo.GetUserByID(todo.UserID)
+		if err != nil {
+			// Inactive or deleted users get no reminders
+			continue
+		}
+
+		if err := s.notifier.Send(reminderMessage(user, todo, prefs.Location())); err != nil {
+			log.Printf("Warning: Failed to send reminder for todo %d: %v", todo.ID, err)
+			if err := s.todoRepo.ReleaseReminder(todo.ID); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// StartReminderWorker periodically sends due reminders in the background
+func (s *ReminderService) StartReminderWorker() {
+	if s.config.Interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sent, err := s.SendDueReminders()
+			if err != nil {
+				log.Printf("Warning: Failed to send reminders: %v", err)
+			} else if sent > 0 {
+				log.Printf("Sent %d todo reminders", sent)
+			}
+		}
+	}()
+}
+
+// reminderMessage renders a reminder with times in the user's timezone
+func reminderMessage(user *model.User, todo *model.Todo, loc *time.Location) notify.Message {
+	body := fmt.Sprintf("Hi %s,\n\nThis is your reminder for: %s\n", user.Username, todo.Title)
+	if todo.DueAt != nil {
+		due := todo.DueAt.In(loc)
+		if todo.IsOverdue(time.Now()) {
+			body += fmt.Sprintf("It was due %s.\n", due.Format("Mon, 02 Jan 2006 15:04 MST"))
+		} else {
+			body += fmt.Sprintf("It is due %s.\n", due.Format("Mon, 02 Jan 2006 15:04 MST"))
+		}
+	}
+	if todo.Content != "" {
+		body += "\n" + todo.Content + "\n"
+	}
+
+	return notify.Message{
+		To:      user.Email,
+		Subject: "Reminder: " + todo.Title,
+		Body:    body,
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"jmrashed/apps/userApp/database"
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/notify"
+	"jmrashed/apps/userApp/repository"
+	"jmrashed/apps/userApp/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testEnv holds services wired to a test database the way route.SetupRoutes wires them
+type testEnv struct {
+	db             *database.DB
+	userRepo       *repository.UserRepository
+	todoRepo       *repository.TodoRepository
+	prefService    *PreferenceService
+	todoService    *TodoService
+	trashService   *TrashService
+	archiveService *ArchiveService
+	bulkService    *BulkService
+	accountService *AccountService
+}
+
+// newTestEnv connects to the MySQL database named by TEST_DB_NAME, on the server configured by
+// DB_HOST, DB_PORT, DB_USER and DB_PASSWORD, and applies schema.sql to it. Tests using it are
+// skipped when TEST_DB_NAME is not set.
+func newTestEnv(t *testing.T) *testEnv {
+	name := os.Getenv("TEST_DB_NAME")
+	if name == "" {
+		t.Skip("TEST_DB_NAME is not set")
+	}
+
+	config := database.GetDefaultConfig()
+	config.DBName = name
+	db, err := database.NewConnection(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	schema, err := ioutil.ReadFile("../schema/schema.sql")
+	require.NoError(t, err)
+	_, err = db.Exec(string(schema))
+	require.NoError(t, err)
+
+	blobStore, err := storage.NewLocalStore(t.TempDir(), "/media")
+	require.NoError(t, err)
+	notifier, err := notify.New(notify.Config{Driver: notify.DriverLog})
+	require.NoError(t, err)
+
+	userRepo := repository.NewUserRepository(db.DB)
+	todoRepo := repository.NewTodoRepository(db.DB)
+	auditRepo := repository.NewAuditRepository(db.DB)
+
+	auditService := NewAuditService(auditRepo, DefaultAuditConfig())
+	prefService := NewPreferenceService(repository.NewPreferenceRepository(db.DB))
+	recurrenceService := NewRecurrenceService(repository.NewSeriesRepository(db.DB), todoRepo, prefService)
+	tagService := NewTagService(repository.NewTagRepository(db.DB))
+	projectRepo := repository.NewProjectRepository(db.DB)
+	workflowService := NewWorkflowService(repository.NewWorkflowRepository(db.DB), projectRepo, todoRepo, auditService)
+	projectService := NewProjectService(projectRepo, todoRepo, auditService, workflowService)
+	dependencyService := NewDependencyService(repository.NewDependencyRepository(db.DB), todoRepo, prefService, projectService)
+	subtaskService := NewSubtaskService(todoRepo, repository.NewChecklistRepository(db.DB), workflowService, dependencyService,
+		DefaultSubtaskConfig())
+	assigneeService := NewAssigneeService(repository.NewAssigneeRepository(db.DB), userRepo, todoRepo, prefService, notifier)
+	commentService := NewCommentService(repository.NewCommentRepository(db.DB), userRepo, assigneeService, prefService, notifier)
+	historyService := NewHistoryService(repository.NewRevisionRepository(db.DB), assigneeService, prefService,
+		DefaultHistoryConfig())
+	todoService := NewTodoService(todoRepo, auditService, prefService, recurrenceService, tagService, projectService,
+		subtaskService, workflowService, NewPositionService(todoRepo, DefaultPositionConfig()),
+		NewNextService(todoRepo, DefaultNextConfig()), assigneeService, commentService, historyService, dependencyService)
+	avatarService := NewAvatarService(userRepo, blobStore, auditService, DefaultAvatarConfig())
+
+	accountConfig := DefaultAccountConfig()
+	accountConfig.ExportDir = t.TempDir()
+
+	return &testEnv{
+		db:             db,
+		userRepo:       userRepo,
+		todoRepo:       todoRepo,
+		prefService:    prefService,
+		todoService:    todoService,
+		trashService:   NewTrashService(todoRepo, todoService, auditService, DefaultTrashConfig()),
+		archiveService: NewArchiveService(todoRepo, todoService, prefService, auditService, DefaultArchiveConfig()),
+		bulkService: NewBulkService(todoRepo, todoService, projectService, assigneeService, auditService,
+			DefaultBulkConfig()),
+		accountService: NewAccountService(userRepo, todoRepo, repository.NewExportRepository(db.DB), auditRepo,
+			auditService, avatarService, accountConfig),
+	}
+}
+
+// createUser creates an active user with a unique name
+func (e *testEnv) createUser(t *testing.T) *model.User {
+	name := fmt.Sprintf("user%d", time.Now().UnixNano())
+	user := &model.User{Username: name, Email: name + "@example.com", PasswordHash: "x", IsActive: true}
+	require.NoError(t, e.userRepo.CreateUser(user))
+	return user
+}
+
+// createTodo creates a todo for userID, as a subtask when parentID is set
+func (e *testEnv) createTodo(t *testing.T, userID int, title string, parentID *int) *model.Todo {
+	todo, err := e.todoService.CreateTodo(userID, model.CreateTodoRequest{Title: title, ParentID: parentID})
+	require.NoError(t, err)
+	return todo
+}
+
+// complete marks a todo owned by userID completed
+func (e *testEnv) complete(t *testing.T, userID, id int) {
+	completed := true
+	_, err := e.todoService.UpdateTodo(id, userID, model.UpdateTodoRequest{Completed: &completed}, model.EditScopeThis)
+	require.NoError(t, err)
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// SubtaskConfig holds subtask configuration
+type SubtaskConfig struct {
+	MaxDepth int // Maximum levels of subtasks below a top-level todo
+}
+
+// DefaultSubtaskConfig returns subtask configuration from the environment
+func DefaultSubtaskConfig() SubtaskConfig {
+	return SubtaskConfig{
+		MaxDepth: getEnvInt("SUBTASK_MAX_DEPTH", 5),
+	}
+}
+
+type SubtaskService struct {
+	todoRepo          *repository.TodoRepository
+	checklistRepo     *repository.ChecklistRepository
+	workflowService   *WorkflowService
+	dependencyService *DependencyService
+	config            SubtaskConfig
+	validator         *validator.Validate
+}
+
+func NewSubtaskService(todoRepo *repository.TodoRepository, checklistRepo *repository.ChecklistRepository,
+	workflowService *WorkflowService, dependencyService *DependencyService, config SubtaskConfig) *SubtaskService {
+	return &SubtaskService{
+		todoRepo:          todoRepo,
+		checklistRepo:     checklistRepo,
+		workflowService:   workflowService,
+		dependencyService: dependencyService,
+		config:            config,
+		validator:         validator.New(),
+	}
+}
+
+// CheckParent verifies that a todo can be placed under parentID: the parent belongs to the
+// user, is not archived, is not the todo or one of its subtasks, and the todo's subtree stays
+// within the depth limit. todoID is 0 for a todo that has not been saved yet.
+func (s *SubtaskService) CheckParent(todoID, parentID, userID int) error {
+	parent, err := s.todoRepo.GetTodoByID(parentID)
+	if err != nil || parent.UserID != userID {
+		return fmt.Errorf("parent todo not found or access denied")
+	}
+	if parent.ArchivedAt != nil {
+		return errors.New("parent todo is archived")
+	}
+	if parentID == todoID {
+		return errors.New("a todo cannot be its own subtask")
+	}
+
+	ancestors, err := s.todoRepo.GetAncestorIDs(parentID)
+	if err != nil {
+		return err
+	}
+
+	height := 0
+	if todoID != 0 {
+		for _, id := range ancestors {
+			if id == todoID {
+				return errors.New("a todo cannot be moved under one of its own subtasks")
+			}
+		}
+		if height, err = s.todoRepo.GetSubtreeHeight(todoID); err != nil {
+			return err
+		}
+	}
+
+	if len(ancestors)+1+height > s.config.MaxDepth {
+		return fmt.Errorf("subtasks can be nested at most %d levels deep", s.config.MaxDepth)
+	}
+	return nil
+}
+
+// LoadProgress fills in the progress of todos that have subtasks or checklist items
+func (s *SubtaskService) LoadProgress(todos ...*model.Todo) {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+		todo.Progress = nil
+	}
+
+	subtasks, err := s.todoRepo.CountSubtasks(ids)
+	if err != nil {
+		log.Printf("Warning: Failed to count subtasks: %v", err)
+		return
+	}
+	items, err := s.checklistRepo.CountItems(ids)
+	if err != nil {
+		log.Printf("Warning: Failed to count checklist items: %v", err)
+		return
+	}
+
+	for _, todo := range todos {
+		progress := &model.Progress{}
+		progress.Add(subtasks[todo.ID][0], subtasks[todo.ID][1])
+		progress.Add(items[todo.ID][0], items[todo.ID][1])
+		if progress.Total > 0 {
+			todo.Progress = progress
+		}
+	}
+}
+
+// LoadChecklists fills in the checklist items of todos
+func (s *SubtaskService) LoadChecklists(todos ...*model.Todo) error {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+	}
+
+	items, err := s.checklistRepo.GetItemsForTodos(ids)
+	if err != nil {
+		return err
+	}
+	for _, todo := range todos {
+		todo.Checklist = items[todo.ID]
+	}
+	return nil
+}
+
+// SyncCompletion walks up from parentID and completes each auto-completing parent whose
+// subtasks are all done, or reopens it when one of them is not. Parents held back by their
+// dependencies stay open. It returns the todos it completed.
+func (s *SubtaskService) SyncCompletion(parentID *int) ([]*model.Todo, error) {
+	var completed []*model.Todo
+	for parentID != nil {
+		parent, err := s.todoRepo.GetTodoByID(*parentID)
+		if err != nil {
+			return completed, err
+		}
+		if !parent.AutoComplete {
+			return completed, nil
+		}
+
+		counts, err := s.todoRepo.CountSubtasks([]int{parent.ID})
+		if err != nil {
+			return completed, err
+		}
+		done := counts[parent.ID][1] > 0 && counts[parent.ID][0] == counts[parent.ID][1]
+		if done == parent.Completed {
+			return completed, nil
+		}
+		if done && s.dependencyService.CheckCompletion(parent) != nil {
+			return completed, nil
+		}
+
+		if err := s.workflowService.SetCompleted(parent, done); err != nil {
+			return completed, err
+		}
+		if done {
+			completed = append(completed, parent)
+		}
+		if err := s.todoRepo.UpdateTodo(parent, nil); err != nil {
+			return completed, err
+		}
+		parentID = parent.ParentID
+	}
+	return completed, nil
+}
+
+// AddChecklistItem adds an item to the checklist of a user's todo, at the end unless a
+// position is given
+func (s *SubtaskService) AddChecklistItem(todoID, userID int, req model.CreateChecklistItemRequest) (*model.ChecklistItem, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOwner(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	item := &model.ChecklistItem{TodoID: todoID, Content: req.Content, Position: -1}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+	if err := s.checklistRepo.CreateItem(item); err != nil {
+		return nil, err
+	}
+
+	return s.checklistRepo.GetItem(item.ID, todoID)
+}
+
+// UpdateChecklistItem edits, checks or moves an item on the checklist of a user's todo
+func (s *SubtaskService) UpdateChecklistItem(todoID, itemID, userID int, req model.UpdateChecklistItemRequest) (*model.ChecklistItem, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkOwner(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	item, err := s.checklistRepo.GetItem(itemID, todoID)
+	if err != nil {
+		return nil, fmt.Errorf("checklist item not found")
+	}
+
+	if req.Content != nil {
+		item.Content = *req.Content
+	}
+	if req.Checked != nil {
+		item.Checked = *req.Checked
+	}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+
+	if err := s.checklistRepo.UpdateItem(item); err != nil {
+		return nil, err
+	}
+	return s.checklistRepo.GetItem(itemID, todoID)
+}
+
+// DeleteChecklistItem removes an item from the checklist of a user's todo
+func (s *SubtaskService) DeleteChecklistItem(todoID, itemID, userID int) error {
+	if err := s.checkOwner(todoID, userID); err != nil {
+		return err
+	}
+	return s.checklistRepo.DeleteItem(itemID, todoID)
+}
+
+func (s *SubtaskService) checkOwner(todoID, userID int) error {
+	todo, err := s.todoRepo.GetTodoByID(todoID)
+	if err != nil || todo.UserID != userID {
+		return fmt.Errorf("todo not found or access denied")
+	}
+	return nil
+}
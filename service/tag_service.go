@@ -0,0 +1,138 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type TagService struct {
+	tagRepo   *repository.TagRepository
+	validator *validator.Validate
+}
+
+func NewTagService(tagRepo *repository.TagRepository) *TagService {
+	return &TagService{
+		tagRepo:   tagRepo,
+		validator: validator.New(),
+	}
+}
+
+// ListTags returns a user's tags with their usage counts
+func (s *TagService) ListTags(userID int) ([]model.Tag, error) {
+	return s.tagRepo.ListTags(userID)
+}
+
+// CreateTag creates a tag; names are unique per user
+func (s *TagService) CreateTag(userID int, req model.CreateTagRequest) (*model.Tag, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	if _, err := s.tagRepo.GetTagByName(userID, req.Name); err == nil {
+		return nil, errors.New("tag already exists")
+	}
+
+	tag := &model.Tag{
+		UserID: userID,
+		Name:   req.Name,
+		Color:  model.DefaultTagColor,
+	}
+	if req.Color != "" {
+		tag.Color = strings.ToLower(req.Color)
+	}
+
+	if err := s.tagRepo.CreateTag(tag); err != nil {
+		return nil, err
+	}
+	return s.tagRepo.GetTagByID(tag.ID)
+}
+
+// UpdateTag renames or recolors a tag
+func (s *TagService) UpdateTag(id, userID int, req model.UpdateTagRequest) (*model.Tag, error) {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	tag, err := s.tagRepo.GetTagByID(id)
+	if err != nil || tag.UserID != userID {
+		return nil, fmt.Errorf("tag not found or access denied")
+	}
+
+	if req.Name != nil && *req.Name != tag.Name {
+		if existing, err := s.tagRepo.GetTagByName(userID, *req.Name); err == nil && existing.ID != id {
+			return nil, errors.New("tag already exists")
+		}
+		tag.Name = *req.Name
+	}
+	if req.Color != nil {
+		tag.Color = strings.ToLower(*req.Color)
+	}
+
+	if err := s.tagRepo.UpdateTag(tag); err != nil {
+		return nil, err
+	}
+	return s.tagRepo.GetTagByID(id)
+}
+
+// DeleteTag deletes a tag and detaches it from its todos
+func (s *TagService) DeleteTag(id, userID int) error {
+	return s.tagRepo.DeleteTag(id, userID)
+}
+
+// ResolveTags checks that every tag belongs to the user and returns the IDs without duplicates
+func (s *TagService) ResolveTags(userID int, tagIDs []int) ([]int, error) {
+	ids := uniqueIDs(tagIDs)
+	count, err := s.tagRepo.CountUserTags(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	if count != len(ids) {
+		return nil, errors.New("unknown tag")
+	}
+	return ids, nil
+}
+
+// LoadTags fills in the tags of each todo
+func (s *TagService) LoadTags(todos ...*model.Todo) {
+	ids := make([]int, len(todos))
+	for i, todo := range todos {
+		ids[i] = todo.ID
+		todo.Tags = []model.Tag{}
+	}
+
+	tags, err := s.tagRepo.GetTagsForTodos(ids)
+	if err != nil {
+		log.Printf("Warning: Failed to load todo tags: %v", err)
+		return
+	}
+	for _, todo := range todos {
+		if todoTags, ok := tags[todo.ID]; ok {
+			todo.Tags = todoTags
+		}
+	}
+}
+
+// uniqueIDs drops duplicate IDs, keeping the first occurrence of each
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// TemplateConfig holds todo template configuration
+type TemplateConfig struct {
+	MaxItems int // Todos a template may create, subtasks included
+}
+
+// DefaultTemplateConfig returns todo template configuration from the environment
+func DefaultTemplateConfig() TemplateConfig {
+	return TemplateConfig{
+		MaxItems: getEnvInt("TEMPLATE_MAX_ITEMS", 200),
+	}
+}
+
+type TemplateService struct {
+	templateRepo    *repository.TemplateRepository
+	orgRepo         *repository.OrganizationRepository
+	todoRepo        *repository.TodoRepository
+	todoService     *TodoService
+	projectService  *ProjectService
+	workflowService *WorkflowService
+	tagService      *TagService
+	subtaskService  *SubtaskService
+	auditService    *AuditService
+	config          TemplateConfig
+	validator       *validator.Validate
+}
+
+func NewTemplateService(templateRepo *repository.TemplateRepository, orgRepo *repository.OrganizationRepository,
+	todoRepo *repository.TodoRepository, todoService *TodoService, projectService *ProjectService,
+	workflowService *WorkflowService, tagService *TagService, subtaskService *SubtaskService, auditService *AuditService,
+	config TemplateConfig) *TemplateService {
+	return &TemplateService{
+		templateRepo:    templateRepo,
+		orgRepo:         orgRepo,
+		todoRepo:        todoRepo,
+		todoService:     todoService,
+		projectService:  projectService,
+		workflowService: workflowService,
+		tagService:      tagService,
+		subtaskService:  subtaskService,
+		auditService:    auditService,
+		config:          config,
+		validator:       validator.New(),
+	}
+}
+
+// ListTemplates returns the templates a user created or shares through an organization
+func (s *TemplateService) ListTemplates(userID int) ([]model.Template, error) {
+	templates, err := s.templateRepo.ListTemplates(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		templates[i].Variables = templates[i].FindVariables()
+	}
+	return templates, nil
+}
+
+// GetTemplate returns a template the user created or shares through an organization
+func (s *TemplateService) GetTemplate(id, userID int) (*model.Template, error) {
+	template, err := s.templateRepo.GetTemplate(id)
+	if err != nil || !s.canUse(template, userID) {
+		return nil, fmt.Errorf("template not found or access denied")
+	}
+	template.Variables = template.FindVariables()
+	return template, nil
+}
+
+// CreateTemplate creates a template, shared with an organization the user belongs to when one
+// is given
+func (s *TemplateService) CreateTemplate(userID int, req model.CreateTemplateRequest, meta model.RequestMeta) (*model.Template, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkItems(req.Items); err != nil {
+		return nil, err
+	}
+	if req.OrganizationID != nil {
+		if member, err := s.orgRepo.IsMember(*req.OrganizationID, userID); err != nil || !member {
+			return nil, fmt.Errorf("organization not found or access denied")
+		}
+	}
+
+	template := &model.Template{
+		UserID:         userID,
+		OrganizationID: req.OrganizationID,
+		Name:           req.Name,
+		Description:    req.Description,
+		ProjectName:    strings.TrimSpace(req.ProjectName),
+		Items:          req.Items,
+	}
+	if err := s.templateRepo.CreateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "template.created",
+		TargetType: "template",
+		TargetID:   strconv.Itoa(template.ID),
+		After:      map[string]interface{}{"name": template.Name, "organization_id": template.OrganizationID},
+	})
+	return s.templateRepo.GetTemplate(template.ID)
+}
+
+// CreateTemplateFromProject creates a template from the todos of a project owned by userID in
+// their manual order. Due dates become offsets from when the project was created.
+func (s *TemplateService) CreateTemplateFromProject(userID int, req model.CreateTemplateFromProjectRequest, meta model.RequestMeta) (*model.Template, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	project, err := s.projectService.GetProject(req.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := s.todoRepo.GetBoardTodos(userID, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(todos) == 0 {
+		return nil, errors.New("project has no todos")
+	}
+	sort.SliceStable(todos, func(i, j int) bool { return todos[i].Position < todos[j].Position })
+	ptrs := make([]*model.Todo, len(todos))
+	for i := range todos {
+		ptrs[i] = &todos[i]
+	}
+	s.tagService.LoadTags(ptrs...)
+
+	projectName := project.Name
+	if req.ProjectName != nil {
+		projectName = *req.ProjectName
+	}
+	return s.CreateTemplate(userID, model.CreateTemplateRequest{
+		Name:           req.Name,
+		Description:    req.Description,
+		OrganizationID: req.OrganizationID,
+		ProjectName:    projectName,
+		Items:          model.TemplateItemsFromTodos(ptrs, project.CreatedAt),
+	}, meta)
+}
+
+// UpdateTemplate applies the fields present in req to a template created by userID
+func (s *TemplateService) UpdateTemplate(id, userID int, req model.UpdateTemplateRequest) (*model.Template, error) {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	template, err := s.templateRepo.GetTemplate(id)
+	if err != nil || template.UserID != userID {
+		return nil, fmt.Errorf("template not found or access denied")
+	}
+
+	if req.Name != nil {
+		template.Name = *req.Name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.ProjectName != nil {
+		template.ProjectName = strings.TrimSpace(*req.ProjectName)
+	}
+	if req.Items != nil {
+		if err := s.checkItems(*req.Items); err != nil {
+			return nil, err
+		}
+		template.Items = *req.Items
+	}
+
+	if err := s.templateRepo.UpdateTemplate(template); err != nil {
+		return nil, err
+	}
+	return s.GetTemplate(id, userID)
+}
+
+// DeleteTemplate deletes a template created by userID
+func (s *TemplateService) DeleteTemplate(id, userID int, meta model.RequestMeta) error {
+	if err := s.templateRepo.DeleteTemplate(id, userID); err != nil {
+		return err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "template.deleted",
+		TargetType: "template",
+		TargetID:   strconv.Itoa(id),
+	})
+	return nil
+}
+
+// Instantiate creates the todos of a template for userID in one transaction, filling in its
+// variables. The todos go into the project given in req, or a new project when the template
+// names one.
+func (s *TemplateService) Instantiate(id, userID int, req model.InstantiateTemplateRequest, meta model.RequestMeta) (*model.TemplateInstance, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	template, err := s.GetTemplate(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkItems(template.Items); err != nil {
+		return nil, err
+	}
+
+	plan := &model.TemplatePlan{UserID: userID}
+	var projectID *int
+	if req.ProjectID != nil {
+		if err := s.projectService.CheckAssignable(*req.ProjectID, userID); err != nil {
+			return nil, err
+		}
+		projectID = req.ProjectID
+	} else if template.ProjectName != "" {
+		name, err := model.Substitute(template.ProjectName, req.Variables)
+		if err != nil {
+			return nil, err
+		}
+		project := model.CreateProjectRequest{Name: strings.TrimSpace(name)}
+		if err := s.validator.Struct(project); err != nil {
+			return nil, fmt.Errorf("invalid project name: %w", err)
+		}
+		plan.Project = &model.Project{UserID: userID, Name: project.Name, Color: model.DefaultProjectColor}
+	}
+
+	start := time.Now()
+	if req.StartAt != nil {
+		start = *req.StartAt
+	}
+	var add func(item model.TemplateItem, parent int) error
+	add = func(item model.TemplateItem, parent int) error {
+		todo, tags, err := s.planTodo(item, userID, projectID, start, req.Variables)
+		if err != nil {
+			return err
+		}
+		plan.Todos = append(plan.Todos, todo)
+		plan.Parents = append(plan.Parents, parent)
+		plan.Tags = append(plan.Tags, tags)
+		index := len(plan.Todos) - 1
+		for _, subtask := range item.Subtasks {
+			if err := add(subtask, index); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, item := range template.Items {
+		if err := add(item, -1); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.templateRepo.Instantiate(plan); err != nil {
+		return nil, err
+	}
+
+	instance := &model.TemplateInstance{Todos: plan.Todos}
+	if plan.Project != nil {
+		if instance.Project, err = s.projectService.GetProject(plan.Project.ID, userID); err != nil {
+			return nil, err
+		}
+		projectID = &plan.Project.ID
+	}
+	s.todoService.PresentTodos(userID, instance.Todos...)
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "template.instantiated",
+		TargetType: "template",
+		TargetID:   strconv.Itoa(id),
+		After:      map[string]interface{}{"todos": len(instance.Todos), "project_id": projectID},
+	})
+	return instance, nil
+}
+
+// planTodo builds the todo of a template item with its variables filled in, along with its
+// tag names
+func (s *TemplateService) planTodo(item model.TemplateItem, userID int, projectID *int, start time.Time,
+	values map[string]string) (*model.Todo, []string, error) {
+	title, err := model.Substitute(item.Title, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := model.Substitute(item.Content, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	req := model.CreateTodoRequest{Title: strings.TrimSpace(title), Content: content, Priority: item.Priority}
+	if err := s.validator.Struct(req); err != nil {
+		return nil, nil, fmt.Errorf("invalid todo %q: %w", item.Title, err)
+	}
+
+	todo := &model.Todo{UserID: userID, Title: req.Title, Content: req.Content, ProjectID: projectID}
+	if item.DueOffset != "" {
+		offset, err := model.ParseOffset(item.DueOffset)
+		if err != nil {
+			return nil, nil, err
+		}
+		due := start.Add(offset).UTC()
+		todo.DueAt = &due
+	}
+	if item.Priority != "" {
+		if todo.Priority, err = model.ParsePriority(item.Priority); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := s.workflowService.InitStatus(todo, ""); err != nil {
+		return nil, nil, err
+	}
+
+	tags := make([]string, len(item.Tags))
+	for i, tag := range item.Tags {
+		if tags[i], err = model.Substitute(tag, values); err != nil {
+			return nil, nil, err
+		}
+		if len([]rune(strings.TrimSpace(tags[i]))) > 50 {
+			return nil, nil, fmt.Errorf("tag %q is longer than 50 characters", tags[i])
+		}
+	}
+	return todo, model.TrimTags(tags), nil
+}
+
+// checkItems verifies that template items stay within the item and subtask depth limits and
+// have valid due offsets
+func (s *TemplateService) checkItems(items []model.TemplateItem) error {
+	if count := model.CountItems(items); count > s.config.MaxItems {
+		return fmt.Errorf("templates can create at most %d todos", s.config.MaxItems)
+	}
+	if depth := model.ItemDepth(items); depth > s.subtaskService.config.MaxDepth {
+		return fmt.Errorf("subtasks can be nested at most %d levels deep", s.subtaskService.config.MaxDepth)
+	}
+
+	var check func(items []model.TemplateItem) error
+	check = func(items []model.TemplateItem) error {
+		for _, item := range items {
+			if _, err := model.ParseOffset(item.DueOffset); err != nil {
+				return err
+			}
+			if err := check(item.Subtasks); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return check(items)
+}
+
+// canUse reports whether a user created a template or shares it through an organization
+func (s *TemplateService) canUse(template *model.Template, userID int) bool {
+	if template.UserID == userID {
+		return true
+	}
+	if template.OrganizationID == nil {
+		return false
+	}
+	member, err := s.orgRepo.IsMember(*template.OrganizationID, userID)
+	return err == nil && member
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// TimeConfig holds time tracking configuration
+type TimeConfig struct {
+	MaxEntryDuration time.Duration // Longest time entry that can be entered by hand
+	MaxReportDays    int           // Longest date range of a time report
+}
+
+// DefaultTimeConfig returns time tracking configuration from the environment
+func DefaultTimeConfig() TimeConfig {
+	return TimeConfig{
+		MaxEntryDuration: getEnvDuration("TIME_ENTRY_MAX_DURATION", 24*time.Hour),
+		MaxReportDays:    getEnvInt("TIME_REPORT_MAX_DAYS", 366),
+	}
+}
+
+type TimeService struct {
+	timeRepo        *repository.TimeRepository
+	tagRepo         *repository.TagRepository
+	assigneeService *AssigneeService
+	prefService     *PreferenceService
+	projectService  *ProjectService
+	tagService      *TagService
+	config          TimeConfig
+	validator       *validator.Validate
+}
+
+func NewTimeService(timeRepo *repository.TimeRepository, tagRepo *repository.TagRepository,
+	assigneeService *AssigneeService, prefService *PreferenceService, projectService *ProjectService, tagService *TagService,
+	config TimeConfig) *TimeService {
+	return &TimeService{
+		timeRepo:        timeRepo,
+		tagRepo:         tagRepo,
+		assigneeService: assigneeService,
+		prefService:     prefService,
+		projectService:  projectService,
+		tagService:      tagService,
+		config:          config,
+		validator:       validator.New(),
+	}
+}
+
+// StartTimer starts the user's timer on a todo they own or are assigned to, stopping their
+// timer on any other todo. A timer already running on the todo is returned as it is.
+func (s *TimeService) StartTimer(todoID, userID int, req model.StartTimerRequest) (*model.TimeEntry, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	todo, err := s.assigneeService.CheckAccess(todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if todo.ArchivedAt != nil {
+		return nil, ErrTodoArchived
+	}
+
+	entry := &model.TimeEntry{TodoID: todoID, UserID: userID, StartedAt: time.Now().UTC(), Note: req.Note}
+	if _, err := s.timeRepo.StartTimer(entry); err != nil {
+		return nil, err
+	}
+	return s.getEntry(entry.ID, todoID, userID)
+}
+
+// StopTimer stops the user's running timer
+func (s *TimeService) StopTimer(userID int) (*model.TimeEntry, error) {
+	running, err := s.timeRepo.GetRunningTimer(userID)
+	if err != nil {
+		return nil, errors.New("no timer is running")
+	}
+	if _, err := s.timeRepo.StopTimer(userID, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return s.getEntry(running.ID, running.TodoID, userID)
+}
+
+// RunningTimer returns the user's running timer, or nil when none runs
+func (s *TimeService) RunningTimer(userID int) *model.TimeEntry {
+	entry, err := s.timeRepo.GetRunningTimer(userID)
+	if err != nil {
+		return nil
+	}
+	s.present(userID, entry)
+	return entry
+}
+
+// CreateEntry records time the user spent on a todo they own or are assigned to
+func (s *TimeService) CreateEntry(todoID, userID int, req model.CreateTimeEntryRequest) (*model.TimeEntry, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	ended := req.EndedAt.UTC()
+	entry := &model.TimeEntry{TodoID: todoID, UserID: userID, StartedAt: req.StartedAt.UTC(), EndedAt: &ended, Note: req.Note}
+	if err := s.checkTimes(entry); err != nil {
+		return nil, err
+	}
+	if err := s.timeRepo.CreateEntry(entry); err != nil {
+		return nil, err
+	}
+	return s.getEntry(entry.ID, todoID, userID)
+}
+
+// UpdateEntry changes the times or note of the user's own time entry. A running timer keeps
+// running unless ended_at is given.
+func (s *TimeService) UpdateEntry(todoID, entryID, userID int, req model.UpdateTimeEntryRequest) (*model.TimeEntry, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	entry, err := s.timeRepo.GetEntry(entryID, todoID)
+	if err != nil || entry.UserID != userID {
+		return nil, fmt.Errorf("time entry not found or access denied")
+	}
+	if req.StartedAt != nil {
+		entry.StartedAt = req.StartedAt.UTC()
+	}
+	if req.EndedAt != nil {
+		ended := req.EndedAt.UTC()
+		entry.EndedAt = &ended
+	}
+	if req.Note != nil {
+		entry.Note = *req.Note
+	}
+	if err := s.checkTimes(entry); err != nil {
+		return nil, err
+	}
+
+	if err := s.timeRepo.UpdateEntry(entry); err != nil {
+		return nil, err
+	}
+	return s.getEntry(entryID, todoID, userID)
+}
+
+// DeleteEntry deletes a time entry. Users can delete their own entries and todo owners any
+// entry on their todo.
+func (s *TimeService) DeleteEntry(todoID, entryID, userID int) error {
+	todo, err := s.assigneeService.CheckAccess(todoID, userID)
+	if err != nil {
+		return err
+	}
+
+	entry, err := s.timeRepo.GetEntry(entryID, todoID)
+	if err != nil || (entry.UserID != userID && todo.UserID != userID) {
+		return fmt.Errorf("time entry not found or access denied")
+	}
+	return s.timeRepo.DeleteEntry(entryID, todoID)
+}
+
+// TodoTime returns the time entries of a todo the user owns or is assigned to, with their total
+func (s *TimeService) TodoTime(todoID, userID int) (*model.TodoTime, error) {
+	if _, err := s.assigneeService.CheckAccess(todoID, userID); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.timeRepo.GetTodoEntries(todoID)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.TodoTime{Entries: entries}
+	for i := range entries {
+		s.present(userID, &entries[i])
+		result.TotalSeconds += entries[i].Seconds
+	}
+	return result, nil
+}
+
+// Report aggregates the time the user tracked, and the time tracked on todos they own, by day
+// or week and by todo, project or tag. Dates are the user's local dates; without them the
+// report covers the last 30 days.
+func (s *TimeService) Report(userID int, req model.TimeReportRequest) (*model.TimeReport, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	loc := s.prefService.Location(userID)
+
+	to := time.Now().In(loc)
+	if req.To != "" {
+		to, _ = time.ParseInLocation("2006-01-02", req.To, loc)
+	}
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+	from := to.AddDate(0, 0, -29)
+	if req.From != "" {
+		from, _ = time.ParseInLocation("2006-01-02", req.From, loc)
+	}
+	if from.After(to) {
+		return nil, errors.New("from must not be after to")
+	}
+	if !from.AddDate(0, 0, s.config.MaxReportDays).After(to) {
+		return nil, fmt.Errorf("reports cover at most %d days", s.config.MaxReportDays)
+	}
+
+	if req.ProjectID != nil {
+		if _, err := s.projectService.GetProject(*req.ProjectID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if req.TagID != nil {
+		if _, err := s.tagService.ResolveTags(userID, []int{*req.TagID}); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := s.timeRepo.GetReportEntries(userID, from.UTC(), to.AddDate(0, 0, 1).UTC(), req.TodoID, req.ProjectID, req.TagID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range entries {
+		entries[i].Measure(now)
+	}
+	if req.GroupBy == model.TimeGroupTag {
+		if err := s.loadTags(entries); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &model.TimeReport{
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		Period:  req.Period,
+		GroupBy: req.GroupBy,
+		Rows:    model.AggregateTime(entries, req.Period, req.GroupBy, loc),
+	}
+	for _, entry := range entries {
+		report.TotalSeconds += entry.Seconds
+	}
+	return report, nil
+}
+
+// checkTimes verifies that a time entry ends after it starts, within the longest entry
+// allowed, and does not lie in the future
+func (s *TimeService) checkTimes(entry *model.TimeEntry) error {
+	now := time.Now()
+	if entry.StartedAt.After(now) {
+		return errors.New("started_at must not be in the future")
+	}
+	if entry.EndedAt == nil {
+		return nil
+	}
+	if !entry.EndedAt.After(entry.StartedAt) {
+		return errors.New("ended_at must be after started_at")
+	}
+	if entry.EndedAt.After(now) {
+		return errors.New("ended_at must not be in the future")
+	}
+	if entry.EndedAt.Sub(entry.StartedAt) > s.config.MaxEntryDuration {
+		return fmt.Errorf("time entries can be at most %s long", s.config.MaxEntryDuration)
+	}
+	return nil
+}
+
+// loadTags sets the tags of the todos of time entries
+func (s *TimeService) loadTags(entries []model.TimeEntry) error {
+	seen := map[int]bool{}
+	var ids []int
+	for _, entry := range entries {
+		if !seen[entry.TodoID] {
+			seen[entry.TodoID] = true
+			ids = append(ids, entry.TodoID)
+		}
+	}
+
+	tags, err := s.tagRepo.GetTagsForTodos(ids)
+	if err != nil {
+		return err
+	}
+	for i := range entries {
+		entries[i].Tags = tags[entries[i].TodoID]
+	}
+	return nil
+}
+
+func (s *TimeService) getEntry(id, todoID, userID int) (*model.TimeEntry, error) {
+	entry, err := s.timeRepo.GetEntry(id, todoID)
+	if err != nil {
+		return nil, err
+	}
+	s.present(userID, entry)
+	return entry, nil
+}
+
+// present measures time entries and converts their timestamps to the viewer's preferred timezone
+func (s *TimeService) present(viewerID int, entries ...*model.TimeEntry) {
+	loc := s.prefService.Location(viewerID)
+	now := time.Now()
+	for _, entry := range entries {
+		entry.Measure(now)
+		entry.In(loc)
+	}
+}
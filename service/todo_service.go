@@ -1,7 +1,11 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"time"
 
 	"jmrashed/apps/userApp/model"
 	"jmrashed/apps/userApp/repository"
@@ -10,40 +14,167 @@ import (
 )
 
 type TodoService struct {
-	todoRepo  *repository.TodoRepository
-	validator *validator.Validate
+	todoRepo          *repository.TodoRepository
+	auditService      *AuditService
+	prefService       *PreferenceService
+	recurrenceService *RecurrenceService
+	tagService        *TagService
+	projectService    *ProjectService
+	subtaskService    *SubtaskService
+	workflowService   *WorkflowService
+	positionService   *PositionService
+	nextService       *NextService
+	assigneeService   *AssigneeService
+	commentService    *CommentService
+	historyService    *HistoryService
+	dependencyService *DependencyService
+	validator         *validator.Validate
 }
 
-func NewTodoService(todoRepo *repository.TodoRepository) *TodoService {
+func NewTodoService(todoRepo *repository.TodoRepository, auditService *AuditService, prefService *PreferenceService,
+	recurrenceService *RecurrenceService, tagService *TagService, projectService *ProjectService,
+	subtaskService *SubtaskService, workflowService *WorkflowService, positionService *PositionService,
+	nextService *NextService, assigneeService *AssigneeService, commentService *CommentService,
+	historyService *HistoryService, dependencyService *DependencyService) *TodoService {
 	return &TodoService{
-		todoRepo:  todoRepo,
-		validator: validator.New(),
+		todoRepo:          todoRepo,
+		auditService:      auditService,
+		prefService:       prefService,
+		recurrenceService: recurrenceService,
+		tagService:        tagService,
+		projectService:    projectService,
+		subtaskService:    subtaskService,
+		workflowService:   workflowService,
+		positionService:   positionService,
+		nextService:       nextService,
+		assigneeService:   assigneeService,
+		commentService:    commentService,
+		historyService:    historyService,
+		dependencyService: dependencyService,
+		validator:         validator.New(),
 	}
 }
 
+// PresentTodos converts todo timestamps to the viewer's preferred timezone, flags overdue todos
+// and loads their tags, assignees, comment counts, progress and blockers
+func (s *TodoService) PresentTodos(viewerID int, todos ...*model.Todo) {
+	loc := s.prefService.Location(viewerID)
+	now := time.Now()
+	for _, todo := range todos {
+		todo.In(loc)
+		todo.Overdue = todo.IsOverdue(now)
+	}
+	s.tagService.LoadTags(todos...)
+	s.assigneeService.LoadAssignees(todos...)
+	s.commentService.LoadCounts(todos...)
+	s.subtaskService.LoadProgress(todos...)
+	s.dependencyService.LoadBlockers(todos...)
+}
+
+// todoChange is a planned change to a todo, along with what its follow-up needs once saved
+type todoChange struct {
+	write *model.TodoWrite
+
+	// What an edited todo looked like before the edit
+	before           model.TodoSnapshot
+	beforeAt         time.Time
+	previousParentID *int
+	wasCompleted     bool
+	wasAutoComplete  bool
+}
+
 // CreateTodo creates a new todo
 func (s *TodoService) CreateTodo(userID int, req model.CreateTodoRequest) (*model.Todo, error) {
+	change, err := s.planCreate(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.todoRepo.SaveTodos(change.write); err != nil {
+		return nil, fmt.Errorf("failed to create todo: %w", err)
+	}
+	return s.finishCreate(change, userID), nil
+}
+
+// planCreate checks a new todo and plans it along with its tags, checklist, assignees and
+// series, which are saved together
+func (s *TodoService) planCreate(userID int, req model.CreateTodoRequest) (*todoChange, error) {
 	if err := s.validator.Struct(req); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
 	todo := &model.Todo{
-		UserID:    userID,
-		Title:     req.Title,
-		Content:   req.Content,
-		Completed: false,
+		UserID:       userID,
+		Title:        req.Title,
+		Content:      req.Content,
+		Completed:    false,
+		DueAt:        utcTime(req.DueAt),
+		RemindAt:     utcTime(req.RemindAt),
+		ProjectID:    req.ProjectID,
+		ParentID:     req.ParentID,
+		AutoComplete: req.AutoComplete,
 	}
 
-	if err := s.todoRepo.CreateTodo(todo); err != nil {
-		return nil, fmt.Errorf("failed to create todo: %w", err)
+	if req.Priority != "" {
+		priority, err := model.ParsePriority(req.Priority)
+		if err != nil {
+			return nil, err
+		}
+		todo.Priority = priority
+	}
+	if req.ProjectID != nil {
+		if err := s.projectService.CheckAssignable(*req.ProjectID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if req.ParentID != nil {
+		if err := s.subtaskService.CheckParent(0, *req.ParentID, userID); err != nil {
+			return nil, err
+		}
 	}
 
-	return todo, nil
+	tagIDs, err := s.tagService.ResolveTags(userID, req.TagIDs)
+	if err != nil {
+		return nil, err
+	}
+	assigneeIDs, err := s.assigneeService.ResolveAssignees(userID, req.AssigneeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.workflowService.InitStatus(todo, req.Status); err != nil {
+		return nil, err
+	}
+
+	write := &model.TodoWrite{Todo: todo, Links: &model.TodoLinks{Checklist: req.Checklist, AssignedBy: userID}}
+	if len(tagIDs) > 0 {
+		write.Links.TagIDs = &tagIDs
+	}
+	if len(assigneeIDs) > 0 {
+		write.Links.AssigneeIDs = &assigneeIDs
+	}
+	if req.Recurrence != nil {
+		if write.Series, write.FirstAt, err = s.recurrenceService.PlanSeries(todo, *req.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	return &todoChange{write: write}, nil
+}
+
+// finishCreate follows up on a saved new todo and returns it
+func (s *TodoService) finishCreate(change *todoChange, userID int) *model.Todo {
+	todo := change.write.Todo
+	s.assigneeService.NotifyAssignments(todo, userID, change.write.Links)
+
+	// A new open subtask reopens an auto-completed parent
+	s.syncCompletion(todo.ParentID)
+
+	s.PresentTodos(userID, todo)
+	return todo
 }
 
-// GetTodoByID retrieves a todo by ID
-func (s *TodoService) GetTodoByID(id int) (*model.Todo, error) {
-	return s.todoRepo.GetTodoByID(id)
+// GetTodoByID retrieves a todo the user owns or is assigned to
+func (s *TodoService) GetTodoByID(id, userID int) (*model.Todo, error) {
+	return s.assigneeService.CheckAccess(id, userID)
 }
 
 // GetUserTodos retrieves todos for a user with pagination
@@ -60,11 +191,28 @@ func (s *TodoService) GetUserTodos(userID int, req model.PaginationRequest) (*mo
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	// Narrow the due date range to the user's current local day
+	if req.DueToday {
+		start, end := localDay(time.Now(), s.prefService.Location(userID))
+		if req.DueAfter == nil || req.DueAfter.Before(start) {
+			req.DueAfter = &start
+		}
+		if req.DueBefore == nil || req.DueBefore.After(end) {
+			req.DueBefore = &end
+		}
+	}
+
 	todos, total, err := s.todoRepo.GetTodosByUser(userID, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get todos: %w", err)
 	}
 
+	page := make([]*model.Todo, len(todos))
+	for i := range todos {
+		page[i] = &todos[i]
+	}
+	s.PresentTodos(userID, page...)
+
 	totalPages := int((total + int64(req.Limit) - 1) / int64(req.Limit))
 	
 	pagination := model.Pagination{
@@ -82,10 +230,39 @@ func (s *TodoService) GetUserTodos(userID int, req model.PaginationRequest) (*mo
 	}, nil
 }
 
-// UpdateTodo updates a todo
-func (s *TodoService) UpdateTodo(id, userID int, req model.UpdateTodoRequest) (*model.Todo, error) {
-	if err := s.validator.Struct(req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+// UpdateTodo updates a todo and records the change in its history. For an occurrence of a
+// recurring todo, scope "series" applies the change to the whole series instead of this
+// occurrence only; completing an occurrence generates the next one.
+func (s *TodoService) UpdateTodo(id, userID int, req model.UpdateTodoRequest, scope string) (*model.Todo, error) {
+	return s.updateTodo(id, userID, req, scope, nil)
+}
+
+// RestoreTodo rolls a todo back to an earlier version of its history. The rollback is applied
+// as an edit, so it is validated like one and recorded as the newest version.
+func (s *TodoService) RestoreTodo(id, userID, version int) (*model.Todo, error) {
+	todo, err := s.todoRepo.GetTodoByID(id)
+	if err != nil || todo.UserID != userID {
+		return nil, fmt.Errorf("todo not found or access denied")
+	}
+	revision, err := s.historyService.GetRevision(id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	s.tagService.LoadTags(todo)
+	s.assigneeService.LoadAssignees(todo)
+	req := revision.Snapshot.RestoreRequest(model.SnapshotTodo(todo))
+	todo, err = s.updateTodo(id, userID, req, model.EditScopeThis, &version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore version %d: %w", version, err)
+	}
+	return todo, nil
+}
+
+// updateTodo applies an edit; restoredFrom is the version a restore rolls back to
+func (s *TodoService) updateTodo(id, userID int, req model.UpdateTodoRequest, scope string, restoredFrom *int) (*model.Todo, error) {
+	if err := validateScope(scope); err != nil {
+		return nil, err
 	}
 
 	// Get existing todo
@@ -94,32 +271,247 @@ func (s *TodoService) UpdateTodo(id, userID int, req model.UpdateTodoRequest) (*
 		return nil, fmt.Errorf("todo not found: %w", err)
 	}
 
-	// Check ownership
+	change, err := s.planUpdate(todo, userID, req, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if scope == model.EditScopeSeries {
+		if err := s.recurrenceService.UpdateSeries(todo, req); err != nil {
+			return nil, err
+		}
+		links := change.write.Links
+		if err := s.todoRepo.SetTodoLinks(todo.ID, links); err != nil {
+			return nil, err
+		}
+		s.assigneeService.NotifyAssignments(todo, userID, links)
+		if req.ProjectID.Set {
+			if _, err := s.todoRepo.MoveTodos(userID, []int{todo.ID}, req.ProjectID.ID); err != nil {
+				return nil, err
+			}
+		}
+
+		if todo, err = s.todoRepo.GetTodoByID(id); err != nil {
+			return nil, fmt.Errorf("todo not found: %w", err)
+		}
+		s.PresentTodos(userID, todo)
+		s.historyService.Record(todo, change.before, change.beforeAt, userID, restoredFrom)
+		return todo, nil
+	}
+
+	if err := s.todoRepo.SaveTodos(change.write); err != nil {
+		return nil, fmt.Errorf("failed to update todo: %w", err)
+	}
+	return s.finishUpdate(change, userID, restoredFrom)
+}
+
+// planUpdate checks an edit of todo by userID and applies it to todo, which is saved along with
+// its tags, checklist, assignees and any new series. In scope "series" the edit is only
+// checked; the series is updated separately.
+func (s *TodoService) planUpdate(todo *model.Todo, userID int, req model.UpdateTodoRequest, scope string) (*todoChange, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// Check ownership; assignees may only move the todo along its workflow
 	if todo.UserID != userID {
-		return nil, fmt.Errorf("access denied")
+		if !s.assigneeService.IsAssignee(todo.ID, userID) {
+			return nil, fmt.Errorf("access denied")
+		}
+		if !req.StatusOnly() || scope == model.EditScopeSeries {
+			return nil, errors.New("assignees can only change the status of a todo they do not own")
+		}
+	}
+	if todo.ArchivedAt != nil {
+		return nil, ErrTodoArchived
+	}
+
+	// Snapshot the todo for its history before it changes
+	s.tagService.LoadTags(todo)
+	s.assigneeService.LoadAssignees(todo)
+	change := &todoChange{
+		before:           model.SnapshotTodo(todo),
+		beforeAt:         todo.UpdatedAt,
+		previousParentID: todo.ParentID,
+		wasCompleted:     todo.Completed,
+		wasAutoComplete:  todo.AutoComplete,
+	}
+
+	// Tags and assignees belong to this todo in either scope; the next occurrence copies them
+	var tagIDs, assigneeIDs []int
+	var err error
+	if req.TagIDs != nil {
+		if tagIDs, err = s.tagService.ResolveTags(userID, *req.TagIDs); err != nil {
+			return nil, err
+		}
+	}
+	if req.AssigneeIDs != nil {
+		if assigneeIDs, err = s.assigneeService.ResolveAssignees(userID, *req.AssigneeIDs); err != nil {
+			return nil, err
+		}
+	}
+	if req.ProjectID.Set && req.ProjectID.ID != nil {
+		if err := s.projectService.CheckAssignable(*req.ProjectID.ID, userID); err != nil {
+			return nil, err
+		}
+	}
+	change.write = &model.TodoWrite{Todo: todo, Links: editLinks(userID, req, tagIDs, assigneeIDs)}
+
+	if scope == model.EditScopeSeries {
+		if todo.SeriesID == nil {
+			return nil, errors.New("todo is not recurring")
+		}
+		if req.ParentID.Set || req.AutoComplete != nil {
+			return nil, errors.New("subtask settings apply to a single occurrence; use scope=this")
+		}
+		if req.Status != nil || req.Priority != nil {
+			return nil, errors.New("status and priority apply to a single occurrence; use scope=this")
+		}
+		return change, nil
+	}
+
+	if req.Recurrence != nil && todo.SeriesID != nil {
+		return nil, errors.New("todo is already recurring; use scope=series to change its rule")
+	}
+
+	if req.ParentID.Set && req.ParentID.ID != nil {
+		if err := s.subtaskService.CheckParent(todo.ID, *req.ParentID.ID, userID); err != nil {
+			return nil, err
+		}
 	}
 
 	// Update fields
+	previousProjectID, previousStatus := todo.ProjectID, todo.Status
 	if req.Title != nil {
 		todo.Title = *req.Title
 	}
 	if req.Content != nil {
 		todo.Content = *req.Content
 	}
-	if req.Completed != nil {
-		todo.Completed = *req.Completed
+	if req.DueAt.Set {
+		todo.DueAt = utcTime(req.DueAt.Time)
+	}
+	if req.RemindAt.Set {
+		// A new reminder time fires again even if the old one already did
+		todo.RemindAt = utcTime(req.RemindAt.Time)
+		todo.RemindedAt = nil
+	}
+	if req.ProjectID.Set {
+		todo.ProjectID = req.ProjectID.ID
+	}
+	if req.Priority != nil {
+		if todo.Priority, err = model.ParsePriority(*req.Priority); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := s.todoRepo.UpdateTodo(todo); err != nil {
-		return nil, fmt.Errorf("failed to update todo: %w", err)
+	// Completion and status move together along the workflow of the todo's project
+	if err := s.workflowService.ApplyStatus(todo, req.Status, req.Completed); err != nil {
+		return nil, err
+	}
+	if todo.Completed && !change.wasCompleted {
+		if err := s.dependencyService.CheckCompletion(todo); err != nil {
+			return nil, err
+		}
 	}
+	change.write.Reposition = todo.Status != previousStatus || !sameID(todo.ProjectID, previousProjectID)
 
+	if req.ParentID.Set {
+		todo.ParentID = req.ParentID.ID
+	}
+	if req.AutoComplete != nil {
+		todo.AutoComplete = *req.AutoComplete
+	}
+	if req.Recurrence != nil {
+		if change.write.Series, change.write.FirstAt, err = s.recurrenceService.PlanSeries(todo, *req.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	return change, nil
+}
+
+// finishUpdate follows up on a saved edit and returns the todo
+func (s *TodoService) finishUpdate(change *todoChange, userID int, restoredFrom *int) (*model.Todo, error) {
+	todo := change.write.Todo
+	s.assigneeService.NotifyAssignments(todo, userID, change.write.Links)
+
+	if todo.Completed && !change.wasCompleted && todo.SeriesID != nil {
+		s.advanceSeries(*todo.SeriesID)
+	}
+
+	// Keep auto-completing parents in line with their subtasks
+	if !sameID(change.previousParentID, todo.ParentID) {
+		s.syncCompletion(change.previousParentID, todo.ParentID)
+	} else if todo.Completed != change.wasCompleted {
+		s.syncCompletion(todo.ParentID)
+	}
+	if todo.AutoComplete && !change.wasAutoComplete {
+		s.syncCompletion(&todo.ID)
+		var err error
+		if todo, err = s.todoRepo.GetTodoByID(todo.ID); err != nil {
+			return nil, fmt.Errorf("todo not found: %w", err)
+		}
+	}
+
+	s.PresentTodos(userID, todo)
+	s.historyService.Record(todo, change.before, change.beforeAt, userID, restoredFrom)
 	return todo, nil
 }
 
-// DeleteTodo deletes a todo
-func (s *TodoService) DeleteTodo(id, userID int) error {
-	return s.todoRepo.DeleteTodo(id, userID)
+// DeleteTodo moves a todo and its subtasks to the trash. Deleting an occurrence of a recurring
+// todo skips that date and schedules the next one; scope "series" deletes the series and trashes
+// its open occurrences.
+func (s *TodoService) DeleteTodo(id, userID int, meta model.RequestMeta, scope string) error {
+	if err := validateScope(scope); err != nil {
+		return err
+	}
+
+	// Snapshot the todo for the audit trail before it is trashed
+	todo, err := s.todoRepo.GetTodoByID(id)
+	if err != nil || todo.UserID != userID {
+		return fmt.Errorf("todo not found or access denied")
+	}
+
+	if scope == model.EditScopeSeries {
+		if todo.SeriesID == nil {
+			return errors.New("todo is not recurring")
+		}
+		series, err := s.recurrenceService.DeleteSeries(todo)
+		if err != nil {
+			return err
+		}
+
+		s.auditService.RecordBestEffort(meta, model.AuditEntry{
+			Action:     "todo_series.deleted",
+			TargetType: "todo_series",
+			TargetID:   strconv.Itoa(series.ID),
+			Before:     series,
+		})
+		return nil
+	}
+
+	if err := s.todoRepo.DeleteTodo(id, userID); err != nil {
+		return err
+	}
+	s.finishDelete(todo, meta)
+	return nil
+}
+
+// finishDelete follows up on a todo moved to the trash
+func (s *TodoService) finishDelete(todo *model.Todo, meta model.RequestMeta) {
+	if todo.SeriesID != nil {
+		if err := s.recurrenceService.SkipOccurrence(todo); err != nil {
+			log.Printf("Warning: Failed to skip occurrence of series %d: %v", *todo.SeriesID, err)
+		}
+	}
+	s.syncCompletion(todo.ParentID)
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "todo.deleted",
+		TargetType: "todo",
+		TargetID:   strconv.Itoa(todo.ID),
+		Before:     todo,
+	})
 }
 
 // GetAllTodos retrieves all todos (admin only)
@@ -156,4 +548,233 @@ func (s *TodoService) GetAllTodos(req model.PaginationRequest) (*model.Paginated
 		Data:       todos,
 		Pagination: pagination,
 	}, nil
-}
\ No newline at end of file
+}
+// Occurrences lists the upcoming occurrences of a recurring todo owned by userID
+func (s *TodoService) Occurrences(id, userID, limit int) (*model.OccurrencesResponse, error) {
+	todo, err := s.todoRepo.GetTodoByID(id)
+	if err != nil || todo.UserID != userID {
+		return nil, fmt.Errorf("todo not found or access denied")
+	}
+	return s.recurrenceService.Occurrences(todo, limit)
+}
+
+// MoveTodo moves a todo owned by userID in their manual order
+func (s *TodoService) MoveTodo(id, userID int, req model.MoveTodoRequest) (*model.Todo, error) {
+	if err := s.positionService.Move(id, userID, req); err != nil {
+		return nil, err
+	}
+
+	todo, err := s.todoRepo.GetTodoByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("todo not found: %w", err)
+	}
+	s.PresentTodos(userID, todo)
+	return todo, nil
+}
+
+// Next returns up to limit of a user's pending todos, most pressing first, each with its score
+func (s *TodoService) Next(userID, limit int) ([]*model.Todo, error) {
+	todos, err := s.nextService.Rank(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.PresentTodos(userID, todos...)
+	return todos, nil
+}
+
+// Board returns the todos of a project owned by userID grouped into the columns of its workflow
+func (s *TodoService) Board(projectID, userID int) (*model.Board, error) {
+	project, err := s.projectService.GetProject(projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	workflow, err := s.workflowService.Workflow(&projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := s.todoRepo.GetBoardTodos(userID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	cards := make([]*model.Todo, len(todos))
+	for i := range todos {
+		cards[i] = &todos[i]
+	}
+	s.PresentTodos(userID, cards...)
+
+	board := &model.Board{Project: project, Columns: make([]model.BoardColumn, len(workflow))}
+	columns := make(map[string]*model.BoardColumn, len(workflow))
+	for i, status := range workflow {
+		board.Columns[i] = model.BoardColumn{Status: status, Todos: []*model.Todo{}}
+		columns[status.Key] = &board.Columns[i]
+	}
+	for _, card := range cards {
+		// Statuses outside the workflow are fitted on the next change; show them in the first column
+		column, ok := columns[card.Status]
+		if !ok {
+			column = &board.Columns[0]
+		}
+		column.Todos = append(column.Todos, card)
+	}
+	return board, nil
+}
+
+// MoveOnBoard moves a todo of a project's board to a position in a status column, following
+// the workflow's transitions and completing or reopening the todo to match the status
+func (s *TodoService) MoveOnBoard(projectID, userID int, req model.BoardMoveRequest) (*model.Todo, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	todo, err := s.todoRepo.GetTodoByID(req.TodoID)
+	if err != nil || todo.UserID != userID || todo.ProjectID == nil || *todo.ProjectID != projectID || todo.ArchivedAt != nil {
+		return nil, fmt.Errorf("todo not found on this board")
+	}
+
+	wasCompleted := todo.Completed
+	if err := s.workflowService.ApplyStatus(todo, &req.Status, nil); err != nil {
+		return nil, err
+	}
+	if todo.Completed && !wasCompleted {
+		if err := s.dependencyService.CheckCompletion(todo); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.todoRepo.MoveOnBoard(todo, req.Position); err != nil {
+		return nil, err
+	}
+
+	if todo.Completed != wasCompleted {
+		if todo.Completed && todo.SeriesID != nil {
+			s.advanceSeries(*todo.SeriesID)
+		}
+		s.syncCompletion(todo.ParentID)
+	}
+
+	if todo, err = s.todoRepo.GetTodoByID(req.TodoID); err != nil {
+		return nil, fmt.Errorf("todo not found: %w", err)
+	}
+	s.PresentTodos(userID, todo)
+	return todo, nil
+}
+
+// Dependencies retrieves the todos blocking a todo owned by userID and the todos it blocks
+func (s *TodoService) Dependencies(id, userID int) (*model.TodoDependencies, error) {
+	dependencies, err := s.dependencyService.GetDependencies(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.PresentTodos(userID, dependencies.BlockedBy...)
+	s.PresentTodos(userID, dependencies.Blocking...)
+	return dependencies, nil
+}
+
+// GetTodoTree retrieves a todo userID owns or is assigned to with all of its subtasks nested
+// under it, including their checklists and progress
+func (s *TodoService) GetTodoTree(id, userID int) (*model.Todo, error) {
+	if _, err := s.assigneeService.CheckAccess(id, userID); err != nil {
+		return nil, err
+	}
+
+	todos, err := s.todoRepo.GetSubtree(id)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]*model.Todo, len(todos))
+	nodes := make([]*model.Todo, len(todos))
+	for i := range todos {
+		nodes[i] = &todos[i]
+		byID[todos[i].ID] = &todos[i]
+	}
+
+	root, ok := byID[id]
+	if !ok {
+		return nil, fmt.Errorf("todo not found or access denied")
+	}
+
+	s.PresentTodos(userID, nodes...)
+	if err := s.subtaskService.LoadChecklists(nodes...); err != nil {
+		return nil, err
+	}
+
+	for _, node := range nodes {
+		if node.ID == id {
+			continue
+		}
+		if parent, ok := byID[*node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return root, nil
+}
+
+// syncCompletion updates auto-completing parents after one of their subtasks changed, creating
+// the next occurrence of any recurring parent it completes
+func (s *TodoService) syncCompletion(parentIDs ...*int) {
+	for _, parentID := range parentIDs {
+		completed, err := s.subtaskService.SyncCompletion(parentID)
+		if err != nil {
+			log.Printf("Warning: Failed to update parent todo completion: %v", err)
+		}
+		for _, parent := range completed {
+			if parent.SeriesID != nil {
+				s.advanceSeries(*parent.SeriesID)
+			}
+		}
+	}
+}
+
+// advanceSeries creates the next occurrence of a series after one was completed
+func (s *TodoService) advanceSeries(seriesID int) {
+	if _, err := s.recurrenceService.Advance(seriesID); err != nil {
+		log.Printf("Warning: Failed to create next occurrence of series %d: %v", seriesID, err)
+	}
+}
+
+// editLinks collects the resolved tags and assignees an edit replaces; lists missing from the
+// request are left unchanged
+func editLinks(actorID int, req model.UpdateTodoRequest, tagIDs, assigneeIDs []int) *model.TodoLinks {
+	links := &model.TodoLinks{AssignedBy: actorID}
+	if req.TagIDs != nil {
+		links.TagIDs = &tagIDs
+	}
+	if req.AssigneeIDs != nil {
+		links.AssigneeIDs = &assigneeIDs
+	}
+	return links
+}
+
+// validateScope checks the edit scope of a change to a recurring todo
+func validateScope(scope string) error {
+	switch scope {
+	case "", model.EditScopeThis, model.EditScopeSeries:
+		return nil
+	}
+	return fmt.Errorf("invalid scope %q: use %s or %s", scope, model.EditScopeThis, model.EditScopeSeries)
+}
+
+// sameID reports whether two optional IDs are equal
+func sameID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// utcTime normalizes an optional time to UTC
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// localDay returns the bounds of the day containing now in loc
+func localDay(now time.Time, loc *time.Location) (time.Time, time.Time) {
+	local := now.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	return start, start.AddDate(0, 0, 1)
+}
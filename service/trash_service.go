@@ -0,0 +1,169 @@
+package service
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+)
+
+// defaultTrashLimit is how many trashed todos are listed per page when no limit is given
+const defaultTrashLimit = 20
+
+// TrashConfig holds trash configuration
+type TrashConfig struct {
+	RetentionDays int           // Days deleted todos stay in the trash before they are purged
+	PurgeInterval time.Duration // How often expired trash is purged
+	PurgeBatch    int           // Todos purged per delete statement
+}
+
+// DefaultTrashConfig returns trash configuration from the environment
+func DefaultTrashConfig() TrashConfig {
+	return TrashConfig{
+		RetentionDays: getEnvInt("TRASH_RETENTION_DAYS", 30),
+		PurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+		PurgeBatch:    getEnvInt("TRASH_PURGE_BATCH_SIZE", 500),
+	}
+}
+
+type TrashService struct {
+	todoRepo     *repository.TodoRepository
+	todoService  *TodoService
+	auditService *AuditService
+	config       TrashConfig
+}
+
+func NewTrashService(todoRepo *repository.TodoRepository, todoService *TodoService, auditService *AuditService, config TrashConfig) *TrashService {
+	return &TrashService{
+		todoRepo:     todoRepo,
+		todoService:  todoService,
+		auditService: auditService,
+		config:       config,
+	}
+}
+
+// ListTrash returns a page of the todos a user deleted, most recently deleted first, with the
+// time each will be purged
+func (s *TrashService) ListTrash(userID, page, limit int) (*model.PaginatedResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = defaultTrashLimit
+	}
+
+	todos, total, err := s.todoRepo.GetTrash(userID, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*model.Todo, len(todos))
+	for i := range todos {
+		items[i] = &todos[i]
+		todos[i].SchedulePurge(s.config.RetentionDays)
+	}
+	s.todoService.PresentTodos(userID, items...)
+
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return &model.PaginatedResponse{
+		Data: items,
+		Pagination: model.Pagination{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
+	}, nil
+}
+
+// Restore takes a todo out of the trash along with the subtasks deleted with it
+func (s *TrashService) Restore(id, userID int, meta model.RequestMeta) (*model.Todo, error) {
+	todo, err := s.todoRepo.RestoreTodo(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.todoService.syncCompletion(todo.ParentID)
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "todo.restored",
+		TargetType: "todo",
+		TargetID:   strconv.Itoa(id),
+	})
+
+	s.todoService.PresentTodos(userID, todo)
+	return todo, nil
+}
+
+// DeleteForever permanently deletes a trashed todo and its trashed subtasks
+func (s *TrashService) DeleteForever(id, userID int, meta model.RequestMeta) error {
+	if err := s.todoRepo.DeleteTrashedTodo(id, userID); err != nil {
+		return err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "todo.purged",
+		TargetType: "todo",
+		TargetID:   strconv.Itoa(id),
+	})
+	return nil
+}
+
+// EmptyTrash permanently deletes all of a user's trashed todos
+func (s *TrashService) EmptyTrash(userID int, meta model.RequestMeta) (int64, error) {
+	purged, err := s.todoRepo.EmptyTrash(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "todo.trash_emptied",
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+		After:      map[string]interface{}{"purged": purged},
+	})
+	return purged, nil
+}
+
+// PurgeExpired permanently deletes todos that have been in the trash longer than the retention
+// window and returns how many were deleted
+func (s *TrashService) PurgeExpired() (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -s.config.RetentionDays)
+
+	var total int64
+	for {
+		purged, err := s.todoRepo.PurgeTrash(cutoff, s.config.PurgeBatch)
+		total += purged
+		if err != nil {
+			return total, err
+		}
+		if purged < int64(s.config.PurgeBatch) {
+			return total, nil
+		}
+	}
+}
+
+// StartPurgeWorker periodically purges expired trash
+func (s *TrashService) StartPurgeWorker() {
+	if s.config.RetentionDays <= 0 || s.config.PurgeInterval <= 0 || s.config.PurgeBatch <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.config.PurgeInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			purged, err := s.PurgeExpired()
+			if err != nil {
+				log.Printf("Warning: Failed to purge trash: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Purged %d todos deleted more than %d days ago", purged, s.config.RetentionDays)
+			}
+		}
+	}()
+}
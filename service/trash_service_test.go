@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"jmrashed/apps/userApp/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashPurgeExpired(t *testing.T) {
+	env := newTestEnv(t)
+	env.trashService.config.RetentionDays = 30
+	// Purge one todo per statement so the batches loop
+	env.trashService.config.PurgeBatch = 1
+
+	user := env.createUser(t)
+	expired := env.createTodo(t, user.ID, "Expired", nil)
+	subtask := env.createTodo(t, user.ID, "Subtask", &expired.ID)
+	recent := env.createTodo(t, user.ID, "Recent", nil)
+	kept := env.createTodo(t, user.ID, "Kept", nil)
+
+	require.NoError(t, env.todoService.DeleteTodo(expired.ID, user.ID, model.RequestMeta{}, model.EditScopeThis))
+	require.NoError(t, env.todoService.DeleteTodo(recent.ID, user.ID, model.RequestMeta{}, model.EditScopeThis))
+	_, err := env.db.Exec(`UPDATE todos SET deleted_at = deleted_at - INTERVAL 31 DAY WHERE id IN (?, ?)`,
+		expired.ID, subtask.ID)
+	require.NoError(t, err)
+
+	purged, err := env.trashService.PurgeExpired()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, int64(1))
+
+	// The expired todo goes with its subtask; recently trashed and live todos stay
+	remaining := map[int]bool{}
+	rows, err := env.db.Query(`SELECT id FROM todos WHERE user_id = ?`, user.ID)
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		require.NoError(t, rows.Scan(&id))
+		remaining[id] = true
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, map[int]bool{recent.ID: true, kept.ID: true}, remaining)
+
+	trash, total, err := env.todoRepo.GetTrash(user.ID, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, trash, 1)
+	assert.Equal(t, recent.ID, trash[0].ID)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"jmrashed/apps/userApp/model"
+	"jmrashed/apps/userApp/repository"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type WorkflowService struct {
+	workflowRepo *repository.WorkflowRepository
+	projectRepo  *repository.ProjectRepository
+	todoRepo     *repository.TodoRepository
+	auditService *AuditService
+	validator    *validator.Validate
+}
+
+func NewWorkflowService(workflowRepo *repository.WorkflowRepository, projectRepo *repository.ProjectRepository,
+	todoRepo *repository.TodoRepository, auditService *AuditService) *WorkflowService {
+	return &WorkflowService{
+		workflowRepo: workflowRepo,
+		projectRepo:  projectRepo,
+		todoRepo:     todoRepo,
+		auditService: auditService,
+		validator:    validator.New(),
+	}
+}
+
+// Workflow returns the workflow of a project, or the default workflow for todos outside a
+// project and projects without one of their own
+func (s *WorkflowService) Workflow(projectID *int) (model.Workflow, error) {
+	if projectID == nil {
+		return model.DefaultWorkflow(), nil
+	}
+
+	workflow, err := s.workflowRepo.GetWorkflow(*projectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(workflow) == 0 {
+		return model.DefaultWorkflow(), nil
+	}
+	return workflow, nil
+}
+
+// GetWorkflow returns the workflow of a project owned by userID
+func (s *WorkflowService) GetWorkflow(projectID, userID int) (model.Workflow, error) {
+	if err := s.checkProject(projectID, userID); err != nil {
+		return nil, err
+	}
+	return s.Workflow(&projectID)
+}
+
+// UpdateWorkflow replaces the workflow of a project owned by userID. Todos whose status is no
+// longer part of it move to the first open or done status.
+func (s *WorkflowService) UpdateWorkflow(projectID, userID int, req model.UpdateWorkflowRequest, meta model.RequestMeta) (model.Workflow, error) {
+	if err := s.validator.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	workflow := model.Workflow(req.Statuses)
+	for i := range workflow {
+		if workflow[i].Transitions == nil {
+			workflow[i].Transitions = []string{}
+		}
+	}
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkProject(projectID, userID); err != nil {
+		return nil, err
+	}
+	before, err := s.Workflow(&projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.workflowRepo.ReplaceWorkflow(projectID, workflow); err != nil {
+		return nil, err
+	}
+	if _, err := s.todoRepo.FitStatuses(userID, &projectID, workflow); err != nil {
+		return nil, err
+	}
+
+	s.auditService.RecordBestEffort(meta, model.AuditEntry{
+		Action:     "project.workflow_updated",
+		TargetType: "project",
+		TargetID:   strconv.Itoa(projectID),
+		Before:     before,
+		After:      workflow,
+	})
+	return workflow, nil
+}
+
+// FitTodos moves a user's todos in a project, or outside any project when projectID is nil,
+// into that workflow
+func (s *WorkflowService) FitTodos(userID int, projectID *int) error {
+	workflow, err := s.Workflow(projectID)
+	if err != nil {
+		return err
+	}
+	_, err = s.todoRepo.FitStatuses(userID, projectID, workflow)
+	return err
+}
+
+// InitStatus sets the status of a new todo: the given one, or the first open status of its
+// workflow. A done status completes the todo.
+func (s *WorkflowService) InitStatus(todo *model.Todo, status string) error {
+	workflow, err := s.Workflow(todo.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	initial := workflow.Initial()
+	if status != "" {
+		if initial = workflow.Find(status); initial == nil {
+			return fmt.Errorf("unknown status %q", status)
+		}
+	}
+	setStatus(todo, initial)
+	return nil
+}
+
+// ApplyStatus moves a todo to a new status or completion, keeping the two consistent and
+// following the workflow's transitions. The todo is first fitted into the workflow of its
+// project, which may have changed.
+func (s *WorkflowService) ApplyStatus(todo *model.Todo, status *string, completed *bool) error {
+	workflow, err := s.Workflow(todo.ProjectID)
+	if err != nil {
+		return err
+	}
+	setStatus(todo, workflow.Find(workflow.Fit(todo.Status, todo.Completed)))
+
+	var next *model.WorkflowStatus
+	switch {
+	case status != nil:
+		if next = workflow.Find(*status); next == nil {
+			return fmt.Errorf("unknown status %q", *status)
+		}
+		if completed != nil && *completed != next.Done {
+			return errors.New("completed does not match the done state of the status")
+		}
+	case completed != nil && *completed != todo.Completed:
+		next = workflow.Find(workflow.Fit("", *completed))
+	default:
+		return nil
+	}
+
+	if !workflow.CanTransition(todo.Status, next.Key) {
+		return fmt.Errorf("cannot move a todo from %q to %q", todo.Status, next.Key)
+	}
+	setStatus(todo, next)
+	return nil
+}
+
+// SetCompleted completes or reopens a todo on behalf of the system, moving it to the first
+// done or open status regardless of transitions
+func (s *WorkflowService) SetCompleted(todo *model.Todo, completed bool) error {
+	workflow, err := s.Workflow(todo.ProjectID)
+	if err != nil {
+		return err
+	}
+	setStatus(todo, workflow.Find(workflow.Fit(todo.Status, completed)))
+	return nil
+}
+
+func (s *WorkflowService) checkProject(projectID, userID int) error {
+	project, err := s.projectRepo.GetProjectByID(projectID)
+	if err != nil || project.UserID != userID {
+		return fmt.Errorf("project not found or access denied")
+	}
+	return nil
+}
+
+// setStatus puts a todo in a status, completing or reopening it to match
+func setStatus(todo *model.Todo, status *model.WorkflowStatus) {
+	todo.Status = status.Key
+	if status.Done == todo.Completed {
+		return
+	}
+
+	todo.Completed = status.Done
+	todo.CompletedAt = nil
+	if todo.Completed {
+		now := time.Now().UTC()
+		todo.CompletedAt = &now
+	}
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Storage drivers
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// ErrNotFound is returned when a blob does not exist
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores binary objects under slash-separated keys
+type BlobStore interface {
+	Put(key string, r io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	URL(key string) string
+}
+
+// Config selects and configures a BlobStore implementation
+type Config struct {
+	Driver        string
+	LocalDir      string
+	PublicBaseURL string
+
+	S3Endpoint     string
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3UsePathStyle bool
+}
+
+// New creates the BlobStore selected by config.Driver
+func New(config Config) (BlobStore, error) {
+	switch config.Driver {
+	case DriverLocal, "":
+		return NewLocalStore(config.LocalDir, config.PublicBaseURL)
+	case DriverS3:
+		return NewS3Store(S3Config{
+			Endpoint:      config.S3Endpoint,
+			Region:        config.S3Region,
+			Bucket:        config.S3Bucket,
+			AccessKey:     config.S3AccessKey,
+			SecretKey:     config.S3SecretKey,
+			UsePathStyle:  config.S3UsePathStyle,
+			PublicBaseURL: config.PublicBaseURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.Driver)
+	}
+}
+
+// validateKey rejects keys that could escape the store's namespace
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}